@@ -0,0 +1,284 @@
+package mtp
+
+import (
+	"maps"
+	pathpkg "path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// PathAnnotation attaches an annotation to a command by its path below the
+// root (e.g. []string{"db", "migrate"}). Each segment may be the command's
+// name or one of its aliases. An empty path annotates the root command.
+type PathAnnotation struct {
+	Path       []string
+	Annotation *CommandAnnotation
+}
+
+// MatchAnnotation applies an annotation to every command for which Match
+// returns true. Match gets the command's canonical path below the root;
+// the root's path is empty.
+type MatchAnnotation struct {
+	Match      func(path []string) bool
+	Annotation *CommandAnnotation
+}
+
+// lookupAnnotation returns the annotation for cmd, whose schema name is
+// name: its own annotation (see exactAnnotation), then the one Register
+// derived for it, merged over those of the families it belongs to. Family
+// annotations come from Commands keys with glob patterns ("db *"), most
+// words first and then alphabetically, then from DescribeOptions.Match
+// entries in order, and last from DescribeOptions.Defaults. Earlier
+// annotations win field by field; see mergeAnnotation.
+func lookupAnnotation(cmd *cobra.Command, name string, opts *DescribeOptions) *CommandAnnotation {
+	registered := registeredAnnotation(cmd)
+	if opts == nil {
+		return registered
+	}
+	ann := mergeAnnotation(exactAnnotation(cmd, name, opts), registered)
+
+	chain := commandChain(cmd, name, opts)
+	path := make([]string, len(chain))
+	for i, c := range chain {
+		path[i] = c.Name()
+	}
+	for _, key := range patternKeys(opts.Commands) {
+		if matchPattern(strings.Fields(key), path) {
+			ann = mergeAnnotation(ann, opts.Commands[key])
+		}
+	}
+	for _, m := range opts.Match {
+		if m.Match != nil && m.Match(path) {
+			ann = mergeAnnotation(ann, m.Annotation)
+		}
+	}
+	return mergeAnnotation(ann, opts.Defaults)
+}
+
+// exactAnnotation finds the annotation addressed to cmd itself.
+// Precedence, highest first:
+//
+//  1. DescribeOptions.Paths entry matching the canonical path
+//  2. DescribeOptions.Commands entry keyed by the canonical name
+//  3. DescribeOptions.Paths entry matching via aliases
+//  4. DescribeOptions.Commands entry whose key matches via aliases
+//     (e.g. "db mig" where "mig" is an alias of "migrate")
+//
+// The root command can also be keyed by "_root" or the tool's name, below
+// RootName.
+//
+// Ties at the same level go to the first Paths entry or the alphabetically
+// first Commands key.
+func exactAnnotation(cmd *cobra.Command, name string, opts *DescribeOptions) *CommandAnnotation {
+	chain := commandChain(cmd, name, opts)
+
+	for _, pa := range opts.Paths {
+		if pathEquals(pa.Path, chain) {
+			return pa.Annotation
+		}
+	}
+	if ann, ok := opts.Commands[name]; ok {
+		return ann
+	}
+	for _, pa := range opts.Paths {
+		if pathMatchesAliases(pa.Path, chain) {
+			return pa.Annotation
+		}
+	}
+
+	if len(chain) == 0 {
+		for _, key := range []string{"_root", cmd.Name()} {
+			if ann, ok := opts.Commands[key]; ok {
+				return ann
+			}
+		}
+		return nil
+	}
+	keys := make([]string, 0, len(opts.Commands))
+	for k := range opts.Commands {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if pathMatchesAliases(strings.Fields(k), chain) {
+			return opts.Commands[k]
+		}
+	}
+	return nil
+}
+
+// commandChain returns the commands from just below the described root down
+// to cmd. name is cmd's schema name and fixes how many levels to include;
+// the root itself has an empty chain.
+func commandChain(cmd *cobra.Command, name string, opts *DescribeOptions) []*cobra.Command {
+	if name == rootName(opts) {
+		return nil
+	}
+	depth := len(strings.Fields(name))
+	chain := make([]*cobra.Command, depth)
+	for i := depth - 1; i >= 0 && cmd != nil; i-- {
+		chain[i] = cmd
+		cmd = cmd.Parent()
+	}
+	return chain
+}
+
+// pathEquals reports whether path names exactly the commands in chain. An
+// empty path names the root.
+func pathEquals(path []string, chain []*cobra.Command) bool {
+	if len(path) != len(chain) {
+		return false
+	}
+	for i, seg := range path {
+		if seg != chain[i].Name() {
+			return false
+		}
+	}
+	return true
+}
+
+// pathMatchesAliases reports whether each segment of path is the name or an
+// alias of the corresponding command in chain.
+func pathMatchesAliases(path []string, chain []*cobra.Command) bool {
+	if len(path) != len(chain) || len(path) == 0 {
+		return false
+	}
+	for i, seg := range path {
+		if seg != chain[i].Name() && !chain[i].HasAlias(seg) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPattern reports whether a Commands key is a glob pattern.
+func isPattern(key string) bool {
+	return strings.ContainsAny(key, "*?[")
+}
+
+// patternKeys returns the pattern keys of commands, those with the most
+// words first, then alphabetically.
+func patternKeys(commands map[string]*CommandAnnotation) []string {
+	var keys []string
+	for k := range commands {
+		if isPattern(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ni, nj := len(strings.Fields(keys[i])), len(strings.Fields(keys[j]))
+		if ni != nj {
+			return ni > nj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// matchPattern reports whether path matches pattern word by word, using
+// path.Match syntax. A final "*" word matches one or more words, so
+// "db *" covers every command below db.
+func matchPattern(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return false
+	}
+	if pattern[len(pattern)-1] == "*" {
+		if len(path) < len(pattern) {
+			return false
+		}
+		pattern, path = pattern[:len(pattern)-1], path[:len(pattern)-1]
+	} else if len(path) != len(pattern) {
+		return false
+	}
+	for i, p := range pattern {
+		if ok, err := pathpkg.Match(p, path[i]); !ok || err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeAnnotation returns an annotation with each field of primary, or of
+// fallback where primary leaves it unset. Maps are merged key by key, with
+// primary's entries winning. Neither argument is modified.
+func mergeAnnotation(primary, fallback *CommandAnnotation) *CommandAnnotation {
+	if fallback == nil {
+		return primary
+	}
+	if primary == nil {
+		return fallback
+	}
+	m := *primary
+	if m.Args == nil {
+		m.Args = fallback.Args
+	}
+	m.ArgTypes = mergeMaps(primary.ArgTypes, fallback.ArgTypes)
+	if m.Stdin == nil {
+		m.Stdin = fallback.Stdin
+	}
+	if m.Stdout == nil {
+		m.Stdout = fallback.Stdout
+	}
+	if m.Examples == nil {
+		m.Examples = fallback.Examples
+	}
+	if m.Auth == nil {
+		m.Auth = fallback.Auth
+	}
+	m.MayElicit = m.MayElicit || fallback.MayElicit
+	if m.Requires == nil {
+		m.Requires = fallback.Requires
+	}
+	if m.WhenToUse == "" {
+		m.WhenToUse = fallback.WhenToUse
+	}
+	if m.CommonMistakes == nil {
+		m.CommonMistakes = fallback.CommonMistakes
+	}
+	if m.SeeAlso == nil {
+		m.SeeAlso = fallback.SeeAlso
+	}
+	if m.Endpoints == nil {
+		m.Endpoints = fallback.Endpoints
+	}
+	if m.Filesystem == nil {
+		m.Filesystem = fallback.Filesystem
+	}
+	if m.Capabilities == nil {
+		m.Capabilities = fallback.Capabilities
+	}
+	if m.Cost == nil {
+		m.Cost = fallback.Cost
+	}
+	if m.Locks == nil {
+		m.Locks = fallback.Locks
+	}
+	if m.Version == "" {
+		m.Version = fallback.Version
+	}
+	if m.Stability == "" {
+		m.Stability = fallback.Stability
+	}
+	if m.Deprecated == nil {
+		m.Deprecated = fallback.Deprecated
+	}
+	m.DeprecatedArgs = mergeMaps(primary.DeprecatedArgs, fallback.DeprecatedArgs)
+	return &m
+}
+
+// mergeMaps returns the union of primary and fallback, preferring primary.
+// It returns one of them unchanged when the other is empty.
+func mergeMaps[V any](primary, fallback map[string]V) map[string]V {
+	if len(fallback) == 0 {
+		return primary
+	}
+	if len(primary) == 0 {
+		return fallback
+	}
+	m := make(map[string]V, len(primary)+len(fallback))
+	maps.Copy(m, fallback)
+	maps.Copy(m, primary)
+	return m
+}
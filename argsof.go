@@ -0,0 +1,129 @@
+package mtp
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ArgsOf returns the positional arguments declared by T's fields, for
+// CommandAnnotation.Args, so their documentation is generated from the
+// struct the command fills from args (see Bind):
+//
+//	type convertArgs struct {
+//		Input   string   `arg:"input_file" type:"path" help:"Image to convert"`
+//		Format  string   `arg:"format,optional" enum:"png,webp" default:"png"`
+//		Extras  []string `arg:"extras,optional" help:"More images"`
+//	}
+//
+//	mtp.Annotate("convert").Args(mtp.ArgsOf[convertArgs]()...)
+//
+// Each field tagged arg is one positional, in field order, including
+// fields promoted from embedded structs. The tag names the argument, and
+// the option optional makes it so. A slice field is variadic and must
+// come last. The type is "string", "integer", "number", or "boolean"
+// after the field's kind, or "array" for a slice, unless a type tag names
+// another (e.g. "path"). help sets the description, enum (comma-separated
+// values) makes it an enum, and default records its default.
+//
+// ArgsOf panics if T isn't a struct, if a required argument follows an
+// optional one, or if a field's kind can't hold an argument.
+func ArgsOf[T any]() []ArgDescriptor {
+	return argsOfType(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// argsOfType implements ArgsOf.
+func argsOfType(t reflect.Type) []ArgDescriptor {
+	fields := taggedFields(t, "arg")
+	args := make([]ArgDescriptor, 0, len(fields))
+	for i, f := range fields {
+		arg := ArgDescriptor{
+			Name:        f.name,
+			Type:        argKindType(f.Type),
+			Description: f.Tag.Get("help"),
+			Required:    !f.has("optional"),
+		}
+		if arg.Type == "" {
+			panic(fmt.Sprintf("mtp: ArgsOf: %s.%s: a %s can't hold an argument", t.Name(), f.Name, f.Type))
+		}
+		if f.Type.Kind() == reflect.Slice {
+			arg.Variadic = true
+			if i != len(fields)-1 {
+				panic(fmt.Sprintf("mtp: ArgsOf: %s.%s: a variadic argument must be the last", t.Name(), f.Name))
+			}
+		}
+		if typ := f.Tag.Get("type"); typ != "" {
+			arg.Type = typ
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			arg.Type = "enum"
+			arg.Values = strings.Split(enum, ",")
+		}
+		if def, ok := f.Tag.Lookup("default"); ok {
+			arg.Default = def
+		}
+		if arg.Required && i > 0 && !args[i-1].Required {
+			panic(fmt.Sprintf("mtp: ArgsOf: %s.%s: required argument %s follows an optional one", t.Name(), f.Name, f.name))
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// taggedField is a struct field tagged with an input key: arg, flag, or
+// stdin.
+type taggedField struct {
+	reflect.StructField
+	name    string   // From the tag, or the field's name in lower case
+	options []string // After the name, e.g. "optional"
+}
+
+// has reports whether the field's tag has option opt.
+func (f taggedField) has(opt string) bool {
+	return slices.Contains(f.options, opt)
+}
+
+// taggedFields returns the exported fields of struct type t, including
+// promoted ones, that have a key tag, in order.
+func taggedFields(t reflect.Type, key string) []taggedField {
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("mtp: inputs must be declared by a struct, not %s", t))
+	}
+	var fields []taggedField
+	for _, f := range reflect.VisibleFields(t) {
+		tag, ok := f.Tag.Lookup(key)
+		if !ok || !f.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields = append(fields, taggedField{StructField: f, name: name, options: strings.Split(options, ",")})
+	}
+	return fields
+}
+
+// argKindType returns the MTP type for a field holding an argument, or ""
+// if its kind can't hold one.
+func argKindType(t reflect.Type) string {
+	if t.Kind() == reflect.Slice {
+		if argKindType(t.Elem()) == "" || t.Elem().Kind() == reflect.Slice {
+			return ""
+		}
+		return "array"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package mtp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type convertSource struct {
+	Input string `arg:"input_file" type:"path" help:"Image to convert"`
+}
+
+type convertArgs struct {
+	convertSource
+	Quality int      `arg:"quality,optional" default:"90"`
+	Format  string   `arg:"format,optional" enum:"png,webp"`
+	Extras  []string `arg:"extras,optional" help:"More images"`
+	Verbose bool     // Not an argument
+}
+
+func TestArgsOf(t *testing.T) {
+	got := ArgsOf[convertArgs]()
+	want := []ArgDescriptor{
+		{Name: "input_file", Type: "path", Description: "Image to convert", Required: true},
+		{Name: "quality", Type: "integer", Default: "90"},
+		{Name: "format", Type: "enum", Values: []string{"png", "webp"}},
+		{Name: "extras", Type: "array", Description: "More images", Variadic: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+
+	root := &cobra.Command{Use: "convert", Run: func(*cobra.Command, []string) {}}
+	schema := Describe(root, &DescribeOptions{Paths: []PathAnnotation{
+		Annotate("_root").Args(ArgsOf[convertArgs]()...).Done(),
+	}})
+	if diags := ValidateSchema(schema); HasErrors(diags) {
+		t.Errorf("unexpected errors: %v", diags)
+	}
+}
+
+func TestArgsOfPanics(t *testing.T) {
+	type variadicFirst struct {
+		Files []string `arg:"files"`
+		Dest  string   `arg:"dest"`
+	}
+	type requiredAfterOptional struct {
+		Src string `arg:"src,optional"`
+		Dst string `arg:"dst"`
+	}
+	type badKind struct {
+		Opts map[string]string `arg:"opts"`
+	}
+	for want, build := range map[string]func(){
+		"must be the last":             func() { ArgsOf[variadicFirst]() },
+		"follows an optional":          func() { ArgsOf[requiredAfterOptional]() },
+		"can't hold an argument":       func() { ArgsOf[badKind]() },
+		"must be declared by a struct": func() { ArgsOf[string]() },
+	} {
+		func() {
+			defer func() {
+				if msg, _ := recover().(string); !strings.Contains(msg, want) {
+					t.Errorf("panic %q, want %q", msg, want)
+				}
+			}()
+			build()
+		}()
+	}
+}
@@ -0,0 +1,161 @@
+package mtp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// AuditCallerEnvVar names the environment variable an agent host sets to
+// identify who an invocation acts for (a user, session, or agent ID). It
+// is copied into each AuditRecord.
+const AuditCallerEnvVar = "MTP_CALLER"
+
+// AuditRecord is one invocation recorded by WithAuditLog.
+type AuditRecord struct {
+	Time       time.Time         `json:"time"` // When the command started
+	Tool       string            `json:"tool"`
+	Command    string            `json:"command"`         // "_root" or the path below the root, e.g. "pr list"
+	Args       []string          `json:"args,omitempty"`  // Positional arguments, redacted
+	Flags      map[string]string `json:"flags,omitempty"` // Flags set on the command line, redacted
+	ExitCode   int               `json:"exitCode"`
+	Error      string            `json:"error,omitempty"`
+	DurationMs int64             `json:"durationMs"`
+	Caller     string            `json:"caller,omitempty"` // From $MTP_CALLER
+}
+
+// WithAuditLog records every invocation of a command in root's tree to
+// sink, one JSON AuditRecord per line, for compliance review of what agents
+// did. Values of sensitive flags (see Sensitive) and of positionals whose
+// descriptors in opts are Sensitive become "***", and well-known token
+// formats are masked wherever they appear. Exit codes come from
+// opts.ErrorCodes, as with ExitCode.
+//
+// Each record is written with a single Write once the command finishes, so
+// an *os.File opened with OpenAuditLog can be shared by concurrent
+// processes. A refused invocation is recorded too, whether its arguments
+// were rejected or its PreRun hook failed, which covers refusals installed
+// earlier by EnforceCapabilities or EnforceConditions. Invocations Cobra
+// rejects on its own, for unknown flags or missing required ones, aren't
+// recorded, nor are a failing PersistentPreRun hook and a command that
+// exits the process itself. Commands added after the call aren't covered.
+func WithAuditLog(root *cobra.Command, opts *DescribeOptions, sink io.Writer) {
+	withAuditLog(root, opts, sink)
+}
+
+func withAuditLog(cmd *cobra.Command, opts *DescribeOptions, sink io.Writer) {
+	if cmd.Runnable() {
+		name := commandName(cmd, opts)
+		auditCommand(cmd, name, opts, sink)
+	}
+	for _, sub := range cmd.Commands() {
+		withAuditLog(sub, opts, sink)
+	}
+}
+
+// OpenAuditLog opens path for appending audit records, creating it with
+// permissions for the owner only.
+func OpenAuditLog(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+}
+
+// auditCommand wraps cmd's Args validator, which Cobra runs first, to
+// start a record, and its PreRun and Run hooks to finish it. name is cmd's
+// name in opts, whose annotation marks Sensitive positionals.
+func auditCommand(cmd *cobra.Command, name string, opts *DescribeOptions, sink io.Writer) {
+	var rec *AuditRecord
+	var start time.Time
+	finish := func(cmd *cobra.Command, err error) error {
+		if rec == nil {
+			return err
+		}
+		rec.DurationMs = time.Since(start).Milliseconds()
+		rec.ExitCode = ExitCode(err, opts)
+		if err != nil {
+			rec.Error = redactCommand(err.Error(), nil)
+		}
+		data, merr := json.Marshal(rec)
+		rec = nil
+		if merr == nil {
+			_, merr = sink.Write(append(data, '\n'))
+		}
+		if merr != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "mtp: writing audit record: %v\n", merr)
+		}
+		return err
+	}
+
+	args := cmd.Args
+	cmd.Args = func(cmd *cobra.Command, positional []string) error {
+		start = time.Now()
+		rec = newAuditRecord(cmd, name, lookupAnnotation(cmd, name, opts), positional, start)
+		if args != nil {
+			if err := args(cmd, positional); err != nil {
+				return finish(cmd, err)
+			}
+		}
+		return nil
+	}
+
+	preRunE, preRun := cmd.PreRunE, cmd.PreRun
+	if preRunE != nil || preRun != nil {
+		cmd.PreRunE = func(cmd *cobra.Command, positional []string) error {
+			if preRunE != nil {
+				if err := preRunE(cmd, positional); err != nil {
+					return finish(cmd, err)
+				}
+				return nil
+			}
+			preRun(cmd, positional)
+			return nil
+		}
+		cmd.PreRun = nil
+	}
+
+	runE, run := cmd.RunE, cmd.Run
+	cmd.RunE = func(cmd *cobra.Command, positional []string) error {
+		if runE != nil {
+			return finish(cmd, runE(cmd, positional))
+		}
+		run(cmd, positional)
+		return finish(cmd, nil)
+	}
+	cmd.Run = nil
+}
+
+// newAuditRecord starts the record of an invocation of cmd, whose schema
+// name is name and annotation is ann.
+func newAuditRecord(cmd *cobra.Command, name string, ann *CommandAnnotation, positional []string, start time.Time) *AuditRecord {
+	rec := &AuditRecord{
+		Time:    start.UTC(),
+		Tool:    cmd.Root().Name(),
+		Command: name,
+		Caller:  os.Getenv(AuditCallerEnvVar),
+	}
+	cr := &commandRedaction{}
+	if ann != nil {
+		cr.args = ann.Args
+	}
+	for i, a := range positional {
+		if arg := cr.positional(i); arg != nil && arg.Sensitive {
+			a = redacted
+		}
+		rec.Args = append(rec.Args, redactCommand(a, nil))
+	}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if rec.Flags == nil {
+			rec.Flags = map[string]string{}
+		}
+		v := f.Value.String()
+		if isSensitiveFlag(f) || cr.sensitiveFlag(f.Name) {
+			v = redacted
+		}
+		rec.Flags[f.Name] = redactCommand(v, nil)
+	})
+	return rec
+}
@@ -0,0 +1,160 @@
+package mtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// oidcConfiguration is the subset of an OpenID Provider's metadata document
+// that maps onto AuthProvider fields.
+type oidcConfiguration struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint   string   `json:"device_authorization_endpoint"`
+	GrantTypesSupported           []string `json:"grant_types_supported"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+}
+
+// DiscoverOIDC fetches the provider's /.well-known/openid-configuration
+// document from IssuerURL and fills in any endpoint, grant type, and PKCE
+// fields that are still empty. Fields set explicitly are left alone.
+// Call it before WithDescribe, or at build time, to avoid copying endpoints
+// by hand.
+func DiscoverOIDC(ctx context.Context, p *AuthProvider) error {
+	if p.IssuerURL == "" {
+		return fmt.Errorf("provider %q has no issuer URL", p.ID)
+	}
+
+	url := strings.TrimSuffix(p.IssuerURL, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching OIDC configuration: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching OIDC configuration: %s returned %s", url, resp.Status)
+	}
+
+	var cfg oidcConfiguration
+	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+		return fmt.Errorf("decoding OIDC configuration: %w", err)
+	}
+	if cfg.Issuer != "" && strings.TrimSuffix(cfg.Issuer, "/") != strings.TrimSuffix(p.IssuerURL, "/") {
+		return fmt.Errorf("OIDC issuer mismatch: configured %q, document says %q", p.IssuerURL, cfg.Issuer)
+	}
+
+	if p.AuthorizationURL == "" {
+		p.AuthorizationURL = cfg.AuthorizationEndpoint
+	}
+	if p.TokenURL == "" {
+		p.TokenURL = cfg.TokenEndpoint
+	}
+	if p.DeviceAuthorizationURL == "" {
+		p.DeviceAuthorizationURL = cfg.DeviceAuthorizationEndpoint
+	}
+	if len(p.GrantTypes) == 0 {
+		p.GrantTypes = cfg.GrantTypesSupported
+	}
+	if len(p.CodeChallengeMethods) == 0 {
+		p.CodeChallengeMethods = cfg.CodeChallengeMethodsSupported
+	}
+	return nil
+}
+
+// DiscoverAllOIDC runs DiscoverOIDC for every provider in cfg that has an
+// IssuerURL.
+func DiscoverAllOIDC(ctx context.Context, cfg *AuthConfig) error {
+	for i := range cfg.Providers {
+		if cfg.Providers[i].IssuerURL == "" {
+			continue
+		}
+		if err := DiscoverOIDC(ctx, &cfg.Providers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunCredentialCommand runs cfg.CredentialCommand and returns its trimmed
+// stdout. The command line is split on whitespace; no shell is involved.
+func RunCredentialCommand(ctx context.Context, cfg *AuthConfig) (string, error) {
+	fields := strings.Fields(cfg.CredentialCommand)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("auth config has no credential command")
+	}
+
+	var stderr bytes.Buffer
+	c := exec.CommandContext(ctx, fields[0], fields[1:]...)
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("credential command failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("credential command failed: %w", err)
+	}
+
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", fmt.Errorf("credential command printed no credential")
+	}
+	return token, nil
+}
+
+// EnvironmentEnvVar is the environment variable consulted by Environment
+// when --mtp-env is not given.
+const EnvironmentEnvVar = "MTP_ENV"
+
+// Environment returns the deployment environment selected for this
+// invocation: the --mtp-env flag if set, otherwise $MTP_ENV. An empty result
+// means the default environment.
+func Environment(cmd *cobra.Command) string {
+	if f := cmd.Flag("mtp-env"); f != nil && f.Value.String() != "" {
+		return f.Value.String()
+	}
+	return os.Getenv(EnvironmentEnvVar)
+}
+
+// ResolveAuthEnvironment returns the auth config for the named environment,
+// with its overrides applied and Environments cleared. An empty name selects
+// cfg.DefaultEnvironment; if that is also empty, cfg is returned unchanged.
+func ResolveAuthEnvironment(cfg *AuthConfig, name string) (*AuthConfig, error) {
+	if name == "" {
+		name = cfg.DefaultEnvironment
+	}
+	if name == "" {
+		return cfg, nil
+	}
+
+	for _, env := range cfg.Environments {
+		if env.Name != name {
+			continue
+		}
+		resolved := *cfg
+		resolved.Environments = nil
+		resolved.DefaultEnvironment = ""
+		if env.EnvVar != "" {
+			resolved.EnvVar = env.EnvVar
+		}
+		if len(env.Providers) > 0 {
+			resolved.Providers = env.Providers
+		}
+		return &resolved, nil
+	}
+	return nil, fmt.Errorf("unknown environment %q", name)
+}
@@ -0,0 +1,635 @@
+package mtp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modeltoolsprotocol/go-sdk/credstore"
+	"github.com/spf13/cobra"
+)
+
+// WithAuthCommands adds an "auth" command group to root with "login",
+// "status", and "logout" subcommands implementing the flows declared in cfg:
+//
+//   - api-key providers prompt for a key on stdin
+//   - oauth2 providers with a DeviceAuthorizationURL use the device code flow
+//   - other oauth2 providers use the authorization code flow with a loopback
+//     redirect, sending a PKCE challenge when UsesPKCE is set
+//
+// Credentials are saved in the OS credential store (see package credstore)
+// and can be read from RunE functions with Token. Like WithDescribe, it adds
+// the persistent --mtp-env flag that selects an environment.
+func WithAuthCommands(root *cobra.Command, cfg *AuthConfig) {
+	authConfigs.Store(root, cfg)
+	addEnvFlag(root)
+
+	authCmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage authentication",
+	}
+
+	var providerID string
+	loginCmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in and save credentials",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			env := credentialEnvironment(cfg, Environment(cmd))
+			ecfg, err := ResolveAuthEnvironment(cfg, env)
+			if err != nil {
+				return err
+			}
+			p, err := selectProvider(ecfg, providerID)
+			if err != nil {
+				return err
+			}
+			cred, err := login(cmd, p)
+			if err != nil {
+				return err
+			}
+			cred.Provider = p.ID
+			if err := saveCredential(root.Name(), env, cred); err != nil {
+				return fmt.Errorf("saving credentials: %w", err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Logged in with %s.\n", providerName(p))
+			return nil
+		},
+	}
+	loginCmd.Flags().StringVar(&providerID, "provider", "", "Provider ID to log in with (defaults to the first provider)")
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show authentication status",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			env := credentialEnvironment(cfg, Environment(cmd))
+			ecfg, err := ResolveAuthEnvironment(cfg, env)
+			if err != nil {
+				return err
+			}
+			if ecfg.EnvVar != "" && os.Getenv(ecfg.EnvVar) != "" {
+				fmt.Fprintf(out, "Using credential from $%s.\n", ecfg.EnvVar)
+				return nil
+			}
+			cred, err := loadCredential(root.Name(), env)
+			if err != nil {
+				return err
+			}
+			if cred == nil {
+				fmt.Fprintln(out, "Not logged in.")
+				return nil
+			}
+			fmt.Fprintf(out, "Logged in with %s.\n", cred.Provider)
+			if !cred.ExpiresAt.IsZero() {
+				if cred.expired() {
+					fmt.Fprintf(out, "Token expired at %s.\n", cred.ExpiresAt.Format(time.RFC3339))
+				} else {
+					fmt.Fprintf(out, "Token expires at %s.\n", cred.ExpiresAt.Format(time.RFC3339))
+				}
+			}
+			return nil
+		},
+	}
+
+	logoutCmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Remove saved credentials",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := deleteCredential(root.Name(), credentialEnvironment(cfg, Environment(cmd))); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.ErrOrStderr(), "Logged out.")
+			return nil
+		},
+	}
+
+	authCmd.AddCommand(loginCmd, statusCmd, logoutCmd)
+	root.AddCommand(authCmd)
+}
+
+// storedCredential is the JSON secret saved in the credential store.
+type storedCredential struct {
+	Provider     string    `json:"provider"`
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	TokenType    string    `json:"tokenType,omitempty"`
+	ExpiresAt    time.Time `json:"expiresAt,omitempty"`
+}
+
+func (c *storedCredential) expired() bool {
+	return !c.ExpiresAt.IsZero() && time.Now().After(c.ExpiresAt)
+}
+
+// credentialEnvironment returns the environment whose credential is used
+// when env is selected: env itself, or cfg's DefaultEnvironment if env is
+// empty, so "--mtp-env prod" and no selection agree when prod is the default.
+func credentialEnvironment(cfg *AuthConfig, env string) string {
+	if env == "" && cfg != nil {
+		return cfg.DefaultEnvironment
+	}
+	return env
+}
+
+// credentialAccount returns the credstore account for an environment, as
+// resolved by credentialEnvironment. Each environment keeps its own
+// credential.
+func credentialAccount(env string) string {
+	if env == "" {
+		return "default"
+	}
+	return "env:" + env
+}
+
+// credentialStore returns the store used by the auth commands and Token.
+// Replaced in tests.
+var credentialStore = credstore.Default
+
+func saveCredential(tool, env string, cred *storedCredential) error {
+	data, err := json.Marshal(cred)
+	if err != nil {
+		return err
+	}
+	return credentialStore().Set(tool, credentialAccount(env), string(data))
+}
+
+// loadCredential returns the saved credential, or nil if there is none.
+func loadCredential(tool, env string) (*storedCredential, error) {
+	data, err := credentialStore().Get(tool, credentialAccount(env))
+	if errors.Is(err, credstore.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cred storedCredential
+	if err := json.Unmarshal([]byte(data), &cred); err != nil {
+		return nil, fmt.Errorf("reading saved credential: %w", err)
+	}
+	return &cred, nil
+}
+
+func deleteCredential(tool, env string) error {
+	err := credentialStore().Delete(tool, credentialAccount(env))
+	if err != nil && !errors.Is(err, credstore.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// ErrNotLoggedIn is returned by Token when no credential is available.
+var ErrNotLoggedIn = errors.New("mtp: not logged in")
+
+// authConfigs maps root commands to the AuthConfig passed to WithAuthCommands.
+var authConfigs sync.Map
+
+// Token returns the credential for the tool cmd belongs to, for use in
+// RunE functions. The AuthConfig env var takes precedence. Otherwise the
+// credential saved by "auth login" is returned, refreshed first if it has
+// expired and the provider supports refresh. Both honor the environment
+// selected with --mtp-env.
+func Token(cmd *cobra.Command) (string, error) {
+	root := cmd.Root()
+	env := Environment(cmd)
+	var cfg *AuthConfig
+	if v, ok := authConfigs.Load(root); ok {
+		env = credentialEnvironment(v.(*AuthConfig), env)
+		resolved, err := ResolveAuthEnvironment(v.(*AuthConfig), env)
+		if err != nil {
+			return "", err
+		}
+		cfg = resolved
+	}
+
+	if cfg != nil && cfg.EnvVar != "" {
+		if v := os.Getenv(cfg.EnvVar); v != "" {
+			return v, nil
+		}
+	}
+
+	cred, err := loadCredential(root.Name(), env)
+	if err != nil {
+		return "", err
+	}
+	if cred == nil {
+		return "", ErrNotLoggedIn
+	}
+	if !cred.expired() {
+		return cred.AccessToken, nil
+	}
+
+	var p *AuthProvider
+	if cfg != nil {
+		p, _ = selectProvider(cfg, cred.Provider)
+	}
+	if p == nil || !p.SupportsRefresh || cred.RefreshToken == "" {
+		return "", fmt.Errorf("mtp: saved credential expired; run %q", root.Name()+" auth login")
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	refreshed, err := refreshCredential(ctx, p, cred)
+	if err != nil {
+		return "", err
+	}
+	if err := saveCredential(root.Name(), env, refreshed); err != nil {
+		return "", fmt.Errorf("saving refreshed credential: %w", err)
+	}
+	return refreshed.AccessToken, nil
+}
+
+// refreshCredential exchanges a refresh token for a new access token.
+func refreshCredential(ctx context.Context, p *AuthProvider, cred *storedCredential) (*storedCredential, error) {
+	endpoint := p.RefreshURL
+	if endpoint == "" {
+		endpoint = p.TokenURL
+	}
+	form := url.Values{
+		"grant_type":    {GrantRefreshToken},
+		"refresh_token": {cred.RefreshToken},
+		"client_id":     {p.ClientID},
+	}
+	var tok tokenResponse
+	status, err := postForm(ctx, endpoint, form, &tok)
+	if err != nil {
+		return nil, fmt.Errorf("refreshing token: %w", err)
+	}
+	refreshed, err := tokenCredential(status, tok)
+	if err != nil {
+		return nil, fmt.Errorf("refreshing token: %w", err)
+	}
+	refreshed.Provider = cred.Provider
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = cred.RefreshToken
+	}
+	return refreshed, nil
+}
+
+// selectProvider picks the provider with the given ID, or the first one.
+func selectProvider(cfg *AuthConfig, id string) (*AuthProvider, error) {
+	if len(cfg.Providers) == 0 {
+		return nil, fmt.Errorf("no auth providers configured")
+	}
+	if id == "" {
+		return &cfg.Providers[0], nil
+	}
+	for i := range cfg.Providers {
+		if cfg.Providers[i].ID == id {
+			return &cfg.Providers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown provider %q", id)
+}
+
+func providerName(p *AuthProvider) string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return p.ID
+}
+
+// login runs the interactive flow for a provider.
+func login(cmd *cobra.Command, p *AuthProvider) (*storedCredential, error) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	switch p.Type {
+	case ProviderAPIKey:
+		return loginAPIKey(cmd, p)
+	case ProviderOAuth2:
+		if p.DeviceAuthorizationURL != "" {
+			return loginDeviceCode(ctx, cmd.ErrOrStderr(), p)
+		}
+		if p.AuthorizationURL != "" {
+			return loginAuthorizationCode(ctx, cmd.ErrOrStderr(), p)
+		}
+		return nil, fmt.Errorf("provider %q has no authorization or device authorization URL", p.ID)
+	default:
+		return nil, fmt.Errorf("provider type %q does not support interactive login", p.Type)
+	}
+}
+
+// loginAPIKey prompts for an API key on stdin.
+func loginAPIKey(cmd *cobra.Command, p *AuthProvider) (*storedCredential, error) {
+	if p.RegistrationURL != "" {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Create an API key at %s\n", p.RegistrationURL)
+	}
+	fmt.Fprint(cmd.ErrOrStderr(), "Paste your API key: ")
+
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	key := strings.TrimSpace(line)
+	if key == "" {
+		return nil, fmt.Errorf("no API key entered")
+	}
+	if p.KeyPattern != "" {
+		re, err := regexp.Compile(p.KeyPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key pattern: %w", err)
+		}
+		if !re.MatchString(key) {
+			return nil, fmt.Errorf("API key does not match the expected format")
+		}
+	}
+	return &storedCredential{AccessToken: key}, nil
+}
+
+// tokenResponse is an OAuth 2.0 token endpoint response (RFC 6749 §5).
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (t *tokenResponse) credential() *storedCredential {
+	cred := &storedCredential{
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+		TokenType:    t.TokenType,
+	}
+	if t.ExpiresIn > 0 {
+		cred.ExpiresAt = time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+	}
+	return cred
+}
+
+// postForm posts form values, decodes a JSON response into v, and returns
+// the response's status code. OAuth error responses come back with 4xx
+// codes and a JSON body, so the body is decoded regardless of status;
+// callers must check both.
+func postForm(ctx context.Context, endpoint string, form url.Values, v any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return resp.StatusCode, fmt.Errorf("%s returned %s: %w", endpoint, resp.Status, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// tokenCredential returns the credential in a token endpoint response. An
+// OAuth error, a non-2xx status, or a missing access token is an error, so
+// a proxy's error page or an empty body is never saved as a credential.
+func tokenCredential(status int, tok tokenResponse) (*storedCredential, error) {
+	switch {
+	case tok.Error != "":
+		return nil, oauthError(tok)
+	case !successStatus(status):
+		return nil, fmt.Errorf("token endpoint returned %d %s", status, http.StatusText(status))
+	case tok.AccessToken == "":
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+	return tok.credential(), nil
+}
+
+// successStatus reports whether status is 2xx.
+func successStatus(status int) bool {
+	return status >= 200 && status < 300
+}
+
+// setRequestParams adds the provider's scope, audience, and RFC 8707
+// resource indicator to an authorization request.
+func setRequestParams(v url.Values, p *AuthProvider) {
+	if len(p.Scopes) > 0 {
+		v.Set("scope", strings.Join(p.Scopes, " "))
+	}
+	if p.Audience != "" {
+		v.Set("audience", p.Audience)
+	}
+	if p.Resource != "" {
+		v.Set("resource", p.Resource)
+	}
+}
+
+// devicePollInterval is the RFC 8628 default polling interval.
+var devicePollInterval = 5 * time.Second
+
+// loginDeviceCode runs the RFC 8628 device authorization grant.
+func loginDeviceCode(ctx context.Context, w io.Writer, p *AuthProvider) (*storedCredential, error) {
+	form := url.Values{"client_id": {p.ClientID}}
+	setRequestParams(form, p)
+
+	var device struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+		Error                   string `json:"error"`
+	}
+	status, err := postForm(ctx, p.DeviceAuthorizationURL, form, &device)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("requesting device code: %w", err)
+	case device.Error != "":
+		return nil, fmt.Errorf("requesting device code: %s", device.Error)
+	case !successStatus(status):
+		return nil, fmt.Errorf("requesting device code: %s returned %d %s", p.DeviceAuthorizationURL, status, http.StatusText(status))
+	case device.DeviceCode == "":
+		return nil, fmt.Errorf("requesting device code: response has no device_code")
+	}
+
+	fmt.Fprintf(w, "Open %s and enter code %s\n", device.VerificationURI, device.UserCode)
+	if device.VerificationURIComplete != "" {
+		openBrowser(device.VerificationURIComplete)
+	} else {
+		openBrowser(device.VerificationURI)
+	}
+
+	interval := devicePollInterval
+	if device.Interval > 0 {
+		interval = time.Duration(device.Interval) * time.Second
+	}
+	if device.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(device.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	poll := url.Values{
+		"grant_type":  {GrantDeviceCode},
+		"device_code": {device.DeviceCode},
+		"client_id":   {p.ClientID},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("device code expired before authorization completed")
+		case <-time.After(interval):
+		}
+
+		var tok tokenResponse
+		status, err := postForm(ctx, p.TokenURL, poll, &tok)
+		if err != nil {
+			return nil, fmt.Errorf("polling for token: %w", err)
+		}
+		switch tok.Error {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return tokenCredential(status, tok)
+		}
+	}
+}
+
+// loginAuthorizationCode runs the authorization code grant with a loopback
+// redirect (RFC 8252), using PKCE when the provider requires it.
+func loginAuthorizationCode(ctx context.Context, w io.Writer, p *AuthProvider) (*storedCredential, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	defer ln.Close()
+	redirectURI := fmt.Sprintf("http://%s/callback", ln.Addr())
+
+	state := randomString()
+	verifier := randomString()
+
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.ClientID},
+		"redirect_uri":  {redirectURI},
+		"state":         {state},
+	}
+	setRequestParams(q, p)
+	if p.UsesPKCE {
+		sum := sha256.Sum256([]byte(verifier))
+		q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(sum[:]))
+		q.Set("code_challenge_method", "S256")
+	}
+	authURL := p.AuthorizationURL + "?" + q.Encode()
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	srv := &http.Server{Handler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(rw, r)
+			return
+		}
+		res := result{code: r.URL.Query().Get("code")}
+		switch {
+		case r.URL.Query().Get("state") != state:
+			res.err = fmt.Errorf("authorization response state mismatch")
+		case r.URL.Query().Get("error") != "":
+			res.err = fmt.Errorf("authorization failed: %s", r.URL.Query().Get("error"))
+		case res.code == "":
+			res.err = fmt.Errorf("authorization response has no code")
+		}
+		if res.err != nil {
+			http.Error(rw, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(rw, "Login complete. You can close this window.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	fmt.Fprintf(w, "Opening %s\n", authURL)
+	openBrowser(authURL)
+
+	var res result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res = <-results:
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	form := url.Values{
+		"grant_type":   {GrantAuthorizationCode},
+		"code":         {res.code},
+		"redirect_uri": {redirectURI},
+		"client_id":    {p.ClientID},
+	}
+	if p.Resource != "" {
+		form.Set("resource", p.Resource)
+	}
+	if p.UsesPKCE {
+		form.Set("code_verifier", verifier)
+	}
+	var tok tokenResponse
+	status, err := postForm(ctx, p.TokenURL, form, &tok)
+	if err != nil {
+		return nil, fmt.Errorf("exchanging code: %w", err)
+	}
+	return tokenCredential(status, tok)
+}
+
+func oauthError(tok tokenResponse) error {
+	if tok.ErrorDescription != "" {
+		return fmt.Errorf("authorization failed: %s: %s", tok.Error, tok.ErrorDescription)
+	}
+	return fmt.Errorf("authorization failed: %s", tok.Error)
+}
+
+// randomString returns 32 random bytes, base64url-encoded. Used for OAuth
+// state and PKCE verifiers.
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// openBrowser tries to open url in the user's browser. Failure is not an
+// error: the URL has already been printed. Replaced in tests.
+var openBrowser = func(url string) {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", url)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		c = exec.Command("xdg-open", url)
+	}
+	_ = c.Start()
+}
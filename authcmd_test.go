@@ -0,0 +1,467 @@
+package mtp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modeltoolsprotocol/go-sdk/credstore"
+	"github.com/spf13/cobra"
+)
+
+// ── Auth command tests ───────────────────────────────────────────────
+
+func TestWithAuthCommandsAddsSubcommands(t *testing.T) {
+	root := &cobra.Command{Use: "tool"}
+	WithAuthCommands(root, &AuthConfig{EnvVar: "TOOL_TOKEN"})
+
+	for _, path := range [][]string{{"auth", "login"}, {"auth", "status"}, {"auth", "logout"}} {
+		cmd, _, err := root.Find(path)
+		if err != nil || cmd.Name() != path[1] {
+			t.Errorf("command %v not installed", path)
+		}
+	}
+}
+
+func TestAuthLoginAPIKey(t *testing.T) {
+	stubStore(t)
+	t.Setenv("TOOL_TOKEN", "")
+
+	cfg := &AuthConfig{
+		EnvVar: "TOOL_TOKEN",
+		Providers: []AuthProvider{
+			{ID: "key", Type: ProviderAPIKey, KeyPattern: "^sk_[a-z]+$"},
+		},
+	}
+
+	out := runAuth(t, cfg, "sk_abc\n", "auth", "login")
+	if !strings.Contains(out, "Logged in with key") {
+		t.Errorf("unexpected login output: %s", out)
+	}
+
+	cred, err := loadCredential("tool", "")
+	if err != nil || cred == nil || cred.AccessToken != "sk_abc" {
+		t.Fatalf("credential not saved: %+v, %v", cred, err)
+	}
+
+	if out := runAuth(t, cfg, "", "auth", "status"); !strings.Contains(out, "Logged in with key") {
+		t.Errorf("unexpected status output: %s", out)
+	}
+
+	runAuth(t, cfg, "", "auth", "logout")
+	if cred, _ := loadCredential("tool", ""); cred != nil {
+		t.Error("credential not removed by logout")
+	}
+	if out := runAuth(t, cfg, "", "auth", "status"); !strings.Contains(out, "Not logged in") {
+		t.Errorf("unexpected status output after logout: %s", out)
+	}
+}
+
+func TestAuthLoginAPIKeyRejectsBadFormat(t *testing.T) {
+	stubStore(t)
+
+	root := &cobra.Command{Use: "tool"}
+	WithAuthCommands(root, &AuthConfig{
+		Providers: []AuthProvider{{ID: "key", Type: ProviderAPIKey, KeyPattern: "^sk_"}},
+	})
+	root.SetIn(strings.NewReader("nope\n"))
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"auth", "login"})
+	if err := root.Execute(); err == nil {
+		t.Error("expected error for key not matching pattern")
+	}
+}
+
+func TestAuthLoginDeviceCode(t *testing.T) {
+	stubStore(t)
+	stubBrowser(t)
+	oldInterval := devicePollInterval
+	devicePollInterval = time.Millisecond
+	t.Cleanup(func() { devicePollInterval = oldInterval })
+
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/device":
+			if r.Form.Get("resource") != "https://api.example.com" || r.Form.Get("audience") != "example-api" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]any{"error": "invalid_target"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"device_code":      "dev-1",
+				"user_code":        "ABCD-EFGH",
+				"verification_uri": "https://example.com/activate",
+				"expires_in":       60,
+			})
+		case "/token":
+			if r.Form.Get("grant_type") != GrantDeviceCode || r.Form.Get("device_code") != "dev-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]any{"error": "invalid_request"})
+				return
+			}
+			if polls.Add(1) < 2 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]any{"error": "authorization_pending"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"access_token":  "at-1",
+				"refresh_token": "rt-1",
+				"token_type":    "Bearer",
+				"expires_in":    3600,
+			})
+		}
+	}))
+	defer srv.Close()
+
+	cfg := &AuthConfig{
+		Providers: []AuthProvider{{
+			ID:                     "idp",
+			Type:                   ProviderOAuth2,
+			ClientID:               "client",
+			TokenURL:               srv.URL + "/token",
+			DeviceAuthorizationURL: srv.URL + "/device",
+			Audience:               "example-api",
+			Resource:               "https://api.example.com",
+		}},
+	}
+
+	out := runAuth(t, cfg, "", "auth", "login")
+	if !strings.Contains(out, "ABCD-EFGH") {
+		t.Errorf("user code not shown: %s", out)
+	}
+
+	cred, err := loadCredential("tool", "")
+	if err != nil || cred == nil {
+		t.Fatalf("credential not saved: %v", err)
+	}
+	if cred.AccessToken != "at-1" || cred.RefreshToken != "rt-1" || cred.ExpiresAt.IsZero() {
+		t.Errorf("unexpected credential: %+v", cred)
+	}
+	if polls.Load() != 2 {
+		t.Errorf("expected 2 polls, got %d", polls.Load())
+	}
+}
+
+func TestAuthLoginRejectsEmptyToken(t *testing.T) {
+	stubBrowser(t)
+	oldInterval := devicePollInterval
+	devicePollInterval = time.Millisecond
+	t.Cleanup(func() { devicePollInterval = oldInterval })
+
+	tests := []struct {
+		name         string
+		deviceStatus int
+		device       map[string]any
+		tokenStatus  int
+		token        map[string]any
+		wantErr      string
+	}{
+		{"server error without body fields", 200, map[string]any{"device_code": "dev-1"}, 500, map[string]any{}, "500 Internal Server Error"},
+		{"proxy error page", 200, map[string]any{"device_code": "dev-1"}, 502, map[string]any{"message": "bad gateway"}, "502 Bad Gateway"},
+		{"success without token", 200, map[string]any{"device_code": "dev-1"}, 200, map[string]any{"token_type": "Bearer"}, "no access_token"},
+		{"device endpoint error", 503, map[string]any{}, 200, nil, "503 Service Unavailable"},
+		{"no device code", 200, map[string]any{}, 200, nil, "no device_code"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubStore(t)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/device" {
+					w.WriteHeader(tt.deviceStatus)
+					json.NewEncoder(w).Encode(tt.device)
+					return
+				}
+				w.WriteHeader(tt.tokenStatus)
+				json.NewEncoder(w).Encode(tt.token)
+			}))
+			defer srv.Close()
+
+			root := &cobra.Command{Use: "tool"}
+			WithAuthCommands(root, &AuthConfig{Providers: []AuthProvider{{
+				ID:                     "idp",
+				Type:                   ProviderOAuth2,
+				TokenURL:               srv.URL + "/token",
+				DeviceAuthorizationURL: srv.URL + "/device",
+			}}})
+			var out bytes.Buffer
+			root.SetOut(&out)
+			root.SetErr(&out)
+			root.SetArgs([]string{"auth", "login"})
+			err := root.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want an error containing %q", err, tt.wantErr)
+			}
+			if cred, _ := loadCredential("tool", ""); cred != nil {
+				t.Errorf("credential saved: %+v", cred)
+			}
+		})
+	}
+}
+
+func TestAuthLoginAuthorizationCodePKCE(t *testing.T) {
+	stubStore(t)
+
+	var gotVerifier string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		gotVerifier = r.Form.Get("code_verifier")
+		if r.Form.Get("code") != "code-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]any{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "at-2"})
+	}))
+	defer srv.Close()
+
+	// Play the browser: follow the redirect back to the loopback listener.
+	var challenge string
+	old := openBrowser
+	openBrowser = func(authURL string) {
+		u, _ := url.Parse(authURL)
+		q := u.Query()
+		challenge = q.Get("code_challenge")
+		redirect := q.Get("redirect_uri") + "?code=code-1&state=" + url.QueryEscape(q.Get("state"))
+		go http.Get(redirect)
+	}
+	t.Cleanup(func() { openBrowser = old })
+
+	cfg := &AuthConfig{
+		Providers: []AuthProvider{{
+			ID:               "idp",
+			Type:             ProviderOAuth2,
+			ClientID:         "client",
+			AuthorizationURL: "https://idp.example/authorize",
+			TokenURL:         srv.URL,
+			UsesPKCE:         true,
+		}},
+	}
+
+	runAuth(t, cfg, "", "auth", "login")
+
+	cred, _ := loadCredential("tool", "")
+	if cred == nil || cred.AccessToken != "at-2" {
+		t.Fatalf("credential not saved: %+v", cred)
+	}
+	if challenge == "" || gotVerifier == "" {
+		t.Errorf("PKCE not used: challenge=%q verifier=%q", challenge, gotVerifier)
+	}
+}
+
+// ── Token tests ──────────────────────────────────────────────────────
+
+func TestTokenPrefersEnvVar(t *testing.T) {
+	stubStore(t)
+	t.Setenv("TOOL_TOKEN", "from-env")
+
+	root := &cobra.Command{Use: "tool"}
+	WithAuthCommands(root, &AuthConfig{EnvVar: "TOOL_TOKEN"})
+	saveCredential("tool", "", &storedCredential{Provider: "p", AccessToken: "from-store"})
+
+	token, err := Token(root)
+	if err != nil || token != "from-env" {
+		t.Errorf("expected from-env, got %q (%v)", token, err)
+	}
+}
+
+func TestTokenFromStore(t *testing.T) {
+	stubStore(t)
+	t.Setenv("TOOL_TOKEN", "")
+
+	root := &cobra.Command{Use: "tool"}
+	sub := &cobra.Command{Use: "fetch"}
+	root.AddCommand(sub)
+	WithAuthCommands(root, &AuthConfig{EnvVar: "TOOL_TOKEN"})
+
+	if _, err := Token(sub); err != ErrNotLoggedIn {
+		t.Errorf("expected ErrNotLoggedIn, got %v", err)
+	}
+	saveCredential("tool", "", &storedCredential{Provider: "p", AccessToken: "from-store"})
+	token, err := Token(sub)
+	if err != nil || token != "from-store" {
+		t.Errorf("expected from-store, got %q (%v)", token, err)
+	}
+}
+
+func TestTokenRefreshesExpired(t *testing.T) {
+	stubStore(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != GrantRefreshToken || r.Form.Get("refresh_token") != "rt-old" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]any{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "at-new", "expires_in": 3600})
+	}))
+	defer srv.Close()
+
+	root := &cobra.Command{Use: "tool"}
+	WithAuthCommands(root, &AuthConfig{
+		Providers: []AuthProvider{{ID: "idp", Type: ProviderOAuth2, TokenURL: srv.URL, SupportsRefresh: true}},
+	})
+	saveCredential("tool", "", &storedCredential{
+		Provider:     "idp",
+		AccessToken:  "at-old",
+		RefreshToken: "rt-old",
+		ExpiresAt:    time.Now().Add(-time.Minute),
+	})
+
+	token, err := Token(root)
+	if err != nil || token != "at-new" {
+		t.Fatalf("expected at-new, got %q (%v)", token, err)
+	}
+	cred, _ := loadCredential("tool", "")
+	if cred.AccessToken != "at-new" || cred.RefreshToken != "rt-old" {
+		t.Errorf("refreshed credential not saved: %+v", cred)
+	}
+}
+
+func TestTokenRefreshWithoutAccessToken(t *testing.T) {
+	stubStore(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"token_type": "Bearer", "expires_in": 3600})
+	}))
+	defer srv.Close()
+
+	root := &cobra.Command{Use: "tool"}
+	WithAuthCommands(root, &AuthConfig{
+		Providers: []AuthProvider{{ID: "idp", Type: ProviderOAuth2, TokenURL: srv.URL, SupportsRefresh: true}},
+	})
+	saveCredential("tool", "", &storedCredential{
+		Provider:     "idp",
+		AccessToken:  "at-old",
+		RefreshToken: "rt-old",
+		ExpiresAt:    time.Now().Add(-time.Minute),
+	})
+
+	token, err := Token(root)
+	if err == nil || !strings.Contains(err.Error(), "no access_token") {
+		t.Errorf("expected a refresh error, got %q (%v)", token, err)
+	}
+	if cred, _ := loadCredential("tool", ""); cred.AccessToken != "at-old" {
+		t.Errorf("stored token replaced: %+v", cred)
+	}
+}
+
+func TestTokenPerEnvironment(t *testing.T) {
+	stubStore(t)
+	t.Setenv("TOOL_TOKEN", "")
+	t.Setenv("TOOL_STAGING_TOKEN", "")
+
+	root := &cobra.Command{Use: "tool"}
+	WithAuthCommands(root, &AuthConfig{
+		EnvVar:       "TOOL_TOKEN",
+		Environments: []AuthEnvironment{{Name: "staging", EnvVar: "TOOL_STAGING_TOKEN"}},
+	})
+	saveCredential("tool", "", &storedCredential{AccessToken: "prod-token"})
+	saveCredential("tool", "staging", &storedCredential{AccessToken: "staging-token"})
+
+	t.Setenv(EnvironmentEnvVar, "staging")
+	if token, _ := Token(root); token != "staging-token" {
+		t.Errorf("expected staging-token, got %q", token)
+	}
+	t.Setenv("TOOL_STAGING_TOKEN", "staging-env")
+	if token, _ := Token(root); token != "staging-env" {
+		t.Errorf("expected staging env var to win, got %q", token)
+	}
+	t.Setenv(EnvironmentEnvVar, "")
+	if token, _ := Token(root); token != "prod-token" {
+		t.Errorf("expected prod-token, got %q", token)
+	}
+}
+
+func TestTokenDefaultEnvironment(t *testing.T) {
+	stubStore(t)
+	t.Setenv("TOOL_TOKEN", "")
+	t.Setenv(EnvironmentEnvVar, "")
+
+	cfg := &AuthConfig{
+		EnvVar:             "TOOL_TOKEN",
+		Providers:          []AuthProvider{{ID: "key", Type: ProviderAPIKey}},
+		Environments:       []AuthEnvironment{{Name: "prod"}, {Name: "staging"}},
+		DefaultEnvironment: "prod",
+	}
+	runAuth(t, cfg, "sk_prod\n", "auth", "login")
+
+	root := &cobra.Command{Use: "tool"}
+	WithAuthCommands(root, cfg)
+	t.Setenv(EnvironmentEnvVar, "prod")
+	if token, err := Token(root); token != "sk_prod" {
+		t.Errorf("--mtp-env prod: got %q, %v, want the default environment's login", token, err)
+	}
+	t.Setenv(EnvironmentEnvVar, "staging")
+	if _, err := Token(root); err != ErrNotLoggedIn {
+		t.Errorf("staging: got %v, want ErrNotLoggedIn", err)
+	}
+}
+
+func TestAuthCommandsEnvFlag(t *testing.T) {
+	stubStore(t)
+	t.Setenv(EnvironmentEnvVar, "")
+
+	cfg := &AuthConfig{
+		EnvVar:       "TOOL_TOKEN",
+		Providers:    []AuthProvider{{ID: "key", Type: ProviderAPIKey}},
+		Environments: []AuthEnvironment{{Name: "staging"}},
+	}
+	runAuth(t, cfg, "sk_staging\n", "auth", "login", "--mtp-env", "staging")
+	if cred, _ := loadCredential("tool", "staging"); cred == nil || cred.AccessToken != "sk_staging" {
+		t.Errorf("login without WithDescribe did not select staging: %+v", cred)
+	}
+
+	// Either order of WithAuthCommands and WithDescribe registers it once.
+	root := &cobra.Command{Use: "tool"}
+	WithAuthCommands(root, cfg)
+	WithDescribe(root, nil)
+	other := &cobra.Command{Use: "tool"}
+	WithDescribe(other, nil)
+	WithAuthCommands(other, cfg)
+}
+
+// ── Helpers ──────────────────────────────────────────────────────────
+
+// runAuth executes a "tool" root with auth commands and returns combined output.
+func runAuth(t *testing.T, cfg *AuthConfig, stdin string, args ...string) string {
+	t.Helper()
+	root := &cobra.Command{Use: "tool"}
+	WithAuthCommands(root, cfg)
+
+	var out bytes.Buffer
+	root.SetIn(strings.NewReader(stdin))
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs(args)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("%v failed: %v\n%s", args, err, out.String())
+	}
+	return out.String()
+}
+
+func stubStore(t *testing.T) {
+	t.Helper()
+	store := credstore.NewFileStore(filepath.Join(t.TempDir(), "credentials.json"))
+	old := credentialStore
+	credentialStore = func() credstore.Store { return store }
+	t.Cleanup(func() { credentialStore = old })
+}
+
+func stubBrowser(t *testing.T) {
+	t.Helper()
+	old := openBrowser
+	openBrowser = func(string) {}
+	t.Cleanup(func() { openBrowser = old })
+}
@@ -0,0 +1,30 @@
+package mtp
+
+// AutoGenTool is an AutoGen tool definition, matching autogen_core's
+// ToolSchema: the OpenAI function-calling shape.
+type AutoGenTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+	Strict      bool           `json:"strict"`
+}
+
+// ToAutoGenTools converts each command in schema to an AutoGen tool
+// definition. Pair each with a FunctionTool that runs the command; its
+// argv prefix is the tool name followed by the command path.
+func ToAutoGenTools(schema *ToolSchema) []AutoGenTool {
+	tools := make([]AutoGenTool, 0, len(schema.Commands))
+	names := exportNames(schema)
+	for i, cmd := range schema.Commands {
+		desc := cmd.Description
+		if desc == "" {
+			desc = schema.Description
+		}
+		tools = append(tools, AutoGenTool{
+			Name:        names[i],
+			Description: desc,
+			Parameters:  argsJSONSchema(cmd),
+		})
+	}
+	return tools
+}
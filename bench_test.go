@@ -0,0 +1,83 @@
+package mtp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// newLargeTree builds a tree shaped like a generated cloud CLI: groups of
+// services, each with resources and verbs, every leaf carrying flags.
+func newLargeTree(services, resources, verbs, flags int) *cobra.Command {
+	root := &cobra.Command{Use: "cloud", Short: "Cloud CLI"}
+	root.PersistentFlags().String("project", "", "Project ID")
+	root.PersistentFlags().String("region", "us-east-1", "Region")
+	for s := 0; s < services; s++ {
+		svc := &cobra.Command{Use: fmt.Sprintf("svc%d", s), Short: "Service"}
+		for r := 0; r < resources; r++ {
+			res := &cobra.Command{Use: fmt.Sprintf("res%d", r), Short: "Resource"}
+			for v := 0; v < verbs; v++ {
+				leaf := &cobra.Command{Use: fmt.Sprintf("verb%d <id>", v), Short: "Do something to a resource"}
+				for f := 0; f < flags; f++ {
+					leaf.Flags().String(fmt.Sprintf("opt%d", f), "x", "An option")
+				}
+				leaf.Flags().Int("limit", 50, "Max results")
+				leaf.Flags().Bool("dry-run", false, "Preview only")
+				res.AddCommand(leaf)
+			}
+			svc.AddCommand(res)
+		}
+		root.AddCommand(svc)
+	}
+	return root
+}
+
+func BenchmarkDescribe(b *testing.B) {
+	root := newLargeTree(40, 10, 8, 10) // 3200 commands
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Describe(root, nil)
+	}
+}
+
+func BenchmarkEncodeSchema(b *testing.B) {
+	root := newLargeTree(40, 10, 8, 10)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		EncodeSchema(io.Discard, root, nil)
+	}
+}
+
+func BenchmarkDescribeAndEncode(b *testing.B) {
+	root := newLargeTree(40, 10, 8, 10)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		json.NewEncoder(io.Discard).Encode(Describe(root, nil))
+	}
+}
+
+func BenchmarkDescribeParallel(b *testing.B) {
+	root := newLargeTree(40, 10, 8, 10)
+	opts := &DescribeOptions{Parallelism: runtime.GOMAXPROCS(0)}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Describe(root, opts)
+	}
+}
+
+func BenchmarkExtractFlags(b *testing.B) {
+	root := newLargeTree(1, 1, 1, 20)
+	leaf := root.Commands()[0].Commands()[0].Commands()[0]
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		flags, _ := collectFlags(leaf)
+		extractFlags(nil, flags, nil)
+	}
+}
@@ -0,0 +1,256 @@
+package mtp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// InvalidInput is the error code Bind reports. Add it to
+// DescribeOptions.ErrorCodes so it's listed in the schema.
+var InvalidInput = ErrorCode{
+	Code:        "INVALID_INPUT",
+	Description: "An argument, flag, or stdin value is missing or malformed; fix the input and try again",
+	ExitCode:    2,
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Bind fills input, a pointer to a struct, from a command's invocation,
+// in place of looking up each flag and argument in RunE:
+//
+//	type convertInput struct {
+//		Input   string   `arg:"input_file" type:"path"`
+//		Format  string   `flag:"format" enum:"png,webp"`
+//		Quality int      `flag:"quality"`
+//		Options Settings `stdin:"optional"`
+//	}
+//
+//	RunE: func(cmd *cobra.Command, args []string) error {
+//		var in convertInput
+//		if err := mtp.Bind(cmd, args, &in); err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Fields tagged arg take the positionals, as ArgsOf declares them; an
+// optional one that isn't given keeps its default tag's value, if any.
+// Fields tagged flag take the named flag's value, set or default. A field
+// tagged stdin is decoded from the command's stdin as JSON, after the
+// JSON is checked against SchemaOf the field's type; with the option
+// optional, empty stdin leaves it unset. Values outside a field's enum
+// tag are rejected.
+//
+// All problems are reported together, as an *Error with the code
+// InvalidInput. Bind panics if input isn't a pointer to a struct, or
+// names a flag the command doesn't have.
+func Bind(cmd *cobra.Command, args []string, input any) error {
+	v := reflect.ValueOf(input)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("mtp: Bind needs a pointer to a struct, got %T", input))
+	}
+	v = v.Elem()
+
+	var errs []error
+	errs = append(errs, bindArgs(v, args)...)
+	errs = append(errs, bindFlags(cmd, v)...)
+	errs = append(errs, bindStdin(cmd, v)...)
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return &Error{Code: InvalidInput.Code, Message: strings.Join(msgs, "; "), Err: errors.Join(errs...)}
+}
+
+func bindArgs(v reflect.Value, args []string) []error {
+	var errs []error
+	fields := taggedFields(v.Type(), "arg")
+	for i, f := range fields {
+		field := v.FieldByIndex(f.Index)
+		if f.Type.Kind() == reflect.Slice {
+			var rest []string
+			if i < len(args) {
+				rest = args[i:]
+			}
+			if len(rest) == 0 && !f.has("optional") {
+				errs = append(errs, fmt.Errorf("missing argument %s", f.name))
+			}
+			errs = append(errs, setValues(field, enumTag(f), f.name, rest)...)
+			return errs
+		}
+		if i >= len(args) {
+			def, hasDefault := f.Tag.Lookup("default")
+			switch {
+			case !f.has("optional"):
+				errs = append(errs, fmt.Errorf("missing argument %s", f.name))
+			case hasDefault:
+				errs = append(errs, setValues(field, enumTag(f), f.name, []string{def})...)
+			}
+			continue
+		}
+		errs = append(errs, setValues(field, enumTag(f), f.name, args[i:i+1])...)
+	}
+	if len(args) > len(fields) {
+		errs = append(errs, fmt.Errorf("unexpected argument %q", args[len(fields)]))
+	}
+	return errs
+}
+
+func bindFlags(cmd *cobra.Command, v reflect.Value) []error {
+	var errs []error
+	for _, f := range taggedFields(v.Type(), "flag") {
+		flag := cmd.Flags().Lookup(f.name)
+		if flag == nil {
+			panic(fmt.Sprintf("mtp: Bind: %s.%s: %s has no flag --%s", v.Type().Name(), f.Name, cmd.CommandPath(), f.name))
+		}
+		values := []string{flag.Value.String()}
+		if sv, ok := flag.Value.(pflag.SliceValue); ok {
+			values = sv.GetSlice()
+		}
+		var enum []string
+		if flag.Changed {
+			enum = enumTag(f) // A default outside the enum means "not set"
+		}
+		errs = append(errs, setValues(v.FieldByIndex(f.Index), enum, "--"+f.name, values)...)
+	}
+	return errs
+}
+
+func bindStdin(cmd *cobra.Command, v reflect.Value) []error {
+	fields := taggedFields(v.Type(), "stdin")
+	if len(fields) == 0 {
+		return nil
+	}
+	if len(fields) > 1 {
+		panic(fmt.Sprintf("mtp: Bind: %s has more than one stdin field", v.Type().Name()))
+	}
+	f := fields[0]
+	optional := slices.Contains(strings.Split(f.Tag.Get("stdin"), ","), "optional")
+	data, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return []error{fmt.Errorf("reading stdin: %w", err)}
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		if optional {
+			return nil
+		}
+		return []error{errors.New("stdin: expected JSON input")}
+	}
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return []error{fmt.Errorf("stdin: %w", err)}
+	}
+
+	// Round-trip the schema, so its values compare with decoded JSON.
+	raw, err := json.Marshal(schemaOfType(f.Type))
+	if err != nil {
+		panic("mtp: Bind: encoding stdin schema: " + err.Error())
+	}
+	schema, _ := decodeJSON(raw)
+	sv := specValidator{root: schema.(map[string]any), patterns: map[string]*regexp.Regexp{}}
+	sv.check(doc, sv.root, "stdin")
+	if len(sv.diags) > 0 {
+		errs := make([]error, len(sv.diags))
+		for i, d := range sv.diags {
+			errs[i] = fmt.Errorf("%s: %s", d.Path, d.Message)
+		}
+		return errs
+	}
+	if err := json.Unmarshal(data, v.FieldByIndex(f.Index).Addr().Interface()); err != nil {
+		return []error{fmt.Errorf("stdin: %w", err)}
+	}
+	return nil
+}
+
+// enumTag returns the values of a field's enum tag.
+func enumTag(f taggedField) []string {
+	if e := f.Tag.Get("enum"); e != "" {
+		return strings.Split(e, ",")
+	}
+	return nil
+}
+
+// setValues parses values into field: the first value for a scalar, every
+// one for a slice. Values outside enum, if it's set, are rejected. name
+// identifies the input in errors.
+func setValues(field reflect.Value, enum []string, name string, values []string) []error {
+	var errs []error
+	parse := func(dst reflect.Value, s string) {
+		if enum != nil && !slices.Contains(enum, s) {
+			errs = append(errs, fmt.Errorf("%s: %q is not one of %s", name, s, strings.Join(enum, ", ")))
+			return
+		}
+		if err := setScalar(dst, s); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if field.Kind() != reflect.Slice {
+		if len(values) > 0 {
+			parse(field, values[0])
+		}
+		return errs
+	}
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, s := range values {
+		parse(slice.Index(i), s)
+	}
+	field.Set(slice)
+	return errs
+}
+
+// setScalar parses s as v's kind and stores it.
+func setScalar(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", s)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a non-negative integer", s)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a number", s)
+		}
+		v.SetFloat(n)
+	default:
+		panic(fmt.Sprintf("mtp: Bind: a %s can't hold a command-line value", v.Type()))
+	}
+	return nil
+}
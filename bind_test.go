@@ -0,0 +1,143 @@
+package mtp
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type bindSettings struct {
+	Name    string   `json:"name"`
+	Retries int      `json:"retries,omitempty" jsonschema:"minimum=0"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+type bindInput struct {
+	Input    string        `arg:"input_file"`
+	Count    int           `arg:"count,optional" default:"3"`
+	Format   string        `flag:"format" enum:"png,webp"`
+	Quality  int           `flag:"quality"`
+	Strip    bool          `flag:"strip"`
+	Include  []string      `flag:"include"`
+	Timeout  time.Duration `flag:"timeout"`
+	Settings bindSettings  `stdin:"optional"`
+}
+
+func bindCommand(t *testing.T, stdin string, argv ...string) (bindInput, error) {
+	t.Helper()
+	var in bindInput
+	var bindErr error
+	cmd := &cobra.Command{Use: "convert", RunE: func(cmd *cobra.Command, args []string) error {
+		bindErr = Bind(cmd, args, &in)
+		return nil
+	}}
+	cmd.Flags().String("format", "", "")
+	cmd.Flags().Int("quality", 80, "")
+	cmd.Flags().Bool("strip", false, "")
+	cmd.Flags().StringSlice("include", nil, "")
+	cmd.Flags().Duration("timeout", time.Minute, "")
+	cmd.SetArgs(argv)
+	cmd.SetIn(strings.NewReader(stdin))
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	return in, bindErr
+}
+
+func TestBind(t *testing.T) {
+	in, err := bindCommand(t, `{"name": "x", "tags": ["a"]}`,
+		"in.png", "--format", "webp", "--strip", "--include", "a,b", "--timeout", "5s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Input != "in.png" || in.Count != 3 || in.Format != "webp" || in.Quality != 80 || !in.Strip ||
+		!slices.Equal(in.Include, []string{"a", "b"}) || in.Timeout != 5*time.Second {
+		t.Errorf("got %+v", in)
+	}
+	if in.Settings.Name != "x" || !slices.Equal(in.Settings.Tags, []string{"a"}) {
+		t.Errorf("stdin: got %+v", in.Settings)
+	}
+
+	if in, err := bindCommand(t, "", "in.png", "7"); err != nil || in.Count != 7 || in.Settings.Name != "" {
+		t.Errorf("optional stdin: got %+v, %v", in, err)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	_, err := bindCommand(t, `{"retries": -1, "extra": true}`, "--format", "gif")
+	var e *Error
+	if !errors.As(err, &e) || e.Code != InvalidInput.Code {
+		t.Fatalf("got %v, want an %s error", err, InvalidInput.Code)
+	}
+	for _, want := range []string{
+		"missing argument input_file",
+		`--format: "gif" is not one of png, webp`,
+		"stdin.name: required field is missing",
+		"stdin.extra: unknown field",
+		"stdin.retries: -1 is less than the minimum 0",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in %v", want, err)
+		}
+	}
+
+	_, err = bindCommand(t, "", "in.png", "many", "more")
+	for _, want := range []string{`count: "many" is not an integer`, `unexpected argument "more"`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in %v", want, err)
+		}
+	}
+}
+
+func TestBindStdinBounds(t *testing.T) {
+	type bounded struct {
+		N     float64  `json:"n,omitempty" jsonschema:"minimum=1,maximum=10"`
+		Ratio float64  `json:"ratio,omitempty" jsonschema:"exclusiveMinimum=0,exclusiveMaximum=1"`
+		S     string   `json:"s,omitempty" jsonschema:"minLength=1,maxLength=2"`
+		Tags  []string `json:"tags,omitempty" jsonschema:"minItems=1,maxItems=2,uniqueItems=true"`
+	}
+	bind := func(stdin string) error {
+		var in struct {
+			B bounded `stdin:""`
+		}
+		var err error
+		cmd := &cobra.Command{Use: "t", Run: func(cmd *cobra.Command, args []string) { err = Bind(cmd, args, &in) }}
+		cmd.SetArgs(nil)
+		cmd.SetIn(strings.NewReader(stdin))
+		if execErr := cmd.Execute(); execErr != nil {
+			t.Fatal(execErr)
+		}
+		return err
+	}
+
+	if err := bind(`{"n": 10, "ratio": 0.5, "s": "ok", "tags": ["a", "b"]}`); err != nil {
+		t.Errorf("in bounds: %v", err)
+	}
+	err := bind(`{"n": 999, "ratio": 1, "s": "toolong", "tags": ["a", "a", "b"]}`)
+	for _, want := range []string{
+		"stdin.n: 999 is greater than the maximum 10",
+		"stdin.ratio: 1 must be less than 1",
+		"stdin.s: must be at most 2 characters",
+		"stdin.tags: must have at most 2 items",
+		"stdin.tags[1]: duplicates item 0",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in %v", want, err)
+		}
+	}
+	err = bind(`{"n": 0, "ratio": 0, "s": "", "tags": []}`)
+	for _, want := range []string{
+		"stdin.n: 0 is less than the minimum 1",
+		"stdin.ratio: 0 must be greater than 0",
+		"stdin.s: must be at least 1 characters",
+		"stdin.tags: must have at least 1 items",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in %v", want, err)
+		}
+	}
+}
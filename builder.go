@@ -0,0 +1,243 @@
+package mtp
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// AnnotationBuilder builds a CommandAnnotation fluently, as an alternative
+// to nested literals in DescribeOptions:
+//
+//	opts := &mtp.DescribeOptions{Paths: []mtp.PathAnnotation{
+//		mtp.Annotate("convert").
+//			StdinJSON(inputSchema).
+//			Example("Convert to PNG", "imgtool convert in.jpg out.png", "").
+//			Auth(true, "read").
+//			Done(),
+//	}}
+//
+// Every method returns the builder, so calls chain; Done returns the
+// finished entry.
+type AnnotationBuilder struct {
+	path []string
+	ann  CommandAnnotation
+}
+
+// Annotate starts an annotation for the command with the given schema name
+// (e.g. "db migrate", or "_root" for the root command).
+func Annotate(command string) *AnnotationBuilder {
+	var path []string
+	if command != "_root" {
+		path = strings.Fields(command)
+	}
+	return &AnnotationBuilder{path: path}
+}
+
+// Arg appends a positional argument.
+func (b *AnnotationBuilder) Arg(name, typ, description string, required bool) *AnnotationBuilder {
+	b.ann.Args = append(b.ann.Args, ArgDescriptor{Name: name, Type: typ, Description: description, Required: required})
+	return b
+}
+
+// Args appends positional arguments, such as those from ArgsOf.
+func (b *AnnotationBuilder) Args(args ...ArgDescriptor) *AnnotationBuilder {
+	b.ann.Args = append(b.ann.Args, args...)
+	return b
+}
+
+// ArgType overrides the MTP type of a flag (e.g. "port", "integer").
+func (b *AnnotationBuilder) ArgType(flag, typ string) *AnnotationBuilder {
+	if b.ann.ArgTypes == nil {
+		b.ann.ArgTypes = map[string]string{}
+	}
+	b.ann.ArgTypes[flag] = typ
+	return b
+}
+
+// Stdin sets the stdin descriptor.
+func (b *AnnotationBuilder) Stdin(io *IODescriptor) *AnnotationBuilder {
+	b.ann.Stdin = io
+	return b
+}
+
+// StdinJSON declares that the command reads JSON matching schema on stdin.
+func (b *AnnotationBuilder) StdinJSON(schema map[string]any) *AnnotationBuilder {
+	return b.Stdin(&IODescriptor{ContentType: "application/json", Schema: schema})
+}
+
+// Stdout sets the stdout descriptor.
+func (b *AnnotationBuilder) Stdout(io *IODescriptor) *AnnotationBuilder {
+	b.ann.Stdout = io
+	return b
+}
+
+// StdoutJSON declares that the command writes JSON matching schema.
+func (b *AnnotationBuilder) StdoutJSON(schema map[string]any) *AnnotationBuilder {
+	return b.Stdout(&IODescriptor{ContentType: "application/json", Schema: schema})
+}
+
+// Example appends a usage example. Output may be empty.
+func (b *AnnotationBuilder) Example(description, command, output string) *AnnotationBuilder {
+	b.ann.Examples = append(b.ann.Examples, Example{Description: description, Command: command, Output: output})
+	return b
+}
+
+// Auth sets the command's auth requirement and the scopes it needs.
+func (b *AnnotationBuilder) Auth(required bool, scopes ...string) *AnnotationBuilder {
+	b.ann.Auth = &CommandAuth{Required: required, Scopes: scopes}
+	return b
+}
+
+// MayElicit marks the command as one that may ask for input mid-execution.
+func (b *AnnotationBuilder) MayElicit() *AnnotationBuilder {
+	b.ann.MayElicit = true
+	return b
+}
+
+// Endpoints adds hosts or URL patterns the command contacts.
+func (b *AnnotationBuilder) Endpoints(endpoints ...string) *AnnotationBuilder {
+	b.ann.Endpoints = append(b.ann.Endpoints, endpoints...)
+	return b
+}
+
+// Reads adds glob patterns for paths the command reads.
+func (b *AnnotationBuilder) Reads(globs ...string) *AnnotationBuilder {
+	b.filesystem().Reads = append(b.filesystem().Reads, globs...)
+	return b
+}
+
+// Writes adds glob patterns for paths the command writes.
+func (b *AnnotationBuilder) Writes(globs ...string) *AnnotationBuilder {
+	b.filesystem().Writes = append(b.filesystem().Writes, globs...)
+	return b
+}
+
+func (b *AnnotationBuilder) filesystem() *FilesystemAccess {
+	if b.ann.Filesystem == nil {
+		b.ann.Filesystem = &FilesystemAccess{}
+	}
+	return b.ann.Filesystem
+}
+
+// Capabilities adds capabilities the command needs from its host.
+func (b *AnnotationBuilder) Capabilities(caps ...string) *AnnotationBuilder {
+	b.ann.Capabilities = append(b.ann.Capabilities, caps...)
+	return b
+}
+
+// Cost declares that the command costs money to run.
+func (b *AnnotationBuilder) Cost(c Cost) *AnnotationBuilder {
+	b.ann.Cost = &c
+	return b
+}
+
+// Locks adds named resources the command needs exclusive use of.
+func (b *AnnotationBuilder) Locks(names ...string) *AnnotationBuilder {
+	b.ann.Locks = append(b.ann.Locks, names...)
+	return b
+}
+
+// WhenToUse sets routing guidance telling agents when to pick the command.
+func (b *AnnotationBuilder) WhenToUse(text string) *AnnotationBuilder {
+	b.ann.WhenToUse = text
+	return b
+}
+
+// CommonMistakes adds known ways agents misuse the command.
+func (b *AnnotationBuilder) CommonMistakes(mistakes ...string) *AnnotationBuilder {
+	b.ann.CommonMistakes = append(b.ann.CommonMistakes, mistakes...)
+	return b
+}
+
+// SeeAlso adds the names of related commands.
+func (b *AnnotationBuilder) SeeAlso(commands ...string) *AnnotationBuilder {
+	b.ann.SeeAlso = append(b.ann.SeeAlso, commands...)
+	return b
+}
+
+// Version sets the command's own version, for subcommands released
+// independently of the tool.
+func (b *AnnotationBuilder) Version(v string) *AnnotationBuilder {
+	b.ann.Version = v
+	return b
+}
+
+// Stability sets the command's maturity (see the Stability* constants).
+func (b *AnnotationBuilder) Stability(level string) *AnnotationBuilder {
+	b.ann.Stability = level
+	return b
+}
+
+// RequiresEnv adds environment variables the command needs.
+func (b *AnnotationBuilder) RequiresEnv(vars ...string) *AnnotationBuilder {
+	b.requires().EnvVars = append(b.requires().EnvVars, vars...)
+	return b
+}
+
+// RequiresBinaries adds executables the command needs on PATH.
+func (b *AnnotationBuilder) RequiresBinaries(bins ...string) *AnnotationBuilder {
+	b.requires().Binaries = append(b.requires().Binaries, bins...)
+	return b
+}
+
+// RequiresOS restricts the command to the given GOOS values.
+func (b *AnnotationBuilder) RequiresOS(goos ...string) *AnnotationBuilder {
+	b.requires().OS = append(b.requires().OS, goos...)
+	return b
+}
+
+// RequiresOSVersion sets the oldest release of goos the command supports,
+// e.g. RequiresOSVersion("darwin", "13.0").
+func (b *AnnotationBuilder) RequiresOSVersion(goos, version string) *AnnotationBuilder {
+	req := b.requires()
+	if req.MinOSVersion == nil {
+		req.MinOSVersion = map[string]string{}
+	}
+	req.MinOSVersion[goos] = version
+	return b
+}
+
+// DependsOn adds executables or services the command needs, with minimum
+// versions.
+func (b *AnnotationBuilder) DependsOn(deps ...Dependency) *AnnotationBuilder {
+	b.requires().Dependencies = append(b.requires().Dependencies, deps...)
+	return b
+}
+
+func (b *AnnotationBuilder) requires() *Requirements {
+	if b.ann.Requires == nil {
+		b.ann.Requires = &Requirements{}
+	}
+	return b.ann.Requires
+}
+
+// Deprecated marks the command deprecated, with an optional timeline.
+func (b *AnnotationBuilder) Deprecated(d Deprecation) *AnnotationBuilder {
+	b.ann.Deprecated = &d
+	return b
+}
+
+// Done returns the annotation as a DescribeOptions.Paths entry. The
+// builder may be reused; later calls don't affect earlier results.
+func (b *AnnotationBuilder) Done() PathAnnotation {
+	ann := b.ann
+	ann.ArgTypes = maps.Clone(ann.ArgTypes)
+	if ann.Requires != nil {
+		req := *ann.Requires
+		ann.Requires = &req
+	}
+	if ann.Filesystem != nil {
+		fs := FilesystemAccess{Reads: slices.Clip(ann.Filesystem.Reads), Writes: slices.Clip(ann.Filesystem.Writes)}
+		ann.Filesystem = &fs
+	}
+	// Capped so appending to the result can't write into the builder's slices.
+	ann.Args = slices.Clip(ann.Args)
+	ann.Examples = slices.Clip(ann.Examples)
+	ann.Endpoints = slices.Clip(ann.Endpoints)
+	ann.Capabilities = slices.Clip(ann.Capabilities)
+	ann.Locks = slices.Clip(ann.Locks)
+	ann.CommonMistakes = slices.Clip(ann.CommonMistakes)
+	ann.SeeAlso = slices.Clip(ann.SeeAlso)
+	return PathAnnotation{Path: append([]string(nil), b.path...), Annotation: &ann}
+}
@@ -0,0 +1,45 @@
+package mtp
+
+import (
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// schemaKey identifies a cached schema. The same tree described with
+// different options caches separately.
+type schemaKey struct {
+	root *cobra.Command
+	opts *DescribeOptions
+}
+
+var schemaCache sync.Map // schemaKey -> *ToolSchema
+
+// DescribeCached is Describe with memoization, for long-running hosts that
+// answer repeated schema requests (a serve mode, or an MCP bridge handling
+// tools/list). The first call for a given root and opts walks the tree;
+// later calls return the same *ToolSchema until InvalidateSchema(root).
+//
+// The returned schema is shared between callers and must not be modified.
+// Changes to the command tree or to *opts after the first call are not
+// seen until the cache is invalidated.
+func DescribeCached(root *cobra.Command, opts *DescribeOptions) *ToolSchema {
+	key := schemaKey{root, opts}
+	if schema, ok := schemaCache.Load(key); ok {
+		return schema.(*ToolSchema)
+	}
+	schema, _ := schemaCache.LoadOrStore(key, Describe(root, opts))
+	return schema.(*ToolSchema)
+}
+
+// InvalidateSchema drops every cached schema for root, whatever options it
+// was described with. Call it after adding, removing, or changing commands
+// or flags on a tree served through DescribeCached.
+func InvalidateSchema(root *cobra.Command) {
+	schemaCache.Range(func(k, _ any) bool {
+		if k.(schemaKey).root == root {
+			schemaCache.Delete(k)
+		}
+		return true
+	})
+}
@@ -0,0 +1,106 @@
+package mtp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Capabilities a command can declare. Hosts grant them to decide which
+// commands a sandboxed agent may run.
+const (
+	CapNet       = "net"       // Opens network connections
+	CapFSRead    = "fs:read"   // Reads files outside its inputs
+	CapFSWrite   = "fs:write"  // Creates, modifies, or deletes files
+	CapExec      = "exec"      // Runs other programs
+	CapClipboard = "clipboard" // Reads or writes the system clipboard
+)
+
+// capabilityOrder is the vocabulary, in the order capabilities are listed.
+var capabilityOrder = []string{CapNet, CapFSRead, CapFSWrite, CapExec, CapClipboard}
+
+// annotationCapabilities returns the capabilities ann declares, plus those
+// implied by its other declarations: Endpoints need net, Filesystem reads
+// and writes need fs:read and fs:write, and Requires binaries or
+// dependencies need exec. Known capabilities come first in vocabulary
+// order, then any others alphabetically.
+func annotationCapabilities(ann *CommandAnnotation) []string {
+	if ann == nil {
+		return nil
+	}
+	caps := slices.Clone(ann.Capabilities)
+	if len(ann.Endpoints) > 0 {
+		caps = append(caps, CapNet)
+	}
+	if fs := ann.Filesystem; fs != nil {
+		if len(fs.Reads) > 0 {
+			caps = append(caps, CapFSRead)
+		}
+		if len(fs.Writes) > 0 {
+			caps = append(caps, CapFSWrite)
+		}
+	}
+	if req := ann.Requires; req != nil && (len(req.Binaries) > 0 || len(req.Dependencies) > 0) {
+		caps = append(caps, CapExec)
+	}
+	if len(caps) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(caps, func(a, b string) int {
+		ia, ib := slices.Index(capabilityOrder, a), slices.Index(capabilityOrder, b)
+		switch {
+		case ia >= 0 && ib >= 0:
+			return ia - ib
+		case ia >= 0:
+			return -1
+		case ib >= 0:
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	return slices.Compact(caps)
+}
+
+// EnforceCapabilities makes every command in root's tree refuse to run if
+// it declares a capability not in granted. Capabilities come from opts as
+// they do in the schema, including those implied by endpoints, filesystem
+// access, and required binaries, so a command is refused exactly when its
+// schema asks for more than granted.
+//
+// The check replaces the command's Args validator, which Cobra runs before
+// any PreRun hooks, so a refused command has no side effects. --mtp-describe
+// and --mtp-check are let through, so a host can still learn what to grant.
+// Commands added after the call aren't covered.
+func EnforceCapabilities(root *cobra.Command, opts *DescribeOptions, granted ...string) {
+	enforceCapabilities(root, opts, granted)
+}
+
+func enforceCapabilities(cmd *cobra.Command, opts *DescribeOptions, granted []string) {
+	name := commandName(cmd, opts)
+	var missing []string
+	for _, c := range annotationCapabilities(lookupAnnotation(cmd, name, opts)) {
+		if !slices.Contains(granted, c) {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) > 0 {
+		err := fmt.Errorf("%s needs capabilities that weren't granted: %s", cmd.CommandPath(), strings.Join(missing, ", "))
+		validate := cmd.Args
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			switch {
+			case !introspecting(cmd):
+				return err
+			case validate != nil:
+				return validate(cmd, args)
+			}
+			return nil
+		}
+	}
+
+	for _, sub := range cmd.Commands() {
+		enforceCapabilities(sub, opts, granted)
+	}
+}
@@ -0,0 +1,100 @@
+package mtp
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newCapabilitiesTool(ran *[]string) (*cobra.Command, *DescribeOptions) {
+	run := func(cmd *cobra.Command, _ []string) { *ran = append(*ran, cmd.Name()) }
+	root := &cobra.Command{Use: "tool"}
+	root.PersistentPreRun = func(*cobra.Command, []string) { *ran = append(*ran, "prerun") }
+	root.AddCommand(&cobra.Command{Use: "fmt", Run: run})
+	root.AddCommand(&cobra.Command{Use: "sync", Run: run})
+	root.AddCommand(&cobra.Command{Use: "copy", Run: run})
+
+	opts := &DescribeOptions{Paths: []PathAnnotation{
+		Annotate("sync").Endpoints("api.example.com").Reads("~/.tool/**").Done(),
+		Annotate("copy").Capabilities(CapClipboard).Writes("./out/**").RequiresBinaries("xclip").Done(),
+	}}
+	return root, opts
+}
+
+func TestCapabilitiesExtracted(t *testing.T) {
+	var ran []string
+	root, opts := newCapabilitiesTool(&ran)
+
+	got := map[string][]string{}
+	for _, cmd := range Describe(root, opts).Commands {
+		got[cmd.Name] = cmd.Capabilities
+	}
+	want := map[string][]string{
+		"fmt":  nil,
+		"sync": {CapNet, CapFSRead},
+		"copy": {CapFSWrite, CapExec, CapClipboard},
+	}
+	for name, caps := range want {
+		if !slices.Equal(got[name], caps) {
+			t.Errorf("%s: got capabilities %v, want %v", name, got[name], caps)
+		}
+	}
+}
+
+func TestEnforceCapabilities(t *testing.T) {
+	tests := []struct {
+		args    []string
+		granted []string
+		wantErr string
+	}{
+		{[]string{"fmt"}, nil, ""},
+		{[]string{"sync"}, []string{CapNet, CapFSRead}, ""},
+		{[]string{"sync"}, []string{CapNet}, "tool sync needs capabilities that weren't granted: fs:read"},
+		{[]string{"copy"}, []string{CapFSWrite}, "tool copy needs capabilities that weren't granted: exec, clipboard"},
+	}
+	for _, tt := range tests {
+		var ran []string
+		root, opts := newCapabilitiesTool(&ran)
+		EnforceCapabilities(root, opts, tt.granted...)
+		root.SetArgs(tt.args)
+		root.SilenceUsage, root.SilenceErrors = true, true
+
+		err := root.Execute()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%v: unexpected error %v", tt.args, err)
+		case tt.wantErr == "" && !slices.Equal(ran, []string{"prerun", tt.args[0]}):
+			t.Errorf("%v: expected the command to run, ran %v", tt.args, ran)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%v: got error %v, want %q", tt.args, err, tt.wantErr)
+		case tt.wantErr != "" && len(ran) != 0:
+			t.Errorf("%v: refused command should have no side effects, ran %v", tt.args, ran)
+		}
+	}
+}
+
+func TestEnforceCapabilitiesAllowsIntrospection(t *testing.T) {
+	var ran []string
+	root, opts := newCapabilitiesTool(&ran)
+	root.PersistentFlags().Bool("mtp-describe", false, "") // As WithDescribe adds it, without exiting
+	EnforceCapabilities(root, opts)
+	root.SetArgs([]string{"sync", "--mtp-describe"})
+	root.SilenceUsage, root.SilenceErrors = true, true
+
+	if err := root.Execute(); err != nil {
+		t.Errorf("--mtp-describe refused: %v", err)
+	}
+}
+
+func TestValidateCapabilities(t *testing.T) {
+	schema := &ToolSchema{Commands: []CommandDescriptor{{
+		Name:         "copy",
+		Capabilities: []string{CapClipboard, "gpu"},
+	}}}
+	diags := ValidateSchema(schema)
+	if len(diags) != 1 || diags[0].Severity != SeverityWarning || diags[0].Path != "commands[copy].capabilities[1]" {
+		t.Errorf("expected one unknown capability warning, got %v", diags)
+	}
+}
@@ -0,0 +1,208 @@
+package mtp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CheckResult is the outcome of verifying a single requirement.
+type CheckResult struct {
+	Kind    string `json:"kind"`              // "env", "binary", "os", or "dependency"
+	Name    string `json:"name"`              // Env var, binary, OS, or dependency name
+	Command string `json:"command,omitempty"` // Declaring command; empty for tool-level requirements
+	OK      bool   `json:"ok"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// CheckReport is the --mtp-check output.
+type CheckReport struct {
+	OK      bool          `json:"ok"`
+	Results []CheckResult `json:"results"`
+}
+
+// Check verifies the tool- and command-level requirements declared in a
+// schema against the current environment.
+func Check(schema *ToolSchema) *CheckReport {
+	report := &CheckReport{OK: true, Results: []CheckResult{}}
+
+	add := func(results []CheckResult) {
+		for _, r := range results {
+			if !r.OK {
+				report.OK = false
+			}
+			report.Results = append(report.Results, r)
+		}
+	}
+
+	add(checkRequirements(schema.Requires, ""))
+	for _, cmd := range schema.Commands {
+		add(checkRequirements(cmd.Requires, cmd.Name))
+	}
+
+	return report
+}
+
+// checkRequirements verifies one Requirements block.
+func checkRequirements(req *Requirements, command string) []CheckResult {
+	if req == nil {
+		return nil
+	}
+
+	var results []CheckResult
+
+	if len(req.OS) > 0 || req.MinOSVersion[runtime.GOOS] != "" {
+		r := CheckResult{Kind: "os", Name: runtime.GOOS, Command: command}
+		r.OK, r.Detail = checkOS(req)
+		results = append(results, r)
+	}
+
+	for _, name := range req.EnvVars {
+		r := CheckResult{Kind: "env", Name: name, Command: command}
+		if _, ok := os.LookupEnv(name); ok {
+			r.OK = true
+		} else {
+			r.Detail = "not set"
+		}
+		results = append(results, r)
+	}
+
+	for _, name := range req.Binaries {
+		r := CheckResult{Kind: "binary", Name: name, Command: command}
+		if path, err := exec.LookPath(name); err == nil {
+			r.OK = true
+			r.Detail = path
+		} else {
+			r.Detail = "not found on PATH"
+		}
+		results = append(results, r)
+	}
+
+	for _, dep := range req.Dependencies {
+		r := CheckResult{Kind: "dependency", Name: dep.Name, Command: command}
+		r.OK, r.Detail = checkDependency(dep)
+		results = append(results, r)
+	}
+
+	return results
+}
+
+// checkOS verifies that the current system is one of req.OS and no older
+// than its entry in req.MinOSVersion.
+func checkOS(req *Requirements) (ok bool, detail string) {
+	if len(req.OS) > 0 && !slices.Contains(req.OS, runtime.GOOS) {
+		return false, "unsupported operating system"
+	}
+	oldest := req.MinOSVersion[runtime.GOOS]
+	if oldest == "" {
+		return true, ""
+	}
+	version := osVersion()
+	if version == "" {
+		return false, "could not determine the " + runtime.GOOS + " version"
+	}
+	if compareDotted(version, oldest) < 0 {
+		return false, fmt.Sprintf("version %s is older than %s", version, oldest)
+	}
+	return true, version
+}
+
+// osVersion returns the release of the running system as a dotted number:
+// the kernel release on Linux, the product version on macOS, and the build
+// number on Windows. It returns "" when the version can't be read.
+var osVersion = func() string {
+	var out []byte
+	switch runtime.GOOS {
+	case "linux":
+		out, _ = os.ReadFile("/proc/sys/kernel/osrelease")
+	case "darwin":
+		out, _ = exec.Command("sw_vers", "-productVersion").Output()
+	case "windows":
+		out, _ = exec.Command("cmd", "/c", "ver").Output()
+	default:
+		out, _ = exec.Command("uname", "-r").Output()
+	}
+	return versionPattern.FindString(string(out))
+}
+
+// dependencyTimeout bounds each version command and service dial.
+const dependencyTimeout = 3 * time.Second
+
+// versionPattern finds a dotted version number in --version output.
+var versionPattern = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// checkDependency verifies one dependency: a service by connecting to its
+// endpoint, an executable by finding it on PATH and, with MinVersion, by
+// running it to read its version.
+func checkDependency(dep Dependency) (ok bool, detail string) {
+	if dep.Endpoint != "" {
+		network, addr, found := strings.Cut(dep.Endpoint, "://")
+		if !found || (network != "unix" && network != "tcp") {
+			return false, "unsupported endpoint " + dep.Endpoint
+		}
+		conn, err := net.DialTimeout(network, addr, dependencyTimeout)
+		if err != nil {
+			return false, "not reachable: " + err.Error()
+		}
+		conn.Close()
+		return true, dep.Endpoint
+	}
+
+	path, err := exec.LookPath(dep.Name)
+	if err != nil {
+		return false, "not found on PATH"
+	}
+	if dep.MinVersion == "" {
+		return true, path
+	}
+
+	args := dep.VersionArgs
+	if len(args) == 0 {
+		args = []string{"--version"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dependencyTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	version := versionPattern.FindString(string(out))
+	if version == "" {
+		if err != nil {
+			return false, "version check failed: " + err.Error()
+		}
+		return false, "no version in output of " + dep.Name + " " + strings.Join(args, " ")
+	}
+	if compareDotted(version, dep.MinVersion) < 0 {
+		return false, fmt.Sprintf("version %s is older than %s", version, dep.MinVersion)
+	}
+	return true, fmt.Sprintf("%s (%s)", path, version)
+}
+
+// compareDotted compares dotted numeric versions part by part; a missing
+// part counts as zero, so "24" equals "24.0". Non-numeric parts compare as
+// zero.
+func compareDotted(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
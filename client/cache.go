@@ -0,0 +1,283 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+// Cache keeps schemas on disk, so a host that enumerates dozens of tools at
+// startup doesn't rerun --mtp-describe or refetch every URL each launch.
+//
+// Schemas are stored once each, as <name>@<version>-<hash>.json, where hash
+// is a prefix of the SHA-256 of the schema. A small record per source (a
+// binary path or a URL) says which schema it last produced and how to tell
+// whether that is still current:
+//
+//   - A binary is unchanged while its size and modification time are. If
+//     they differ, its SHA-256 is compared with the recorded one before it
+//     is described again, so a touched but identical binary stays cached.
+//   - A URL is fresh for the max-age its server allowed, then revalidated
+//     with its ETag.
+//
+// A Cache is safe for concurrent use, including by several processes.
+type Cache struct {
+	Dir string
+}
+
+// DefaultCacheDir returns mtp/schemas in the user's cache directory
+// ($XDG_CACHE_HOME or ~/.cache on Linux).
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mtp", "schemas"), nil
+}
+
+// NewCache returns a cache in dir, or in DefaultCacheDir when dir is
+// empty. The directory is created when the first schema is stored.
+func NewCache(dir string) (*Cache, error) {
+	if dir == "" {
+		var err error
+		if dir, err = DefaultCacheDir(); err != nil {
+			return nil, err
+		}
+	}
+	return &Cache{Dir: dir}, nil
+}
+
+// cacheRecord is what the cache remembers about one source.
+type cacheRecord struct {
+	Source string `json:"source"`
+	Schema string `json:"schema"` // File name of the schema in Dir
+
+	// Binaries
+	Size    int64     `json:"size,omitempty"`
+	ModTime time.Time `json:"modTime,omitempty"`
+	SHA256  string    `json:"sha256,omitempty"`
+
+	// URLs
+	ETag      string    `json:"etag,omitempty"`
+	Expires   time.Time `json:"expires,omitempty"`
+	Signature []byte    `json:"signature,omitempty"`
+	SignedAt  time.Time `json:"signedAt,omitempty"`
+}
+
+// Describe returns the schema of the tool binary at path, running it with
+// --mtp-describe only when the binary changed since it was last described.
+func (c *Cache) Describe(ctx context.Context, path string) (*mtp.ToolSchema, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, _ := c.record(path)
+	if rec != nil && rec.Size == info.Size() && rec.ModTime.Equal(info.ModTime()) {
+		if schema, _, err := c.schema(rec.Schema); err == nil {
+			return schema, nil
+		}
+	}
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return nil, err
+	}
+	if rec != nil && rec.SHA256 == sum {
+		if schema, _, err := c.schema(rec.Schema); err == nil {
+			rec.Size, rec.ModTime = info.Size(), info.ModTime()
+			return schema, c.saveRecord(rec)
+		}
+	}
+
+	schema, err := mtp.DescribeExecutable(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := c.saveSchema(schema, nil)
+	if err != nil {
+		return nil, err
+	}
+	return schema, c.saveRecord(&cacheRecord{Source: path, Schema: file, Size: info.Size(), ModTime: info.ModTime(), SHA256: sum})
+}
+
+// Fetch returns the schema published at url. A copy fetched within its
+// max-age is returned without a request; an older one is revalidated with
+// its ETag, and downloaded again only if it changed. opts.ETag is ignored.
+// opts.Trust is checked against cached copies too, so tightening a policy
+// takes effect without clearing the cache.
+func (c *Cache) Fetch(ctx context.Context, url string, opts FetchOptions) (*mtp.ToolSchema, error) {
+	rec, _ := c.record(url)
+	var cached *mtp.ToolSchema
+	var raw []byte
+	if rec != nil {
+		cached, raw, _ = c.schema(rec.Schema)
+	}
+	trusted := func() error {
+		if opts.Trust == nil {
+			return nil
+		}
+		_, err := opts.Trust.Check(cached.Name, SignedMessage(raw, rec.SignedAt), rec.Signature, rec.SignedAt)
+		return err
+	}
+	if cached != nil && time.Now().Before(rec.Expires) {
+		return cached, trusted()
+	}
+
+	opts.ETag = ""
+	if cached != nil {
+		opts.ETag = rec.ETag
+	}
+	res, err := FetchSchema(ctx, url, opts)
+	if err != nil {
+		return nil, err
+	}
+	if res.NotModified {
+		if cached == nil || opts.ETag == "" {
+			return nil, fmt.Errorf("fetching %s: not modified, but no copy was cached", url)
+		}
+		if err := trusted(); err != nil {
+			return nil, err
+		}
+		rec.ETag, rec.Expires = res.ETag, time.Now().Add(res.MaxAge)
+		return cached, c.saveRecord(rec)
+	}
+
+	// Stored as fetched, so the signature still verifies.
+	file, err := c.saveSchema(res.Schema, res.Raw)
+	if err != nil {
+		return nil, err
+	}
+	return res.Schema, c.saveRecord(&cacheRecord{
+		Source: url, Schema: file, ETag: res.ETag, Expires: time.Now().Add(res.MaxAge),
+		Signature: res.Signature, SignedAt: res.SignedAt,
+	})
+}
+
+// recordPath returns where the record for source is kept.
+func (c *Cache) recordPath(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(c.Dir, "sources", hex.EncodeToString(sum[:16])+".json")
+}
+
+// record loads the record for source. A record for a different source
+// that happens to share its file is treated as missing.
+func (c *Cache) record(source string) (*cacheRecord, error) {
+	data, err := os.ReadFile(c.recordPath(source))
+	if err != nil {
+		return nil, err
+	}
+	var rec cacheRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	if rec.Source != source {
+		return nil, fs.ErrNotExist
+	}
+	return &rec, nil
+}
+
+func (c *Cache) saveRecord(rec *cacheRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.recordPath(rec.Source), data)
+}
+
+// schema loads a stored schema by file name, returning it parsed and as
+// stored.
+func (c *Cache) schema(file string) (*mtp.ToolSchema, []byte, error) {
+	if file == "" || filepath.Base(file) != file {
+		return nil, nil, fs.ErrNotExist
+	}
+	data, err := os.ReadFile(filepath.Join(c.Dir, file))
+	if err != nil {
+		return nil, nil, err
+	}
+	schema, err := mtp.ParseSchema(data, mtp.ParseOptions{PreserveUnknown: true})
+	return schema, data, err
+}
+
+// saveSchema stores schema under its name, version, and hash, unless an
+// identical schema is already stored, and returns its file name. data is
+// the schema's encoding, or nil to encode it.
+func (c *Cache) saveSchema(schema *mtp.ToolSchema, data []byte) (string, error) {
+	if data == nil {
+		var err error
+		if data, err = json.Marshal(schema); err != nil {
+			return "", err
+		}
+	}
+	sum := sha256.Sum256(data)
+	file := fileNamePart(schema.Name) + "@" + fileNamePart(schema.Version) + "-" + hex.EncodeToString(sum[:8]) + ".json"
+	path := filepath.Join(c.Dir, file)
+	if _, err := os.Stat(path); err == nil {
+		return file, nil
+	}
+	return file, writeFileAtomic(path, data)
+}
+
+// fileNamePart makes s safe to use in a file name.
+func fileNamePart(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+	if s == "" || strings.Trim(s, ".") == "" {
+		return "_"
+	}
+	return s
+}
+
+// writeFileAtomic writes data to path through a temporary file, so readers
+// in other processes never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheDescribe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the tool")
+	}
+	dir := t.TempDir()
+	runs := filepath.Join(dir, "runs")
+	bin := filepath.Join(dir, "gh")
+	script := "#!/bin/sh\necho x >> " + runs + "\necho '" + testSchema + "'\n"
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	countRuns := func() int {
+		data, _ := os.ReadFile(runs)
+		return strings.Count(string(data), "x")
+	}
+
+	cache, err := NewCache(filepath.Join(dir, "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		schema, err := cache.Describe(context.Background(), bin)
+		if err != nil || schema.Name != "gh" {
+			t.Fatalf("describe %d: %v, %v", i, schema, err)
+		}
+	}
+	if n := countRuns(); n != 1 {
+		t.Errorf("expected one --mtp-describe run, got %d", n)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "cache", "gh@2.40.0-*.json"))
+	if len(matches) != 1 {
+		t.Errorf("expected the schema stored by name and version, got %v", matches)
+	}
+
+	// Touched but identical: revalidated by hash, not rerun.
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(bin, later, later)
+	if _, err := cache.Describe(context.Background(), bin); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRuns(); n != 1 {
+		t.Errorf("an unchanged binary should not be rerun, got %d runs", n)
+	}
+
+	// Changed: described again.
+	os.WriteFile(bin, []byte(script+"# v2\n"), 0o755)
+	if _, err := cache.Describe(context.Background(), bin); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRuns(); n != 2 {
+		t.Errorf("a changed binary should be rerun, got %d runs", n)
+	}
+}
+
+func TestCacheFetch(t *testing.T) {
+	var requests, downloads int
+	maxAge := "max-age=3600"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", maxAge)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testSchema))
+	}))
+	defer srv.Close()
+
+	cache := &Cache{Dir: t.TempDir()}
+	for i := 0; i < 2; i++ {
+		if schema, err := cache.Fetch(context.Background(), srv.URL, FetchOptions{}); err != nil || schema.Name != "gh" {
+			t.Fatalf("fetch %d: %v, %v", i, schema, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("a fresh copy should be served without a request, got %d requests", requests)
+	}
+
+	// Expired: revalidated with the ETag.
+	rec, err := cache.record(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Expires = time.Now().Add(-time.Minute)
+	cache.saveRecord(rec)
+	maxAge = "no-cache"
+	for i := 0; i < 2; i++ {
+		if schema, err := cache.Fetch(context.Background(), srv.URL, FetchOptions{}); err != nil || schema.Name != "gh" {
+			t.Fatalf("revalidate %d: %v, %v", i, schema, err)
+		}
+	}
+	if requests != 3 || downloads != 1 {
+		t.Errorf("expected two revalidations and no new download, got %d requests and %d downloads", requests, downloads)
+	}
+}
+
+func TestCacheFetchUnexpectedNotModified(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer srv.Close()
+
+	trust := &TrustPolicy{}
+	cache := &Cache{Dir: t.TempDir()}
+	if _, err := cache.Fetch(context.Background(), srv.URL, FetchOptions{Trust: trust}); err == nil {
+		t.Error("expected an error for a 304 with nothing cached")
+	}
+
+	// A record whose schema file is gone.
+	cache.saveRecord(&cacheRecord{Source: srv.URL, Schema: "gone.json", ETag: `"v1"`})
+	if _, err := cache.Fetch(context.Background(), srv.URL, FetchOptions{Trust: trust}); err == nil {
+		t.Error("expected an error for a 304 with no loadable copy")
+	}
+}
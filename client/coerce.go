@@ -0,0 +1,228 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+// Coerce turns the arguments of a model's tool call into the argv words
+// for cmd, starting with its path. values is keyed by arg name without
+// dashes ("format" for --format), as the JSON Schema exporters name them, and
+// holds decoded JSON: strings, float64s or json.Numbers, bools, and []any.
+//
+// Each value is formatted for its arg's type and Style. Booleans are given
+// by presence unless the style is StyleExplicit or they default to true;
+// arrays repeat their flag unless the style is StyleComma. Models often
+// quote numbers and booleans, so "3" is accepted for an integer and "true"
+// for a boolean. Flags are written as --name=value, so a value can't be
+// mistaken for another flag, and positionals follow a "--" when one starts
+// with a dash.
+//
+// Unknown args, missing required ones, and values that don't fit their
+// type are errors, all reported together. Nil values are left out.
+func Coerce(cmd mtp.CommandDescriptor, values map[string]any) ([]string, error) {
+	var errs []error
+	known := map[string]bool{}
+	argv := slices.Clone(cmd.Path)
+	var positionals []string
+	for _, arg := range cmd.Args {
+		key := strings.TrimLeft(arg.Name, "-")
+		known[key] = true
+		v, ok := values[key]
+		if !ok || v == nil {
+			if arg.Required {
+				errs = append(errs, fmt.Errorf("%s is required", key))
+			}
+			continue
+		}
+		words, err := CoerceValue(arg, v)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if strings.HasPrefix(arg.Name, "-") {
+			argv = append(argv, words...)
+		} else {
+			positionals = append(positionals, words...)
+		}
+	}
+
+	var unknown []string
+	for key := range values {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("%s is not an argument of %s", key, cmd.Name))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if slices.ContainsFunc(positionals, func(p string) bool { return strings.HasPrefix(p, "-") }) {
+		argv = append(argv, "--")
+	}
+	return append(argv, positionals...), nil
+}
+
+// CoerceValue formats one JSON value for arg: the flag words, such as
+// ["--tag=a", "--tag=b"], or for a positional, its values. A false
+// boolean flag in the presence style has no words, unless it defaults to
+// true, when it's written as --name=false.
+func CoerceValue(arg mtp.ArgDescriptor, v any) ([]string, error) {
+	key := strings.TrimLeft(arg.Name, "-")
+	isFlag := strings.HasPrefix(arg.Name, "-")
+	flag := func(value string) string { return arg.Name + "=" + value }
+
+	if arg.Type == "array" || arg.Variadic {
+		items, ok := v.([]any)
+		if !ok {
+			items = []any{v}
+		}
+		strs := make([]string, len(items))
+		for i, item := range items {
+			s, err := scalar(arg, item)
+			if err != nil {
+				return nil, fmt.Errorf("%s[%d]: %w", key, i, err)
+			}
+			strs[i] = s
+		}
+		switch {
+		case !isFlag:
+			return strs, nil
+		case arg.Style == mtp.StyleComma:
+			for _, s := range strs {
+				if strings.Contains(s, ",") {
+					return nil, fmt.Errorf("%s: %q contains a comma, which the flag uses to separate values", key, s)
+				}
+			}
+			if len(strs) == 0 {
+				return nil, nil
+			}
+			return []string{flag(strings.Join(strs, ","))}, nil
+		}
+		words := make([]string, len(strs))
+		for i, s := range strs {
+			words[i] = flag(s)
+		}
+		return words, nil
+	}
+
+	s, err := scalar(arg, v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	switch {
+	case !isFlag:
+		return []string{s}, nil
+	case arg.Type == "boolean" && arg.Style != mtp.StyleExplicit:
+		switch {
+		case s == "true":
+			return []string{arg.Name}, nil
+		case defaultsTrue(arg):
+			return []string{flag(s)}, nil // Absence would leave it on
+		}
+		return nil, nil
+	}
+	return []string{flag(s)}, nil
+}
+
+// defaultsTrue reports whether a boolean arg is on unless turned off.
+func defaultsTrue(arg mtp.ArgDescriptor) bool {
+	switch d := arg.Default.(type) {
+	case bool:
+		return d
+	case string:
+		b, _ := strconv.ParseBool(d)
+		return b
+	}
+	return false
+}
+
+// scalar formats a single JSON value as arg's type.
+func scalar(arg mtp.ArgDescriptor, v any) (string, error) {
+	if n, ok := v.(json.Number); ok {
+		v = string(n) // Parsed like a quoted number below
+	}
+	switch arg.Type {
+	case "boolean":
+		switch v := v.(type) {
+		case bool:
+			return strconv.FormatBool(v), nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return strconv.FormatBool(b), nil
+			}
+		}
+		return "", fmt.Errorf("expected a boolean, got %s", describeJSON(v))
+	case "integer":
+		switch v := v.(type) {
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+				return strconv.FormatInt(int64(v), 10), nil
+			}
+		case string:
+			if _, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return strings.TrimSpace(v), nil
+			}
+		}
+		return "", fmt.Errorf("expected an integer, got %s", describeJSON(v))
+	case "number":
+		switch v := v.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+				return strings.TrimSpace(v), nil
+			}
+		}
+		return "", fmt.Errorf("expected a number, got %s", describeJSON(v))
+	case "enum":
+		s, ok := v.(string)
+		if !ok {
+			if f, isNum := v.(float64); isNum {
+				s, ok = strconv.FormatFloat(f, 'f', -1, 64), true
+			}
+		}
+		if ok && slices.Contains(arg.Values, s) {
+			return s, nil
+		}
+		return "", fmt.Errorf("expected one of [%s], got %s", strings.Join(arg.Values, " "), describeJSON(v))
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("expected a %s, got %s", arg.Type, describeJSON(v))
+}
+
+// describeJSON renders a value for an error message.
+func describeJSON(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case nil:
+		return "null"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
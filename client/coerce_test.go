@@ -0,0 +1,89 @@
+package client
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+func TestCoerce(t *testing.T) {
+	cmd := mtp.CommandDescriptor{
+		Name: "image convert",
+		Path: []string{"image", "convert"},
+		Args: []mtp.ArgDescriptor{
+			{Name: "inputs", Type: "path", Variadic: true, Required: true},
+			{Name: "--format", Type: "enum", Values: []string{"png", "webp"}},
+			{Name: "--quality", Type: "integer"},
+			{Name: "--scale", Type: "number"},
+			{Name: "--strip", Type: "boolean"},
+			{Name: "--overwrite", Type: "boolean", Style: mtp.StyleExplicit},
+			{Name: "--tag", Type: "array"},
+			{Name: "--exclude", Type: "array", Style: mtp.StyleComma},
+		},
+	}
+	var values map[string]any
+	decoded := `{
+		"inputs": ["a.png", "-b.png"],
+		"format": "webp",
+		"quality": "85",
+		"scale": 0.5,
+		"strip": true,
+		"overwrite": false,
+		"tag": ["x", "y"],
+		"exclude": ["*.tmp", "*.bak"]
+	}`
+	if err := json.Unmarshal([]byte(decoded), &values); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Coerce(cmd, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"image", "convert",
+		"--format=webp", "--quality=85", "--scale=0.5", "--strip", "--overwrite=false",
+		"--tag=x", "--tag=y", "--exclude=*.tmp,*.bak",
+		"--", "a.png", "-b.png",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+
+	got, err = Coerce(cmd, map[string]any{"inputs": "a.png", "strip": "false", "quality": 90.0})
+	if err != nil || !slices.Equal(got, []string{"image", "convert", "--quality=90", "a.png"}) {
+		t.Errorf("scalars and quoted booleans: %q, %v", got, err)
+	}
+
+	verify := mtp.ArgDescriptor{Name: "--verify", Type: "boolean", Default: true}
+	for v, want := range map[bool][]string{true: {"--verify"}, false: {"--verify=false"}} {
+		if got, err := CoerceValue(verify, v); err != nil || !slices.Equal(got, want) {
+			t.Errorf("--verify defaulting to true, given %v: %q, %v", v, got, err)
+		}
+	}
+
+	_, err = Coerce(cmd, map[string]any{
+		"format":  "gif",
+		"quality": 8.5,
+		"strip":   "maybe",
+		"exclude": []any{"a,b"},
+		"colour":  "red",
+	})
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, msg := range []string{
+		"inputs is required",
+		"format: expected one of [png webp], got \"gif\"",
+		"quality: expected an integer, got 8.5",
+		"strip: expected a boolean",
+		"exclude: \"a,b\" contains a comma",
+		"colour is not an argument of image convert",
+	} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("missing %q in:\n%v", msg, err)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+// ErrDenied is returned for a discovered tool that a DiscoveryPolicy
+// doesn't allow.
+var ErrDenied = errors.New("mtp: tool denied by discovery policy")
+
+// DiscoveryPolicy constrains which discovered tools are surfaced to a
+// model. Each rule applies only when it is set, and a tool must pass all
+// of them. The zero value allows everything.
+//
+// A policy is usually loaded from a JSON file maintained by a security
+// team; see LoadDiscoveryPolicy.
+type DiscoveryPolicy struct {
+	// Binaries allowlists tool executables, as path.Match patterns on
+	// their absolute path ("/usr/local/bin/*") or base name ("gh"). A tool
+	// whose executable can't be found is rejected.
+	Binaries []string `json:"binaries,omitempty"`
+	// Publishers that may sign manifests. When set, a tool must be signed
+	// by one of them (see TrustPolicy), unless AllowUnsigned.
+	Publishers    []Publisher `json:"publishers,omitempty"`
+	AllowUnsigned bool        `json:"allowUnsigned,omitempty"`
+	// DenyCapabilities rejects tools with a command that needs every
+	// capability of any one set, e.g. {"net", "fs:read"} for commands
+	// that could read local files and send them elsewhere.
+	DenyCapabilities [][]string `json:"denyCapabilities,omitempty"`
+	// MaxSchemaBytes rejects schemas larger than this, so one tool can't
+	// crowd the others out of a model's context.
+	MaxSchemaBytes int64 `json:"maxSchemaBytes,omitempty"`
+}
+
+// LoadDiscoveryPolicy reads a policy file:
+//
+//	{
+//	  "binaries": ["/usr/local/bin/*", "gh"],
+//	  "publishers": [{"name": "platform", "keys": ["<base64 Ed25519 key>"], "tools": ["*"]}],
+//	  "denyCapabilities": [["net", "fs:read"], ["exec", "clipboard"]],
+//	  "maxSchemaBytes": 262144
+//	}
+//
+// Unknown fields are an error, so a misspelled rule isn't silently ignored.
+func LoadDiscoveryPolicy(path string) (*DiscoveryPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var p DiscoveryPolicy
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, set := range p.DenyCapabilities {
+		if len(set) == 0 {
+			return nil, fmt.Errorf("%s: empty capability set in denyCapabilities", path)
+		}
+	}
+	return &p, nil
+}
+
+// Discovered is a tool found by Discover.
+type Discovered struct {
+	Schema     *mtp.ToolSchema
+	Source     string // The manifest or executable it was discovered from
+	Executable string // Absolute path of the tool's binary; "" if not found
+	Publisher  string // Who signed the manifest, if it was signed
+}
+
+// Rejection is a tool Discover found but didn't surface.
+type Rejection struct {
+	Source string
+	Err    error // Wraps ErrDenied or ErrUntrusted when the policy rejected it
+}
+
+// DiscoverOptions configures Discover.
+type DiscoverOptions struct {
+	// ManifestDirs are scanned for installed manifests, <name>.json. When
+	// nil, mtp.ManifestDirs() is used. A tool found in several directories
+	// is taken from the first.
+	ManifestDirs []string
+	// Executables are described by running them with --mtp-describe,
+	// through Cache when it is set.
+	Executables []string
+	Cache       *Cache
+	// Policy, when set, filters what is surfaced.
+	Policy *DiscoveryPolicy
+}
+
+// Discover finds the MTP tools installed on this machine: the manifests in
+// opts.ManifestDirs and the schemas of opts.Executables. A manifest's
+// executable is the tool's name looked up on $PATH. A manifest may be
+// signed by a file next to it, <name>.json.sig, holding the base64
+// signature and optionally a second line with the RFC 3339 signing time
+// (see SignedMessage).
+//
+// Tools that fail to load or that opts.Policy denies are returned as
+// rejections rather than errors, so one broken tool doesn't hide the rest.
+func Discover(ctx context.Context, opts DiscoverOptions) ([]Discovered, []Rejection) {
+	dirs := opts.ManifestDirs
+	if dirs == nil {
+		dirs = mtp.ManifestDirs()
+	}
+	var found []Discovered
+	var rejected []Rejection
+	seen := map[string]bool{}
+	surface := func(d Discovered, raw, sig []byte, signedAt time.Time) {
+		if seen[d.Schema.Name] {
+			return
+		}
+		seen[d.Schema.Name] = true
+		if opts.Policy != nil {
+			pub, err := opts.Policy.check(d, raw, sig, signedAt)
+			if err != nil {
+				rejected = append(rejected, Rejection{Source: d.Source, Err: err})
+				return
+			}
+			d.Publisher = pub
+		}
+		found = append(found, d)
+	}
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				rejected = append(rejected, Rejection{Source: dir, Err: err})
+			}
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			if err := opts.Policy.checkSize(path); err != nil {
+				rejected = append(rejected, Rejection{Source: path, Err: err})
+				continue
+			}
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				rejected = append(rejected, Rejection{Source: path, Err: err})
+				continue
+			}
+			schema, err := mtp.ParseSchema(raw, mtp.ParseOptions{PreserveUnknown: true})
+			if err != nil {
+				rejected = append(rejected, Rejection{Source: path, Err: fmt.Errorf("%s: %w", path, err)})
+				continue
+			}
+			sig, signedAt, err := readSignatureFile(path + ".sig")
+			if err != nil {
+				rejected = append(rejected, Rejection{Source: path, Err: err})
+				continue
+			}
+			d := Discovered{Schema: schema, Source: path}
+			if bin, err := exec.LookPath(schema.Name); err == nil {
+				d.Executable, _ = filepath.Abs(bin)
+			}
+			surface(d, raw, sig, signedAt)
+		}
+	}
+
+	for _, path := range opts.Executables {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			rejected = append(rejected, Rejection{Source: path, Err: err})
+			continue
+		}
+		var schema *mtp.ToolSchema
+		if opts.Cache != nil {
+			schema, err = opts.Cache.Describe(ctx, abs)
+		} else {
+			schema, err = mtp.DescribeExecutable(ctx, abs)
+		}
+		if err != nil {
+			rejected = append(rejected, Rejection{Source: path, Err: err})
+			continue
+		}
+		raw, err := json.Marshal(schema)
+		if err != nil {
+			rejected = append(rejected, Rejection{Source: path, Err: err})
+			continue
+		}
+		surface(Discovered{Schema: schema, Source: path, Executable: abs}, raw, nil, time.Time{})
+	}
+	return found, rejected
+}
+
+// readSignatureFile reads a detached manifest signature. A missing file
+// means the manifest is unsigned.
+func readSignatureFile(path string) (sig []byte, signedAt time.Time, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	b64, ts, _ := strings.Cut(strings.TrimSpace(string(data)), "\n")
+	if sig, err = base64.StdEncoding.DecodeString(strings.TrimSpace(b64)); err != nil {
+		return nil, time.Time{}, fmt.Errorf("%s: malformed signature", path)
+	}
+	if ts = strings.TrimSpace(ts); ts != "" {
+		if signedAt, err = time.Parse(time.RFC3339, ts); err != nil {
+			return nil, time.Time{}, fmt.Errorf("%s: malformed signing time", path)
+		}
+	}
+	return sig, signedAt, nil
+}
+
+// checkSize rejects a manifest file over MaxSchemaBytes before it is read.
+func (p *DiscoveryPolicy) checkSize(path string) error {
+	if p == nil || p.MaxSchemaBytes <= 0 {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() > p.MaxSchemaBytes {
+		return fmt.Errorf("%w: %s is %d bytes, more than the allowed %d", ErrDenied, path, info.Size(), p.MaxSchemaBytes)
+	}
+	return nil
+}
+
+// check applies the policy to a discovered tool whose schema is raw, and
+// returns the publisher that signed it.
+func (p *DiscoveryPolicy) check(d Discovered, raw, sig []byte, signedAt time.Time) (publisher string, err error) {
+	name := d.Schema.Name
+	if p.MaxSchemaBytes > 0 && int64(len(raw)) > p.MaxSchemaBytes {
+		return "", fmt.Errorf("%w: the schema of %s is %d bytes, more than the allowed %d", ErrDenied, name, len(raw), p.MaxSchemaBytes)
+	}
+	if len(p.Binaries) > 0 {
+		if d.Executable == "" {
+			return "", fmt.Errorf("%w: no executable found for %s", ErrDenied, name)
+		}
+		if !matchAny(p.Binaries, d.Executable) && !matchAny(p.Binaries, filepath.Base(d.Executable)) {
+			return "", fmt.Errorf("%w: %s is not an allowed binary", ErrDenied, d.Executable)
+		}
+	}
+	for _, cmd := range d.Schema.Commands {
+		for _, set := range p.DenyCapabilities {
+			if len(set) > 0 && !slices.ContainsFunc(set, func(c string) bool { return !slices.Contains(cmd.Capabilities, c) }) {
+				return "", fmt.Errorf("%w: %s %s needs %s", ErrDenied, name, cmd.Name, strings.Join(set, " and "))
+			}
+		}
+	}
+	if len(p.Publishers) > 0 {
+		trust := &TrustPolicy{Publishers: p.Publishers, AllowUnsigned: p.AllowUnsigned}
+		return trust.Check(name, SignedMessage(raw, signedAt), sig, signedAt)
+	}
+	return "", nil
+}
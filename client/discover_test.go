@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+func TestDiscover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as tools")
+	}
+	pub, key, _ := ed25519.GenerateKey(nil)
+	dir := t.TempDir()
+	manifests := filepath.Join(dir, "manifests")
+	bin := filepath.Join(dir, "bin")
+	for _, d := range []string{manifests, bin} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(path, data string, mode os.FileMode) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifest := func(name string, caps ...string) string {
+		s := &mtp.ToolSchema{SpecVersion: mtp.MTPSpecVersion, Name: name, Version: "1.0.0", Description: name,
+			Commands: []mtp.CommandDescriptor{{Name: "run", Capabilities: caps}}}
+		path := filepath.Join(manifests, name+".json")
+		if err := mtp.WriteManifest(path, s); err != nil {
+			t.Fatal(err)
+		}
+		write(filepath.Join(bin, name), "#!/bin/sh\n", 0o755)
+		return path
+	}
+	sign := func(path string, at time.Time) {
+		raw, _ := os.ReadFile(path)
+		sig := base64.StdEncoding.EncodeToString(Sign(key, raw, at))
+		write(path+".sig", sig+"\n"+at.UTC().Format(time.RFC3339)+"\n", 0o644)
+	}
+
+	sign(manifest("gh", mtp.CapNet), time.Now())
+	manifest("unsigned")
+	sign(manifest("exfil", mtp.CapNet, mtp.CapFSRead), time.Time{})
+	tampered := manifest("tampered")
+	sign(tampered, time.Now())
+	raw, _ := os.ReadFile(tampered)
+	write(tampered, strings.Replace(string(raw), `"1.0.0"`, `"1.0.1"`, 1), 0o644)
+	write(filepath.Join(manifests, "broken.json"), "{", 0o644)
+	t.Setenv("PATH", bin)
+
+	tool := filepath.Join(dir, "local-tool")
+	write(tool, "#!/bin/sh\necho '"+strings.Replace(testSchema, `"gh"`, `"local"`, 1)+"'\n", 0o755)
+
+	ctx := context.Background()
+	found, rejected := Discover(ctx, DiscoverOptions{ManifestDirs: []string{manifests}, Executables: []string{tool}})
+	if len(found) != 5 || len(rejected) != 1 {
+		t.Fatalf("without a policy: found %d, rejected %v", len(found), rejected)
+	}
+	for _, d := range found {
+		if d.Schema.Name == "gh" && d.Executable != filepath.Join(bin, "gh") {
+			t.Errorf("gh executable: %q", d.Executable)
+		}
+	}
+
+	policyFile := filepath.Join(dir, "policy.json")
+	write(policyFile, `{
+		"binaries": ["`+bin+`/*", "local-tool"],
+		"publishers": [{"name": "platform", "keys": ["`+base64.StdEncoding.EncodeToString(pub)+`"]}],
+		"denyCapabilities": [["net", "fs:read"]],
+		"maxSchemaBytes": 4096
+	}`, 0o644)
+	policy, err := LoadDiscoveryPolicy(policyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, rejected = Discover(ctx, DiscoverOptions{ManifestDirs: []string{manifests}, Executables: []string{tool}, Policy: policy})
+	if len(found) != 1 || found[0].Schema.Name != "gh" || found[0].Publisher != "platform" {
+		t.Fatalf("expected only the signed gh, got %+v", found)
+	}
+	reasons := map[string]error{}
+	for _, r := range rejected {
+		reasons[filepath.Base(r.Source)] = r.Err
+	}
+	for source, want := range map[string]error{
+		"unsigned.json": ErrUntrusted,
+		"tampered.json": ErrUntrusted,
+		"exfil.json":    ErrDenied,
+		"local-tool":    ErrUntrusted, // Allowlisted binary, but not signed
+	} {
+		if !errors.Is(reasons[source], want) {
+			t.Errorf("%s: expected %v, got %v", source, want, reasons[source])
+		}
+	}
+
+	policy.AllowUnsigned = true
+	policy.MaxSchemaBytes = 64
+	found, rejected = Discover(ctx, DiscoverOptions{ManifestDirs: []string{manifests}, Policy: policy})
+	if len(found) != 0 || !errors.Is(rejected[len(rejected)-1].Err, ErrDenied) {
+		t.Errorf("oversized manifests should be denied: %+v, %v", found, rejected)
+	}
+
+	policy = &DiscoveryPolicy{Binaries: []string{"gh"}}
+	found, _ = Discover(ctx, DiscoverOptions{ManifestDirs: []string{manifests}, Executables: []string{tool}, Policy: policy})
+	if len(found) != 1 || found[0].Schema.Name != "gh" {
+		t.Errorf("binary allowlist by name: %+v", found)
+	}
+
+	write(policyFile, `{"binaries": ["gh"], "allowUnsinged": true}`, 0o644)
+	if _, err := LoadDiscoveryPolicy(policyFile); err == nil {
+		t.Error("expected an error for a misspelled rule")
+	}
+}
@@ -0,0 +1,216 @@
+// Package client retrieves MTP tool schemas for agent hosts: over HTTP,
+// for hosts that store schema URLs rather than binaries, and through a disk
+// cache that spares rerunning --mtp-describe at every launch.
+//
+// # Signatures
+//
+// A server may sign a schema with Ed25519 over the exact response body and
+// send the base64-encoded signature in the Mtp-Signature header. A signature
+// made at a known time also covers that time, sent in Mtp-Signature-Time
+// (see SignedMessage and Sign). Set FetchOptions.VerifySignature, for
+// example to Ed25519Verifier(key), to reject schemas that aren't signed by a
+// trusted key, or FetchOptions.Trust for a full TrustPolicy.
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+// MediaType is the content type of an MTP schema document. Servers that
+// don't know it may answer with plain application/json.
+const MediaType = "application/vnd.mtp.schema+json"
+
+// SignatureHeader carries the base64 signature of a schema response.
+const SignatureHeader = "Mtp-Signature"
+
+// DefaultMaxBytes limits a fetched schema when FetchOptions.MaxBytes is 0.
+const DefaultMaxBytes = 16 << 20
+
+// ErrBadSignature is returned when a schema's signature is missing or
+// doesn't verify.
+var ErrBadSignature = errors.New("mtp: schema signature verification failed")
+
+// FetchOptions configures FetchSchema. The zero value fetches
+// unconditionally, with DefaultMaxBytes and no timeout beyond ctx.
+type FetchOptions struct {
+	// ETag is the entity tag of a copy the caller already has. The request
+	// is made conditional on it, and an unchanged schema comes back as
+	// NotModified instead of being downloaded again.
+	ETag string
+	// Timeout bounds the whole request, body included.
+	Timeout time.Duration
+	// MaxBytes limits the size of the response body.
+	MaxBytes int64
+	// VerifySignature, if set, is called with the signed message (see
+	// SignedMessage) and the decoded Mtp-Signature header. A missing header
+	// or a non-nil error rejects the schema with ErrBadSignature.
+	VerifySignature func(msg, signature []byte) error
+	// Trust, if set, is checked against every fetched schema; schemas it
+	// rejects fail with ErrUntrusted.
+	Trust *TrustPolicy
+	// HTTPClient sends the request; http.DefaultClient when nil.
+	HTTPClient *http.Client
+}
+
+// FetchResult is a fetched schema and the caching metadata that came with
+// it.
+type FetchResult struct {
+	Schema *mtp.ToolSchema // Nil when NotModified
+	Raw    []byte          // The response body, as signed
+	// ETag identifies this version of the schema; pass it back as
+	// FetchOptions.ETag to revalidate.
+	ETag string
+	// NotModified reports that the server confirmed the caller's ETag is
+	// still current.
+	NotModified bool
+	// Publisher is the TrustPolicy publisher that signed the schema, if
+	// FetchOptions.Trust was set and the schema was signed.
+	Publisher string
+	// Signature is the schema's signature, if it was signed, and SignedAt
+	// when, from Mtp-Signature-Time.
+	Signature []byte
+	SignedAt  time.Time
+	// MaxAge is how long the response may be reused without revalidating,
+	// from Cache-Control max-age. It is 0 when the server didn't say, or
+	// said no-cache or no-store.
+	MaxAge time.Duration
+}
+
+// FetchSchema retrieves the schema published at url. It asks for MediaType,
+// accepts any JSON content type, and makes the request conditional on
+// opts.ETag. A 304 response is an error unless opts.ETag was sent.
+func FetchSchema(ctx context.Context, url string, opts FetchOptions) (*FetchResult, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", MediaType+", application/json;q=0.9")
+	if opts.ETag != "" {
+		req.Header.Set("If-None-Match", opts.ETag)
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &FetchResult{ETag: resp.Header.Get("ETag"), MaxAge: maxAge(resp.Header.Get("Cache-Control"))}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if opts.ETag == "" {
+			return nil, fmt.Errorf("fetching %s: %s to a request without an ETag", url, resp.Status)
+		}
+		result.NotModified = true
+		if result.ETag == "" {
+			result.ETag = opts.ETag
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); !isJSON(ct) {
+		return nil, fmt.Errorf("fetching %s: unexpected content type %q", url, ct)
+	}
+
+	limit := opts.MaxBytes
+	if limit <= 0 {
+		limit = DefaultMaxBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("fetching %s: schema is larger than %d bytes", url, limit)
+	}
+
+	sig, signedAt, err := readSignature(resp.Header)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w: %v", url, ErrBadSignature, err)
+	}
+	msg := SignedMessage(body, signedAt)
+	if opts.VerifySignature != nil {
+		if len(sig) == 0 {
+			return nil, fmt.Errorf("fetching %s: %w: no %s header", url, ErrBadSignature, SignatureHeader)
+		}
+		if err := opts.VerifySignature(msg, sig); err != nil {
+			return nil, fmt.Errorf("fetching %s: %w: %v", url, ErrBadSignature, err)
+		}
+	}
+
+	schema, err := mtp.ParseSchema(body, mtp.ParseOptions{PreserveUnknown: true})
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	if opts.Trust != nil {
+		if result.Publisher, err = opts.Trust.Check(schema.Name, msg, sig, signedAt); err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", url, err)
+		}
+	}
+	result.Schema = schema
+	result.Raw = body
+	result.Signature = sig
+	result.SignedAt = signedAt
+	return result, nil
+}
+
+// Ed25519Verifier returns a FetchOptions.VerifySignature function that
+// accepts schemas signed with the private key matching any of keys. For a
+// policy with publishers and signature ages, use FetchOptions.Trust.
+func Ed25519Verifier(keys ...ed25519.PublicKey) func(msg, signature []byte) error {
+	return func(msg, signature []byte) error {
+		for _, k := range keys {
+			if len(k) == ed25519.PublicKeySize && ed25519.Verify(k, msg, signature) {
+				return nil
+			}
+		}
+		return errors.New("not signed by a trusted key")
+	}
+}
+
+// isJSON reports whether a Content-Type is application/json or a +json
+// type such as MediaType.
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
+
+// maxAge returns the max-age directive of a Cache-Control header, or 0
+// when the response must not be reused without revalidating.
+func maxAge(cacheControl string) time.Duration {
+	var age time.Duration
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache", "no-store":
+			return 0
+		case "max-age":
+			if secs, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && secs > 0 {
+				age = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return age
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+const testSchema = `{"specVersion":"` + mtp.MTPSpecVersion + `","name":"gh","version":"2.40.0","description":"GitHub","commands":[]}`
+
+func TestFetchSchema(t *testing.T) {
+	var accept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", MediaType)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Write([]byte(testSchema))
+	}))
+	defer srv.Close()
+
+	res, err := FetchSchema(context.Background(), srv.URL, FetchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Schema.Name != "gh" || res.ETag != `"v1"` || res.MaxAge != 5*time.Minute || string(res.Raw) != testSchema {
+		t.Errorf("unexpected result %+v", res)
+	}
+	if !strings.HasPrefix(accept, MediaType) {
+		t.Errorf("expected Accept to prefer %s, got %q", MediaType, accept)
+	}
+
+	res, err = FetchSchema(context.Background(), srv.URL, FetchOptions{ETag: `"v1"`})
+	if err != nil || !res.NotModified || res.Schema != nil || res.ETag != `"v1"` {
+		t.Errorf("expected not modified, got %+v, %v", res, err)
+	}
+}
+
+func TestFetchSchemaRejects(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		status      int
+		opts        FetchOptions
+		want        string
+	}{
+		{"status", "application/json", http.StatusNotFound, FetchOptions{}, "404"},
+		{"html", "text/html", http.StatusOK, FetchOptions{}, "content type"},
+		{"size", "application/json", http.StatusOK, FetchOptions{MaxBytes: 10}, "larger than 10 bytes"},
+		{"unconditional 304", "application/json", http.StatusNotModified, FetchOptions{}, "without an ETag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(testSchema))
+			}))
+			defer srv.Close()
+			if _, err := FetchSchema(context.Background(), srv.URL, tt.opts); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestFetchSchemaSignature(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	other, _, _ := ed25519.GenerateKey(nil)
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(testSchema)))
+
+	signed := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if signed {
+			w.Header().Set(SignatureHeader, sig)
+		}
+		w.Write([]byte(testSchema))
+	}))
+	defer srv.Close()
+
+	if _, err := FetchSchema(context.Background(), srv.URL, FetchOptions{VerifySignature: Ed25519Verifier(other, pub)}); err != nil {
+		t.Errorf("signature by a trusted key should verify: %v", err)
+	}
+	if _, err := FetchSchema(context.Background(), srv.URL, FetchOptions{VerifySignature: Ed25519Verifier(other)}); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for an untrusted key, got %v", err)
+	}
+	signed = false
+	if _, err := FetchSchema(context.Background(), srv.URL, FetchOptions{VerifySignature: Ed25519Verifier(pub)}); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature for an unsigned schema, got %v", err)
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":                     0,
+		"max-age=60":           time.Minute,
+		"public, max-age=3600": time.Hour,
+		"no-cache, max-age=60": 0,
+		"max-age=60, no-store": 0,
+		"max-age=abc":          0,
+	}
+	for header, want := range tests {
+		if got := maxAge(header); got != want {
+			t.Errorf("maxAge(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
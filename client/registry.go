@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+// ErrNoMatchingVersion is returned by Registry.Fetch when no published
+// version satisfies the constraint, or none of those that do is compatible.
+var ErrNoMatchingVersion = errors.New("mtp: no matching version")
+
+// Registry is a client for a registry server (see package registry).
+type Registry struct {
+	URL string // Base URL, e.g. "https://tools.acme.dev"
+	// Options applies to every schema fetch. ETag is ignored.
+	Options FetchOptions
+}
+
+// Versions lists a tool's published versions, newest first.
+func (r *Registry) Versions(ctx context.Context, name string) ([]string, error) {
+	if r.Options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Options.Timeout)
+		defer cancel()
+	}
+	u := strings.TrimSuffix(r.URL, "/") + "/v1/tools/" + url.PathEscape(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpClient := r.Options.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listing versions of %s: %s", name, resp.Status)
+	}
+	var list struct {
+		Versions []string `json:"versions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("listing versions of %s: %w", name, err)
+	}
+	sort.Slice(list.Versions, func(i, j int) bool { return CompareVersions(list.Versions[i], list.Versions[j]) > 0 })
+	return list.Versions, nil
+}
+
+// Fetch retrieves the newest version of a tool that satisfies constraint
+// (see ParseConstraint), e.g. Fetch(ctx, "filetool", ">=1.2 <2").
+func (r *Registry) Fetch(ctx context.Context, name, constraint string) (*FetchResult, error) {
+	return r.FetchCompatible(ctx, name, constraint, nil)
+}
+
+// FetchCompatible is Fetch, but skips versions with breaking changes from
+// current, the schema the caller already relies on (see mtp.Diff). Newer
+// versions are tried first, so the result is the newest safe upgrade. With
+// a nil current, it's Fetch.
+func (r *Registry) FetchCompatible(ctx context.Context, name, constraint string, current *mtp.ToolSchema) (*FetchResult, error) {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := r.Versions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	opts := r.Options
+	opts.ETag = ""
+	for _, v := range versions {
+		if !c.Match(v) {
+			continue
+		}
+		u := strings.TrimSuffix(r.URL, "/") + "/v1/tools/" + url.PathEscape(name) + "/" + url.PathEscape(v)
+		res, err := FetchSchema(ctx, u, opts)
+		if err != nil {
+			return nil, err
+		}
+		if current == nil || !mtp.HasBreaking(mtp.Diff(current, res.Schema)) {
+			return res, nil
+		}
+	}
+	return nil, fmt.Errorf("%w for %s %s", ErrNoMatchingVersion, name, constraint)
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+// fakeRegistry serves the registry API for filetool. Version 1.4.0
+// removed the info command; 1.3.0 only added one.
+func fakeRegistry(t *testing.T) *httptest.Server {
+	commands := map[string]string{
+		"1.1.0":      `[{"name":"info","description":""}]`,
+		"1.3.0":      `[{"name":"info","description":""},{"name":"stat","description":""}]`,
+		"1.4.0":      `[{"name":"stat","description":""}]`,
+		"2.0.0":      `[]`,
+		"2.1.0-beta": `[]`,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/tools/filetool" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"name":"filetool","versions":["1.1.0","2.0.0","1.4.0","2.1.0-beta","1.3.0"]}`)
+			return
+		}
+		v, ok := strings.CutPrefix(r.URL.Path, "/v1/tools/filetool/")
+		if !ok || commands[v] == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", MediaType)
+		fmt.Fprintf(w, `{"specVersion":%q,"name":"filetool","version":%q,"description":"","commands":%s}`, mtp.MTPSpecVersion, v, commands[v])
+	}))
+}
+
+func TestRegistryFetch(t *testing.T) {
+	srv := fakeRegistry(t)
+	defer srv.Close()
+	reg := &Registry{URL: srv.URL}
+	ctx := context.Background()
+
+	versions, err := reg.Versions(ctx, "filetool")
+	if err != nil || strings.Join(versions, " ") != "2.1.0-beta 2.0.0 1.4.0 1.3.0 1.1.0" {
+		t.Errorf("versions %v, %v", versions, err)
+	}
+
+	res, err := reg.Fetch(ctx, "filetool", ">=1.2 <2")
+	if err != nil || res.Schema.Version != "1.4.0" {
+		t.Errorf("expected 1.4.0, got %v, %v", res, err)
+	}
+
+	current, _ := mtp.ParseSchema([]byte(fmt.Sprintf(`{"specVersion":%q,"name":"filetool","version":"1.1.0","description":"","commands":[{"name":"info","description":""}]}`, mtp.MTPSpecVersion)), mtp.ParseOptions{})
+	res, err = reg.FetchCompatible(ctx, "filetool", ">=1.2 <2", current)
+	if err != nil || res.Schema.Version != "1.3.0" {
+		t.Errorf("expected 1.3.0, the newest without breaking changes, got %v, %v", res, err)
+	}
+
+	if _, err := reg.Fetch(ctx, "filetool", ">=3"); !errors.Is(err, ErrNoMatchingVersion) {
+		t.Errorf("expected ErrNoMatchingVersion, got %v", err)
+	}
+	if _, err := reg.Fetch(ctx, "missing", "*"); err == nil {
+		t.Error("an unknown tool should be an error")
+	}
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	pathpkg "path"
+	"time"
+)
+
+// SignatureTimeHeader carries the time a schema was signed, in RFC 3339
+// form. When it is present, the signature covers the time as well as the
+// body; see SignedMessage.
+const SignatureTimeHeader = "Mtp-Signature-Time"
+
+// ErrUntrusted is returned when a schema doesn't satisfy a TrustPolicy.
+var ErrUntrusted = errors.New("mtp: schema is not trusted")
+
+// clockSkew is how far in the future a signature time may be before it is
+// rejected.
+const clockSkew = 5 * time.Minute
+
+// SignedMessage returns the bytes a schema signature covers: the body
+// alone, or, for a signature made at a known time, the RFC 3339 time and a
+// newline followed by the body. Signing the time keeps it from being
+// altered to pass a TrustPolicy's age limits.
+func SignedMessage(body []byte, signedAt time.Time) []byte {
+	if signedAt.IsZero() {
+		return body
+	}
+	ts := signedAt.UTC().Format(time.RFC3339)
+	return append(append([]byte(ts), '\n'), body...)
+}
+
+// Sign signs a schema body at time at, for publishing with its signature in
+// the Mtp-Signature header and at in Mtp-Signature-Time. A zero at signs
+// the body alone.
+func Sign(key ed25519.PrivateKey, body []byte, at time.Time) []byte {
+	return ed25519.Sign(key, SignedMessage(body, at.Truncate(time.Second)))
+}
+
+// readSignature returns the signature and signing time from response
+// headers. Both are zero when the response isn't signed.
+func readSignature(h http.Header) (sig []byte, signedAt time.Time, err error) {
+	if v := h.Get(SignatureHeader); v != "" {
+		if sig, err = base64.StdEncoding.DecodeString(v); err != nil {
+			return nil, time.Time{}, fmt.Errorf("malformed %s header", SignatureHeader)
+		}
+	}
+	if v := h.Get(SignatureTimeHeader); v != "" {
+		if signedAt, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, time.Time{}, fmt.Errorf("malformed %s header", SignatureTimeHeader)
+		}
+	}
+	return sig, signedAt, nil
+}
+
+// Publisher is an organization or team whose signatures a TrustPolicy
+// accepts.
+type Publisher struct {
+	Name string
+	Keys []ed25519.PublicKey
+	// Tools limits which tools the publisher may sign, as path.Match
+	// patterns on the tool name ("acme-*"). Empty allows any tool.
+	Tools []string
+}
+
+// TrustPolicy decides which fetched schemas an agent host may load. The
+// zero value trusts nothing: every schema must be signed by a publisher.
+type TrustPolicy struct {
+	Publishers []Publisher
+	// AllowUnsigned accepts schemas without a signature. Signed schemas
+	// must still verify against a publisher.
+	AllowUnsigned bool
+	// MinSignatureAge rejects schemas signed more recently, giving a
+	// window to revoke a compromised release before agents pick it up.
+	MinSignatureAge time.Duration
+	// MaxSignatureAge rejects schemas signed longer ago, so old releases
+	// must be re-signed to stay loadable.
+	MaxSignatureAge time.Duration
+	// Now returns the current time; time.Now when nil.
+	Now func() time.Time
+}
+
+// Check verifies a schema for tool against the policy: msg is the signed
+// message (see SignedMessage), sig the signature, and signedAt the signing
+// time, zero if unknown. It returns the name of the publisher whose key
+// verified the signature, which is empty for an accepted unsigned schema.
+// Errors wrap ErrUntrusted.
+func (p *TrustPolicy) Check(tool string, msg, sig []byte, signedAt time.Time) (publisher string, err error) {
+	if len(sig) == 0 {
+		if p.AllowUnsigned {
+			return "", nil
+		}
+		return "", fmt.Errorf("%w: %s is not signed", ErrUntrusted, tool)
+	}
+
+	var signer *Publisher
+	for i, pub := range p.Publishers {
+		for _, k := range pub.Keys {
+			if len(k) == ed25519.PublicKeySize && ed25519.Verify(k, msg, sig) {
+				signer = &p.Publishers[i]
+				break
+			}
+		}
+		if signer != nil {
+			break
+		}
+	}
+	if signer == nil {
+		return "", fmt.Errorf("%w: %s is not signed by a trusted publisher", ErrUntrusted, tool)
+	}
+	if len(signer.Tools) > 0 && !matchAny(signer.Tools, tool) {
+		return "", fmt.Errorf("%w: %s may not sign %s", ErrUntrusted, signer.Name, tool)
+	}
+
+	if p.MinSignatureAge > 0 || p.MaxSignatureAge > 0 {
+		if signedAt.IsZero() {
+			return "", fmt.Errorf("%w: the signature of %s has no %s", ErrUntrusted, tool, SignatureTimeHeader)
+		}
+		now := time.Now()
+		if p.Now != nil {
+			now = p.Now()
+		}
+		age := now.Sub(signedAt)
+		switch {
+		case age < -clockSkew:
+			return "", fmt.Errorf("%w: %s was signed in the future (%s)", ErrUntrusted, tool, signedAt.Format(time.RFC3339))
+		case p.MinSignatureAge > 0 && age < p.MinSignatureAge:
+			return "", fmt.Errorf("%w: %s was signed %s ago, less than the required %s", ErrUntrusted, tool, age.Round(time.Second), p.MinSignatureAge)
+		case p.MaxSignatureAge > 0 && age > p.MaxSignatureAge:
+			return "", fmt.Errorf("%w: %s was signed %s ago, more than the allowed %s", ErrUntrusted, tool, age.Round(time.Second), p.MaxSignatureAge)
+		}
+	}
+	return signer.Name, nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pat := range patterns {
+		if ok, _ := pathpkg.Match(pat, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrustPolicy(t *testing.T) {
+	acmePub, acme, _ := ed25519.GenerateKey(nil)
+	infraPub, infra, _ := ed25519.GenerateKey(nil)
+	_, stranger, _ := ed25519.GenerateKey(nil)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	body := []byte(testSchema)
+
+	policy := &TrustPolicy{
+		Publishers: []Publisher{
+			{Name: "acme", Keys: []ed25519.PublicKey{acmePub}},
+			{Name: "infra", Keys: []ed25519.PublicKey{infraPub}, Tools: []string{"deploy", "infra-*"}},
+		},
+		MinSignatureAge: time.Hour,
+		MaxSignatureAge: 90 * 24 * time.Hour,
+		Now:             func() time.Time { return now },
+	}
+	check := func(tool string, key ed25519.PrivateKey, at time.Time) (string, error) {
+		var sig []byte
+		if key != nil {
+			sig = Sign(key, body, at)
+		}
+		return policy.Check(tool, SignedMessage(body, at), sig, at)
+	}
+
+	if pub, err := check("gh", acme, now.Add(-2*time.Hour)); err != nil || pub != "acme" {
+		t.Errorf("acme-signed gh: %q, %v", pub, err)
+	}
+	if pub, err := check("infra-dns", infra, now.Add(-2*time.Hour)); err != nil || pub != "infra" {
+		t.Errorf("infra-signed infra-dns: %q, %v", pub, err)
+	}
+
+	rejected := map[string]func() (string, error){
+		"unsigned":      func() (string, error) { return check("gh", nil, time.Time{}) },
+		"untrusted key": func() (string, error) { return check("gh", stranger, now.Add(-2*time.Hour)) },
+		"out of scope":  func() (string, error) { return check("gh", infra, now.Add(-2*time.Hour)) },
+		"too new":       func() (string, error) { return check("gh", acme, now.Add(-time.Minute)) },
+		"too old":       func() (string, error) { return check("gh", acme, now.Add(-100*24*time.Hour)) },
+		"no time":       func() (string, error) { return check("gh", acme, time.Time{}) },
+		"future":        func() (string, error) { return check("gh", acme, now.Add(time.Hour)) },
+		"time altered after": func() (string, error) {
+			return policy.Check("gh", SignedMessage(body, now.Add(-2*time.Hour)), Sign(acme, body, now), now.Add(-2*time.Hour))
+		},
+	}
+	for name, f := range rejected {
+		if _, err := f(); !errors.Is(err, ErrUntrusted) {
+			t.Errorf("%s: expected ErrUntrusted, got %v", name, err)
+		}
+	}
+
+	policy.AllowUnsigned = true
+	if pub, err := check("gh", nil, time.Time{}); err != nil || pub != "" {
+		t.Errorf("AllowUnsigned should accept unsigned schemas: %q, %v", pub, err)
+	}
+	if _, err := check("gh", stranger, now.Add(-2*time.Hour)); err == nil {
+		t.Error("AllowUnsigned should still reject bad signatures")
+	}
+}
+
+func TestFetchTrust(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	signedAt := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MediaType)
+		w.Header().Set("Cache-Control", "max-age=3600")
+		w.Header().Set(SignatureHeader, base64.StdEncoding.EncodeToString(Sign(priv, []byte(testSchema), signedAt)))
+		w.Header().Set(SignatureTimeHeader, signedAt.UTC().Format(time.RFC3339))
+		w.Write([]byte(testSchema))
+	}))
+	defer srv.Close()
+
+	policy := &TrustPolicy{Publishers: []Publisher{{Name: "acme", Keys: []ed25519.PublicKey{pub}}}, MinSignatureAge: 24 * time.Hour}
+	res, err := FetchSchema(context.Background(), srv.URL, FetchOptions{Trust: policy, VerifySignature: Ed25519Verifier(pub)})
+	if err != nil || res.Publisher != "acme" || !res.SignedAt.Equal(signedAt) {
+		t.Fatalf("trusted fetch: %+v, %v", res, err)
+	}
+
+	cache := &Cache{Dir: t.TempDir()}
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Fetch(context.Background(), srv.URL, FetchOptions{Trust: policy}); err != nil {
+			t.Fatalf("fetch %d: %v", i, err)
+		}
+	}
+	policy.MinSignatureAge = 72 * time.Hour
+	if _, err := cache.Fetch(context.Background(), srv.URL, FetchOptions{Trust: policy}); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("a tightened policy should reject the cached copy, got %v", err)
+	}
+	if _, err := FetchSchema(context.Background(), srv.URL, FetchOptions{Trust: &TrustPolicy{}}); err == nil || !strings.Contains(err.Error(), "trusted publisher") {
+		t.Errorf("an empty policy should trust nothing, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed version: up to three numeric release parts, with
+// missing ones zero, and the pre-release identifiers. Build metadata is
+// dropped, as it doesn't affect ordering.
+type semver struct {
+	release [3]int
+	parts   int // How many release parts were written, for x-ranges
+	pre     []string
+}
+
+// parseSemver parses "1", "1.2", "v1.2.3", "1.2.3-rc.1+build.5", and the
+// like.
+func parseSemver(s string) (semver, bool) {
+	var v semver
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, pre, hasPre := strings.Cut(s, "-")
+	if hasPre {
+		if pre == "" {
+			return v, false
+		}
+		v.pre = strings.Split(pre, ".")
+	}
+	nums := strings.Split(s, ".")
+	if len(nums) > 3 {
+		return v, false
+	}
+	for i, n := range nums {
+		x, err := strconv.Atoi(n)
+		if err != nil || x < 0 {
+			return v, false
+		}
+		v.release[i] = x
+	}
+	v.parts = len(nums)
+	return v, true
+}
+
+// compare orders versions by semver precedence.
+func (v semver) compare(w semver) int {
+	for i := range v.release {
+		if v.release[i] != w.release[i] {
+			return cmpInt(v.release[i], w.release[i])
+		}
+	}
+	switch {
+	case len(v.pre) == 0 && len(w.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(w.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.pre) && i < len(w.pre); i++ {
+		a, b := v.pre[i], w.pre[i]
+		x, errA := strconv.Atoi(a)
+		y, errB := strconv.Atoi(b)
+		switch {
+		case errA == nil && errB == nil:
+			if x != y {
+				return cmpInt(x, y)
+			}
+		case errA == nil:
+			return -1 // Numeric identifiers sort before alphanumeric ones
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(a, b); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmpInt(len(v.pre), len(w.pre))
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// CompareVersions orders two tool versions by semantic versioning
+// precedence: "1.0.0-rc.1" < "1.0.0" < "1.2" < "1.10.0". A leading "v" is
+// ignored and missing parts count as zero. Versions that aren't semantic
+// versions sort before those that are, and among themselves as strings.
+func CompareVersions(a, b string) int {
+	va, okA := parseSemver(a)
+	vb, okB := parseSemver(b)
+	switch {
+	case okA && okB:
+		if c := va.compare(vb); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case okA:
+		return 1
+	case okB:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// Constraint is a parsed version constraint; see ParseConstraint.
+type Constraint struct {
+	alternatives [][]comparator // OR of ANDs
+	pre          bool           // Some comparator names a pre-release
+}
+
+type comparator struct {
+	op string // "<", "<=", ">", ">=", "=", "!="
+	v  semver
+}
+
+// ParseConstraint parses a version constraint such as ">=1.2 <2". It is a
+// space-separated list of comparators that must all hold, and "||" joins
+// alternatives. Supported forms:
+//
+//	>=1.2  >1.2  <=1.2  <1.2  !=1.2.3
+//	1.2.3, =1.2.3   exactly that version
+//	1.2, 1.2.x      any 1.2 version (>=1.2.0 <1.3.0)
+//	^1.2.3          compatible: >=1.2.3 <2.0.0 (<0.3.0 for 0.2.3)
+//	~1.2.3          patch updates: >=1.2.3 <1.3.0
+//	*, ""           any version
+//
+// Pre-release versions only match when a comparator names a pre-release.
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{}
+	for _, alt := range strings.Split(s, "||") {
+		var cmps []comparator
+		for _, field := range strings.Fields(alt) {
+			parsed, err := parseComparator(field)
+			if err != nil {
+				return nil, fmt.Errorf("constraint %q: %w", s, err)
+			}
+			for _, p := range parsed {
+				if len(p.v.pre) > 0 {
+					c.pre = true
+				}
+			}
+			cmps = append(cmps, parsed...)
+		}
+		c.alternatives = append(c.alternatives, cmps)
+	}
+	return c, nil
+}
+
+// parseComparator expands one constraint term into basic comparators.
+func parseComparator(term string) ([]comparator, error) {
+	if term == "*" || term == "x" {
+		return nil, nil
+	}
+	op := ""
+	for _, prefix := range []string{">=", "<=", "!=", "==", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, prefix) {
+			op, term = prefix, term[len(prefix):]
+			break
+		}
+	}
+	// "1.2.x" and "1.2.*" are written-out partial versions.
+	for strings.HasSuffix(term, ".x") || strings.HasSuffix(term, ".*") {
+		term = term[:len(term)-2]
+	}
+	v, ok := parseSemver(term)
+	if !ok {
+		return nil, fmt.Errorf("invalid version %q", term)
+	}
+
+	switch op {
+	case "^":
+		upper := semver{}
+		switch {
+		case v.release[0] > 0 || v.parts == 1:
+			upper.release[0] = v.release[0] + 1
+		case v.release[1] > 0 || v.parts == 2:
+			upper.release[1] = v.release[1] + 1
+		default:
+			upper.release[2] = v.release[2] + 1
+		}
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	case "~":
+		upper := semver{}
+		if v.parts == 1 {
+			upper.release[0] = v.release[0] + 1
+		} else {
+			upper.release[0], upper.release[1] = v.release[0], v.release[1]+1
+		}
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	case "", "=", "==":
+		if v.parts == 3 || len(v.pre) > 0 {
+			return []comparator{{"=", v}}, nil
+		}
+		upper := v
+		upper.release[v.parts-1]++
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	}
+	return []comparator{{op, v}}, nil
+}
+
+// Match reports whether version satisfies the constraint.
+func (c *Constraint) Match(version string) bool {
+	v, ok := parseSemver(version)
+	if !ok || (len(v.pre) > 0 && !c.pre) {
+		return false
+	}
+	for _, alt := range c.alternatives {
+		if matchAll(alt, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAll(cmps []comparator, v semver) bool {
+	for _, cmp := range cmps {
+		d := v.compare(cmp.v)
+		var ok bool
+		switch cmp.op {
+		case "<":
+			ok = d < 0
+		case "<=":
+			ok = d <= 0
+		case ">":
+			ok = d > 0
+		case ">=":
+			ok = d >= 0
+		case "=":
+			ok = d == 0
+		case "!=":
+			ok = d != 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package client
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	ordered := []string{"nightly", "0.9", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-rc.1", "1.0.0", "v1.0.1", "1.2", "1.10.0", "10.0.0"}
+	for i := 0; i < len(ordered)-1; i++ {
+		if CompareVersions(ordered[i], ordered[i+1]) >= 0 {
+			t.Errorf("%s should sort before %s", ordered[i], ordered[i+1])
+		}
+		if CompareVersions(ordered[i+1], ordered[i]) <= 0 {
+			t.Errorf("%s should sort after %s", ordered[i+1], ordered[i])
+		}
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		{">=1.2 <2", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0", "2.0.0-rc.1", "1.5.0-beta"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0"}},
+		{"^0.2.3", []string{"0.2.9"}, []string{"0.3.0"}},
+		{"~1.2.3", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"~1", []string{"1.9.0"}, []string{"2.0.0"}},
+		{"1.2", []string{"1.2.0", "1.2.7"}, []string{"1.3.0"}},
+		{"1.2.x", []string{"1.2.7"}, []string{"1.3.0"}},
+		{"=1.2.3", []string{"1.2.3", "v1.2.3"}, []string{"1.2.4"}},
+		{"1.0.0-rc.1", []string{"1.0.0-rc.1"}, []string{"1.0.0"}},
+		{">=2.0.0-rc.1", []string{"2.0.0-rc.2", "2.0.0"}, []string{"2.0.0-beta"}},
+		{"<1 || >=3", []string{"0.5.0", "3.1.0"}, []string{"2.0.0"}},
+		{"*", []string{"0.0.1", "9.9.9"}, []string{"nightly"}},
+		{"", []string{"1.0.0"}, nil},
+		{">1.0 !=1.5.0", []string{"1.4.0"}, []string{"1.5.0", "1.0.0"}},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("%q: %v", tt.constraint, err)
+			continue
+		}
+		for _, v := range tt.match {
+			if !c.Match(v) {
+				t.Errorf("%q should match %s", tt.constraint, v)
+			}
+		}
+		for _, v := range tt.noMatch {
+			if c.Match(v) {
+				t.Errorf("%q should not match %s", tt.constraint, v)
+			}
+		}
+	}
+
+	for _, bad := range []string{">=one", "1.2.3.4", "^"} {
+		if _, err := ParseConstraint(bad); err == nil {
+			t.Errorf("%q should not parse", bad)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Command mtp-registry serves a private MTP tool catalog: tools publish
+// their schemas to it, and agent hosts search it and fetch schemas by
+// version. See package registry for the HTTP API.
+//
+//	mtp-registry --dir /var/lib/mtp-registry --trusted-key <base64 Ed25519 public key>
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/modeltoolsprotocol/go-sdk/registry"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCmd() *cobra.Command {
+	var addr, dir string
+	var keys []string
+	root := &cobra.Command{
+		Use:          "mtp-registry",
+		Short:        "Serve an MTP tool registry",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			srv := &registry.Server{Storage: registry.NewDirStorage(dir)}
+			for _, k := range keys {
+				key, err := base64.StdEncoding.DecodeString(k)
+				if err != nil || len(key) != ed25519.PublicKeySize {
+					return fmt.Errorf("--trusted-key %q is not a base64 Ed25519 public key", k)
+				}
+				srv.TrustedKeys = append(srv.TrustedKeys, ed25519.PublicKey(key))
+			}
+			if len(srv.TrustedKeys) == 0 {
+				log.Print("no --trusted-key given; accepting unsigned schemas")
+			}
+			log.Printf("serving %s on %s", dir, addr)
+			return http.ListenAndServe(addr, srv)
+		},
+	}
+	root.Flags().StringVar(&addr, "addr", ":8080", "Address to listen on")
+	root.Flags().StringVar(&dir, "dir", "mtp-registry-data", "Directory to store published schemas in")
+	root.Flags().StringArrayVar(&keys, "trusted-key", nil, "Base64 Ed25519 public key allowed to publish (repeatable)")
+	return root
+}
@@ -0,0 +1,46 @@
+// Command mtp-toolbox runs a directory of MTP tools as one binary:
+// "mtp-toolbox gh pr list" runs the tool named gh with "pr list", and
+// "mtp-toolbox --mtp-describe" prints the merged schema of every tool.
+//
+// The directory is $MTP_TOOLBOX_DIR, or tools/ next to the executable.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+// dirEnvVar overrides the directory the toolbox loads its tools from.
+const dirEnvVar = "MTP_TOOLBOX_DIR"
+
+func main() {
+	dir, err := toolsDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	box, err := mtp.LoadToolbox(context.Background(), dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := box.Command(filepath.Base(os.Args[0])).Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// toolsDir returns the directory to load tools from.
+func toolsDir() (string, error) {
+	if dir := os.Getenv(dirEnvVar); dir != "" {
+		return dir, nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(exe), "tools"), nil
+}
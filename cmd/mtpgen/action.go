@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/spf13/cobra"
+)
+
+func newActionCmd() *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:   "action <tool-or-schema.json> [command]",
+		Short: "Generate a GitHub Action that runs one of the tool's commands",
+		Long: "Write action.yml and entrypoint.sh for a composite GitHub Action. " +
+			"The command may be omitted when the tool has only one.",
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := readSchema(args[0])
+			if err != nil {
+				return err
+			}
+
+			var name string
+			switch {
+			case len(args) == 2:
+				name = args[1]
+			case len(schema.Commands) == 1:
+				name = schema.Commands[0].Name
+			default:
+				return fmt.Errorf("%s has %d commands; name the one to publish", schema.Name, len(schema.Commands))
+			}
+
+			action, err := mtp.ToGitHubAction(schema, name)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(dir, "action.yml"), []byte(action.ActionYAML), 0o644); err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(dir, "entrypoint.sh"), []byte(action.Entrypoint), 0o755); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), filepath.Join(dir, "action.yml"))
+			fmt.Fprintln(cmd.OutOrStdout(), filepath.Join(dir, "entrypoint.sh"))
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&dir, "dir", "d", ".", "Directory to write the action files to")
+	return cmd
+}
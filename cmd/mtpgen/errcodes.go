@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/spf13/cobra"
+)
+
+// sdkImportPath is the import path whose NewError calls errcodes checks.
+const sdkImportPath = "github.com/modeltoolsprotocol/go-sdk"
+
+func newErrCodesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "errcodes <package-dir>",
+		Short: "Check that every mtp.NewError code in the source is declared",
+		Long: "Build and describe the tool in package-dir, then scan the Go files below it for " +
+			"mtp.NewError calls whose code isn't in the schema's errorCodes. NewError panics on " +
+			"undeclared codes at run time; this finds them before they ship. Only codes written " +
+			"as string literals are checked.\n\n" +
+			"Typical use, in the tool's main package:\n\n" +
+			"  //go:generate go run github.com/modeltoolsprotocol/go-sdk/cmd/mtpgen errcodes .",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := readSchema(args[0])
+			if err != nil {
+				return err
+			}
+			undeclared, err := undeclaredErrorCodes(args[0], schema.ErrorCodes)
+			if err != nil {
+				return err
+			}
+			for _, u := range undeclared {
+				fmt.Fprintln(cmd.ErrOrStderr(), u)
+			}
+			if len(undeclared) > 0 {
+				return fmt.Errorf("%d undeclared error code(s)", len(undeclared))
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+// undeclaredErrorCodes scans the Go files below dir, skipping tests,
+// testdata, vendor, and hidden directories, and returns a "file:line: ..."
+// finding for each mtp.NewError call with a literal code not in declared.
+func undeclaredErrorCodes(dir string, declared []mtp.ErrorCode) ([]string, error) {
+	known := map[string]bool{}
+	for _, c := range declared {
+		known[c.Code] = true
+	}
+
+	var findings []string
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		pkg := sdkName(file)
+		if pkg == "" {
+			return nil
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "NewError" {
+				return true
+			}
+			if x, ok := sel.X.(*ast.Ident); !ok || x.Name != pkg {
+				return true
+			}
+			lit, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			code, err := strconv.Unquote(lit.Value)
+			if err == nil && !known[code] {
+				findings = append(findings, fmt.Sprintf("%s: undeclared error code %q", fset.Position(lit.Pos()), code))
+			}
+			return true
+		})
+		return nil
+	})
+	return findings, err
+}
+
+// sdkName returns the name file uses for the SDK package, or "" if it
+// doesn't import it.
+func sdkName(file *ast.File) string {
+	for _, imp := range file.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path != sdkImportPath {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return "mtp"
+	}
+	return ""
+}
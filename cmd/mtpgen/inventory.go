@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/modeltoolsprotocol/go-sdk/client"
+	"github.com/spf13/cobra"
+)
+
+// catalog is the document mtpgen inventory writes: every tool found, with
+// each distinct schema it was seen with.
+type catalog struct {
+	Tools []*catalogTool `json:"tools"`
+}
+
+// catalogTool groups the schemas found for one tool name.
+type catalogTool struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Versions    []*catalogVersion `json:"versions"`
+}
+
+// catalogVersion is one distinct schema of a tool. Two sources that
+// report the same version with different schemas get separate entries,
+// told apart by digest.
+type catalogVersion struct {
+	Version  string          `json:"version"`
+	Digest   string          `json:"digest"`
+	Sources  []string        `json:"sources"`
+	Commands []string        `json:"commands"`
+	Schema   *mtp.ToolSchema `json:"schema"`
+}
+
+func newInventoryCmd() *cobra.Command {
+	var from, out string
+	var update bool
+
+	cmd := &cobra.Command{
+		Use:   "inventory [source...]",
+		Short: "Merge the schemas of many tools into one catalog",
+		Long: "Describe every source (tool binaries, .json schemas, or docker://<image> references) " +
+			"and write a catalog grouping them by tool and version. Identical schemas found in " +
+			"several places are listed once, with every source. With --update, the catalog at " +
+			"--output is read first, so versions seen in earlier runs are kept.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sources := args
+			if from != "" {
+				listed, err := readSourceList(from)
+				if err != nil {
+					return err
+				}
+				sources = append(sources, listed...)
+			}
+			if len(sources) == 0 {
+				return errors.New("no sources given")
+			}
+
+			var cat catalog
+			if update && out != "" {
+				if err := readCatalog(out, &cat); err != nil {
+					return err
+				}
+			}
+			for _, src := range sources {
+				schema, err := readSchema(src)
+				if err != nil {
+					return err
+				}
+				if err := cat.add(src, schema); err != nil {
+					return err
+				}
+			}
+			cat.sort()
+			for _, tool := range cat.Tools {
+				for _, v := range conflictingVersions(tool) {
+					fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s %s was found with different schemas\n", tool.Name, v)
+				}
+			}
+
+			data, err := json.MarshalIndent(cat, "", "  ")
+			if err != nil {
+				return err
+			}
+			data = append(data, '\n')
+			if out == "" {
+				_, err = cmd.OutOrStdout().Write(data)
+				return err
+			}
+			return os.WriteFile(out, data, 0o644)
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "Read sources from this file, one per line (# starts a comment)")
+	cmd.Flags().StringVarP(&out, "output", "o", "", "Write the catalog to this file instead of stdout")
+	cmd.Flags().BoolVar(&update, "update", false, "Merge into the existing catalog at --output")
+	return cmd
+}
+
+// readSourceList reads one source per line, skipping blank lines and
+// comments.
+func readSourceList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sources []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			sources = append(sources, line)
+		}
+	}
+	return sources, sc.Err()
+}
+
+// readCatalog loads an existing catalog. A missing file is an empty catalog.
+func readCatalog(path string, cat *catalog) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, cat); err != nil {
+		return fmt.Errorf("reading catalog %s: %w", path, err)
+	}
+	return nil
+}
+
+// add records that source provides schema.
+func (c *catalog) add(source string, schema *mtp.ToolSchema) error {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	var tool *catalogTool
+	for _, t := range c.Tools {
+		if t.Name == schema.Name {
+			tool = t
+			break
+		}
+	}
+	if tool == nil {
+		tool = &catalogTool{Name: schema.Name}
+		c.Tools = append(c.Tools, tool)
+	}
+
+	for _, v := range tool.Versions {
+		if v.Digest == digest {
+			if !slices.Contains(v.Sources, source) {
+				v.Sources = append(v.Sources, source)
+			}
+			return nil
+		}
+	}
+
+	commands := make([]string, len(schema.Commands))
+	for i, cmd := range schema.Commands {
+		commands[i] = cmd.Name
+	}
+	tool.Versions = append(tool.Versions, &catalogVersion{
+		Version:  schema.Version,
+		Digest:   digest,
+		Sources:  []string{source},
+		Commands: commands,
+		Schema:   schema,
+	})
+	return nil
+}
+
+// sort orders tools by name and versions newest first, and takes each
+// tool's description from its newest version.
+func (c *catalog) sort() {
+	sort.Slice(c.Tools, func(i, j int) bool { return c.Tools[i].Name < c.Tools[j].Name })
+	for _, tool := range c.Tools {
+		sort.SliceStable(tool.Versions, func(i, j int) bool {
+			if cmp := client.CompareVersions(tool.Versions[i].Version, tool.Versions[j].Version); cmp != 0 {
+				return cmp > 0
+			}
+			return tool.Versions[i].Digest < tool.Versions[j].Digest
+		})
+		for _, v := range tool.Versions {
+			sort.Strings(v.Sources)
+		}
+		if len(tool.Versions) > 0 {
+			tool.Description = tool.Versions[0].Schema.Description
+		}
+	}
+}
+
+// conflictingVersions returns the versions of tool that appear with more
+// than one schema.
+func conflictingVersions(tool *catalogTool) []string {
+	var out []string
+	for i := 1; i < len(tool.Versions); i++ {
+		v := tool.Versions[i].Version
+		if v == tool.Versions[i-1].Version && (len(out) == 0 || out[len(out)-1] != v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+// Command mtpgen generates artifacts from MTP tool schemas.
+//
+// Every subcommand takes a schema source: a .json schema file, a tool
+// binary, which is run once with --mtp-describe, a Go main package
+// directory, which is built and then run the same way, or docker://<image>,
+// whose schema is read from the image's labels.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:          "mtpgen",
+		Short:        "Generate artifacts from MTP tool schemas",
+		SilenceUsage: true,
+	}
+	root.AddCommand(newManifestCmd())
+	root.AddCommand(newActionCmd())
+	root.AddCommand(newInventoryCmd())
+	root.AddCommand(newScaffoldCmd())
+	root.AddCommand(newSchemaCmd())
+	root.AddCommand(newErrCodesCmd())
+	mtp.WithDescribe(root, nil)
+	return root
+}
+
+// imagePrefix marks a schema source as a container image reference.
+const imagePrefix = "docker://"
+
+// readSchema loads a schema from a .json file, from an image's labels, or
+// by running a tool binary (built first from a package directory) with
+// --mtp-describe.
+func readSchema(source string) (*mtp.ToolSchema, error) {
+	if ref, ok := strings.CutPrefix(source, imagePrefix); ok {
+		return readImageSchema(ref)
+	}
+	if strings.HasSuffix(source, ".json") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, err
+		}
+		return mtp.ParseSchema(data, mtp.ParseOptions{PreserveUnknown: true})
+	}
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		bin, cleanup, err := buildPackage(source)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		source = bin
+	}
+
+	var stderr bytes.Buffer
+	c := exec.Command(source, "--mtp-describe")
+	c.Env = append(os.Environ(), mtp.LiveSchemaEnvVar+"=1")
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running %s --mtp-describe: %w: %s", source, err, strings.TrimSpace(stderr.String()))
+	}
+	return mtp.ParseSchema(out, mtp.ParseOptions{PreserveUnknown: true})
+}
+
+// readImageSchema reads a schema written by mtp.ToOCILabels from a local
+// image's config, without running the image.
+func readImageSchema(ref string) (*mtp.ToolSchema, error) {
+	var stderr bytes.Buffer
+	c := exec.Command("docker", "image", "inspect", "--format", "{{json .Config.Labels}}", ref)
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("inspecting image %s: %w: %s", ref, err, strings.TrimSpace(stderr.String()))
+	}
+	var labels map[string]string
+	if err := json.Unmarshal(out, &labels); err != nil {
+		return nil, fmt.Errorf("inspecting image %s: %w", ref, err)
+	}
+	schema, err := mtp.SchemaFromOCILabels(labels)
+	if err != nil {
+		return nil, fmt.Errorf("image %s: %w", ref, err)
+	}
+	return schema, nil
+}
+
+// buildPackage builds the Go main package in dir into a temporary binary.
+// The caller must call cleanup when done with it.
+func buildPackage(dir string) (bin string, cleanup func(), err error) {
+	tmp, err := os.MkdirTemp("", "mtpgen-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(tmp) }
+
+	bin = filepath.Join(tmp, "tool")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	var stderr bytes.Buffer
+	// Build from inside dir so the package's own go.mod applies.
+	c := exec.Command("go", "build", "-o", bin, ".")
+	c.Dir = dir
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("building %s: %w: %s", dir, err, strings.TrimSpace(stderr.String()))
+	}
+	return bin, cleanup, nil
+}
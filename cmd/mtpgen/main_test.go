@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+// writeSchemaFile writes a small schema to a temp file and returns its path.
+func writeSchemaFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "imgtool.json")
+	schema := &mtp.ToolSchema{
+		SpecVersion: mtp.MTPSpecVersion,
+		Name:        "imgtool",
+		Version:     "1.0.0",
+		Description: "Image tools",
+		Commands: []mtp.CommandDescriptor{{
+			Name:        "convert",
+			Description: "Convert an image",
+			Args: []mtp.ArgDescriptor{
+				{Name: "input", Type: "string", Required: true},
+				{Name: "--format", Type: "enum", Values: []string{"png", "jpeg"}, Default: "png"},
+				{Name: "--quality", Type: "integer", Default: "90"},
+			},
+		}},
+	}
+	if err := mtp.WriteManifest(path, schema); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// run executes mtpgen with args in dir and returns its stdout.
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	var out bytes.Buffer
+	root := newRootCmd()
+	root.SetOut(&out)
+	root.SetArgs(args)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("mtpgen %s: %v", strings.Join(args, " "), err)
+	}
+	return out.String()
+}
+
+// ── manifest tests ───────────────────────────────────────────────────
+
+func TestManifestRepo(t *testing.T) {
+	src := writeSchemaFile(t)
+	dir := t.TempDir()
+
+	out := run(t, dir, "manifest", src)
+	if strings.TrimSpace(out) != mtp.RepoManifestPath {
+		t.Errorf("unexpected output: %q", out)
+	}
+	schema, err := mtp.LoadManifest(filepath.Join(dir, mtp.RepoManifestPath))
+	if err != nil || schema.Name != "imgtool" {
+		t.Errorf("manifest not written: %v", err)
+	}
+}
+
+func TestManifestInstall(t *testing.T) {
+	src := writeSchemaFile(t)
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+
+	run(t, t.TempDir(), "manifest", "--install", src)
+	if _, from, err := mtp.FindManifest("imgtool"); err != nil || !strings.HasPrefix(from, data) {
+		t.Errorf("installed manifest not found: %s %v", from, err)
+	}
+}
+
+// ── action tests ─────────────────────────────────────────────────────
+
+func TestActionSingleCommand(t *testing.T) {
+	src := writeSchemaFile(t)
+	dir := t.TempDir()
+
+	run(t, dir, "action", src)
+	info, err := os.Stat(filepath.Join(dir, "entrypoint.sh"))
+	if err != nil || info.Mode()&0o100 == 0 {
+		t.Errorf("entrypoint.sh missing or not executable: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "action.yml"))
+	if err != nil || !strings.Contains(string(data), `name: "imgtool convert"`) {
+		t.Errorf("action.yml not written: %v\n%s", err, data)
+	}
+}
+
+// ── inventory tests ──────────────────────────────────────────────────
+
+// writeVersion writes a copy of the test schema with a different version.
+func writeVersion(t *testing.T, version string) string {
+	t.Helper()
+	schema, err := mtp.LoadManifest(writeSchemaFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema.Version = version
+	path := filepath.Join(t.TempDir(), "imgtool-"+version+".json")
+	if err := mtp.WriteManifest(path, schema); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestInventoryDedupAndVersions(t *testing.T) {
+	a, b := writeSchemaFile(t), writeSchemaFile(t)
+	newer := writeVersion(t, "1.10.0")
+	older := writeVersion(t, "1.0.0-rc.1")
+	list := filepath.Join(t.TempDir(), "sources.txt")
+	os.WriteFile(list, []byte("# tools\n"+newer+"\n\n"+older+"  # old build\n"), 0o644)
+
+	var cat catalog
+	if err := json.Unmarshal([]byte(run(t, t.TempDir(), "inventory", a, b, "--from", list)), &cat); err != nil {
+		t.Fatal(err)
+	}
+	if len(cat.Tools) != 1 || cat.Tools[0].Name != "imgtool" || cat.Tools[0].Description != "Image tools" {
+		t.Fatalf("unexpected catalog: %+v", cat.Tools)
+	}
+	versions := cat.Tools[0].Versions
+	var got []string
+	for _, v := range versions {
+		got = append(got, v.Version)
+	}
+	if strings.Join(got, ",") != "1.10.0,1.0.0,1.0.0-rc.1" {
+		t.Errorf("versions should be newest first, got %v", got)
+	}
+	if len(versions[1].Sources) != 2 || !strings.HasPrefix(versions[1].Digest, "sha256:") {
+		t.Errorf("identical schemas should be merged: %+v", versions[1])
+	}
+	if strings.Join(versions[0].Commands, ",") != "convert" || versions[0].Schema == nil {
+		t.Errorf("version entry should summarize commands: %+v", versions[0])
+	}
+}
+
+func TestInventoryUpdate(t *testing.T) {
+	dir := t.TempDir()
+	run(t, dir, "inventory", "-o", "catalog.json", writeSchemaFile(t))
+	run(t, dir, "inventory", "-o", "catalog.json", "--update", writeVersion(t, "2.0.0"))
+
+	var cat catalog
+	if err := readCatalog(filepath.Join(dir, "catalog.json"), &cat); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(cat.Tools[0].Versions); n != 2 {
+		t.Errorf("--update should keep earlier versions, got %d", n)
+	}
+}
+
+func TestInventoryImage(t *testing.T) {
+	labels, err := mtp.ToOCILabels(&mtp.ToolSchema{SpecVersion: mtp.MTPSpecVersion, Name: "boxed", Version: "3.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := t.TempDir()
+	data, _ := json.Marshal(labels)
+	fake := "#!/bin/sh\ncat <<'EOF'\n" + string(data) + "\nEOF\n"
+	os.WriteFile(filepath.Join(bin, "docker"), []byte(fake), 0o755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	var cat catalog
+	json.Unmarshal([]byte(run(t, t.TempDir(), "inventory", "docker://example/boxed:3")), &cat)
+	if len(cat.Tools) != 1 || cat.Tools[0].Name != "boxed" || cat.Tools[0].Versions[0].Sources[0] != "docker://example/boxed:3" {
+		t.Errorf("image schema not read from labels: %+v", cat.Tools)
+	}
+}
+
+func TestCatalogSortsVersions(t *testing.T) {
+	tool := &catalogTool{Name: "tool"}
+	for _, v := range []string{"1.0.0-rc.2", "1.9.2", "1.0.0", "v1.10.0", "1.0.0-rc.10"} {
+		tool.Versions = append(tool.Versions, &catalogVersion{Version: v, Schema: &mtp.ToolSchema{}})
+	}
+	cat := catalog{Tools: []*catalogTool{tool}}
+	cat.sort()
+
+	want := []string{"v1.10.0", "1.9.2", "1.0.0", "1.0.0-rc.10", "1.0.0-rc.2"}
+	for i, v := range tool.Versions {
+		if v.Version != want[i] {
+			t.Errorf("version %d = %s, want %s", i, v.Version, want[i])
+		}
+	}
+}
+
+// ── scaffold tests ───────────────────────────────────────────────────
+
+func TestScaffold(t *testing.T) {
+	schema, err := mtp.LoadManifest(writeSchemaFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema.Commands = append(schema.Commands, mtp.CommandDescriptor{
+		Name:   "info",
+		Stdout: &mtp.IODescriptor{ContentType: "application/json"},
+	})
+	src, err := scaffold(schema, "tools", "imgtoolOptions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package tools\n",
+		"func imgtoolOptions() *mtp.DescribeOptions {",
+		"// Convert an image\n\t\tmtp.Annotate(\"convert\").\n",
+		"Stdin(&mtp.IODescriptor{ContentType: \"TODO\", Description: \"TODO\"}).",
+		`Example("TODO: what this example shows", "imgtool convert <input>", "TODO: expected output").`,
+		"// info is already annotated.",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("scaffold missing %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(string(src), `Annotate("info")`) {
+		t.Error("already annotated commands should be skipped")
+	}
+}
+
+func TestScaffoldBuildsPackage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds mtpgen")
+	}
+	pkg, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := run(t, t.TempDir(), "scaffold", pkg)
+	for _, want := range []string{`mtp.Annotate("manifest").`, `mtp.Annotate("scaffold").`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("scaffold of mtpgen missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// ── schema tests ─────────────────────────────────────────────────────
+
+func TestSchemaWriteAndCheck(t *testing.T) {
+	src := writeSchemaFile(t)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "schema.json")
+
+	run(t, dir, "schema", src, "-o", out)
+	schema, err := mtp.LoadManifest(out)
+	if err != nil || schema.Name != "imgtool" {
+		t.Fatalf("schema not written: %v", err)
+	}
+
+	// An unchanged schema leaves the file alone.
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(out, old, old)
+	run(t, dir, "schema", src, "-o", out)
+	if info, _ := os.Stat(out); !info.ModTime().Equal(old) {
+		t.Error("unchanged schema should not be rewritten")
+	}
+	run(t, dir, "schema", src, "-o", out, "--check")
+
+	os.WriteFile(out, []byte("{}\n"), 0o644)
+	root := newRootCmd()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"schema", src, "-o", out, "--check"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "out of date") {
+		t.Errorf("--check should fail on a stale file, got %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "{}\n" {
+		t.Error("--check should not write")
+	}
+}
+
+func TestUndeclaredErrorCodes(t *testing.T) {
+	dir := t.TempDir()
+	src := `package main
+
+import (
+	sdk "github.com/modeltoolsprotocol/go-sdk"
+)
+
+func find() error {
+	if missing {
+		return sdk.NewError("NOT_FOUND", "no such bucket")
+	}
+	return sdk.NewError("GONE", "bucket deleted")
+}
+`
+	other := `package main
+
+func NewError(code, msg string) error { return nil }
+
+var _ = NewError("UNRELATED", "not the SDK's")
+`
+	test := `package main
+
+import "github.com/modeltoolsprotocol/go-sdk"
+
+var _ = mtp.NewError("IN_TEST", "tests are skipped")
+`
+	for name, content := range map[string]string{"find.go": src, "other.go": other, "find_test.go": test} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	findings, err := undeclaredErrorCodes(dir, []mtp.ErrorCode{{Code: "NOT_FOUND"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0], "find.go:11:") || !strings.Contains(findings[0], `"GONE"`) {
+		t.Errorf("expected one finding for GONE, got %v", findings)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/spf13/cobra"
+)
+
+func newManifestCmd() *cobra.Command {
+	var install bool
+	var out string
+
+	cmd := &cobra.Command{
+		Use:   "manifest <tool-or-schema.json>",
+		Short: "Write the tool's schema to its well-known manifest location",
+		Long: "Write the tool's schema to " + mtp.RepoManifestPath + " in the current directory, " +
+			"or with --install to $XDG_DATA_HOME/mtp/tools/<name>.json, " +
+			"so clients can discover the tool without running it.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := readSchema(args[0])
+			if err != nil {
+				return err
+			}
+
+			path := out
+			switch {
+			case path != "":
+			case install:
+				if path, err = mtp.InstalledManifestPath(schema.Name); err != nil {
+					return err
+				}
+			default:
+				path = mtp.RepoManifestPath
+			}
+
+			if err := mtp.WriteManifest(path, schema); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), path)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&install, "install", false, "Write to the per-user install location instead of the repository")
+	cmd.Flags().StringVarP(&out, "output", "o", "", "Write to this path instead")
+	return cmd
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"strconv"
+	"strings"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/spf13/cobra"
+)
+
+func newScaffoldCmd() *cobra.Command {
+	var pkg, fn, out string
+
+	cmd := &cobra.Command{
+		Use:   "scaffold <package-dir-or-tool>",
+		Short: "Generate starter DescribeOptions for an existing CLI",
+		Long: "Describe the tool and write Go source for a function returning *mtp.DescribeOptions, " +
+			"with an mtp.Annotate entry per command and TODO placeholders for its stdin, stdout, " +
+			"and examples. The tool must already call mtp.WithDescribe(root, nil). Commands that " +
+			"already declare stdin, stdout, or examples are left out, since a generated entry " +
+			"would take precedence over their existing annotation.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := readSchema(args[0])
+			if err != nil {
+				return err
+			}
+			src, err := scaffold(schema, pkg, fn)
+			if err != nil {
+				return err
+			}
+			if out == "" {
+				_, err = cmd.OutOrStdout().Write(src)
+				return err
+			}
+			return os.WriteFile(out, src, 0o644)
+		},
+	}
+	cmd.Flags().StringVar(&pkg, "package", "main", "Package name for the generated file")
+	cmd.Flags().StringVar(&fn, "func", "describeOptions", "Name of the generated function")
+	cmd.Flags().StringVarP(&out, "output", "o", "", "Write to this file instead of stdout")
+	return cmd
+}
+
+// scaffold returns formatted Go source declaring fn, which returns
+// DescribeOptions with a placeholder annotation for every command of
+// schema that has none yet.
+func scaffold(schema *mtp.ToolSchema, pkg, fn string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Scaffolded by mtpgen for %s %s. Edit freely; it won't be regenerated.\n\n", schema.Name, schema.Version)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString("import mtp \"github.com/modeltoolsprotocol/go-sdk\"\n\n")
+	fmt.Fprintf(&b, "// %s supplies the MTP metadata Cobra can't express. Replace each\n", fn)
+	b.WriteString("// TODO, and delete the Stdin or Stdout call for commands that don't use it.\n")
+	fmt.Fprintf(&b, "func %s() *mtp.DescribeOptions {\n", fn)
+	b.WriteString("return &mtp.DescribeOptions{Paths: []mtp.PathAnnotation{\n")
+
+	var skipped []string
+	for _, cmd := range schema.Commands {
+		if cmd.Stdin != nil || cmd.Stdout != nil || len(cmd.Examples) > 0 {
+			skipped = append(skipped, cmd.Name)
+			continue
+		}
+		if cmd.Description != "" {
+			fmt.Fprintf(&b, "// %s\n", strings.Join(strings.Fields(cmd.Description), " "))
+		}
+		key := cmd.Name
+		if key == schema.Name {
+			key = "_root" // Annotate doesn't know the tool's name
+		}
+		fmt.Fprintf(&b, "mtp.Annotate(%s).\n", strconv.Quote(key))
+		b.WriteString("Stdin(&mtp.IODescriptor{ContentType: \"TODO\", Description: \"TODO\"}).\n")
+		b.WriteString("Stdout(&mtp.IODescriptor{ContentType: \"TODO\", Description: \"TODO\"}).\n")
+		fmt.Fprintf(&b, "Example(\"TODO: what this example shows\", %s, \"TODO: expected output\").\n", strconv.Quote(exampleCommand(schema.Name, cmd)))
+		b.WriteString("Done(),\n")
+	}
+	for _, name := range skipped {
+		fmt.Fprintf(&b, "// %s is already annotated.\n", name)
+	}
+	b.WriteString("}}\n}\n")
+
+	return format.Source(b.Bytes())
+}
+
+// exampleCommand returns a starting command line for cmd: the tool and
+// command name, then its required arguments with placeholder values.
+func exampleCommand(tool string, cmd mtp.CommandDescriptor) string {
+	parts := []string{tool}
+	if cmd.Name != "_root" && cmd.Name != tool {
+		parts = append(parts, cmd.Name)
+	}
+	for _, arg := range cmd.Args {
+		if !arg.Required {
+			continue
+		}
+		if strings.HasPrefix(arg.Name, "-") {
+			parts = append(parts, arg.Name, "<"+strings.TrimLeft(arg.Name, "-")+">")
+		} else {
+			parts = append(parts, "<"+arg.Name+">")
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+func newSchemaCmd() *cobra.Command {
+	var out string
+	var check bool
+
+	cmd := &cobra.Command{
+		Use:   "schema <package-dir-or-tool>",
+		Short: "Write the tool's schema to a file, for go:generate",
+		Long: "Build and describe the tool and write its schema as indented JSON. The file is only " +
+			"rewritten when the schema changed. With --check, nothing is written; the command " +
+			"fails if the file at --output is out of date, which suits CI.\n\n" +
+			"Typical use, in the tool's main package:\n\n" +
+			"  //go:generate go run github.com/modeltoolsprotocol/go-sdk/cmd/mtpgen schema . -o schema.json",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if check && out == "" {
+				return fmt.Errorf("--check needs --output")
+			}
+			schema, err := readSchema(args[0])
+			if err != nil {
+				return err
+			}
+			data, err := json.MarshalIndent(schema, "", "  ")
+			if err != nil {
+				return err
+			}
+			data = append(data, '\n')
+
+			if out == "" {
+				_, err = cmd.OutOrStdout().Write(data)
+				return err
+			}
+			existing, err := os.ReadFile(out)
+			if err == nil && bytes.Equal(existing, data) {
+				return nil
+			}
+			if check {
+				return fmt.Errorf("%s is out of date; run go generate", out)
+			}
+			if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+				return err
+			}
+			return os.WriteFile(out, data, 0o644)
+		},
+	}
+	cmd.Flags().StringVarP(&out, "output", "o", "", "Write to this file instead of stdout")
+	cmd.Flags().BoolVar(&check, "check", false, "Fail if --output doesn't match the current schema instead of writing it")
+	return cmd
+}
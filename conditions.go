@@ -0,0 +1,155 @@
+package mtp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Conditions relate one flag to others, beyond what Cobra's flag groups
+// express. A condition is written the way a user would pass the flag:
+//
+//	--encrypt          the flag was given on the command line
+//	--format=table     the flag's value is "table", given or by default
+
+// Flag annotation keys used by RequiredIf and ConflictsWith.
+const (
+	requiredIfAnnotation    = "mtp_required_if"
+	conflictsWithAnnotation = "mtp_conflicts_with"
+)
+
+// Cobra's flag group annotations, extracted as conditions.
+const (
+	cobraRequiredTogether  = "cobra_annotation_required_if_others_set"
+	cobraMutuallyExclusive = "cobra_annotation_mutually_exclusive"
+)
+
+// RequiredIf makes a flag required whenever any of the conditions holds:
+//
+//	mtp.RequiredIf(cmd, "key", "--encrypt=true")
+func RequiredIf(cmd *cobra.Command, flagName string, conditions ...string) {
+	appendFlagAnnotation(cmd, flagName, requiredIfAnnotation, conditions)
+}
+
+// ConflictsWith forbids a flag whenever any of the conditions holds:
+//
+//	mtp.ConflictsWith(cmd, "columns", "--output=json")
+func ConflictsWith(cmd *cobra.Command, flagName string, conditions ...string) {
+	appendFlagAnnotation(cmd, flagName, conflictsWithAnnotation, conditions)
+}
+
+func appendFlagAnnotation(cmd *cobra.Command, flagName, key string, values []string) {
+	f := lookupFlag(cmd, flagName)
+	if f == nil {
+		return
+	}
+	if f.Annotations == nil {
+		f.Annotations = map[string][]string{}
+	}
+	f.Annotations[key] = append(f.Annotations[key], values...)
+}
+
+// flagConditions returns the requiredIf and conflictsWith conditions for
+// f: those set with RequiredIf and ConflictsWith, plus the equivalents of
+// Cobra's MarkFlagsRequiredTogether (each flag requires the others) and
+// MarkFlagsMutuallyExclusive (each flag conflicts with the others).
+func flagConditions(f *pflag.Flag) (requiredIf, conflictsWith []string) {
+	requiredIf = append(requiredIf, f.Annotations[requiredIfAnnotation]...)
+	conflictsWith = append(conflictsWith, f.Annotations[conflictsWithAnnotation]...)
+
+	others := func(groups []string, into []string) []string {
+		for _, group := range groups {
+			for _, name := range strings.Fields(group) {
+				if c := "--" + name; name != f.Name && !slices.Contains(into, c) {
+					into = append(into, c)
+				}
+			}
+		}
+		return into
+	}
+	requiredIf = others(f.Annotations[cobraRequiredTogether], requiredIf)
+	conflictsWith = others(f.Annotations[cobraMutuallyExclusive], conflictsWith)
+	return requiredIf, conflictsWith
+}
+
+// parseCondition splits "--name=value" into the flag name and value. hasValue
+// is false for a bare "--name".
+func parseCondition(cond string) (name, value string, hasValue bool, err error) {
+	if !strings.HasPrefix(cond, "-") {
+		return "", "", false, fmt.Errorf("condition %q must start with a flag name, like --name or --name=value", cond)
+	}
+	name, value, hasValue = strings.Cut(strings.TrimLeft(cond, "-"), "=")
+	if name == "" {
+		return "", "", false, fmt.Errorf("condition %q has no flag name", cond)
+	}
+	return name, value, hasValue, nil
+}
+
+// conditionHolds evaluates cond against cmd's parsed flags. Conditions on
+// unknown flags never hold.
+func conditionHolds(cmd *cobra.Command, cond string) bool {
+	name, value, hasValue, err := parseCondition(cond)
+	if err != nil {
+		return false
+	}
+	f := cmd.Flags().Lookup(name)
+	if f == nil {
+		return false
+	}
+	if !hasValue {
+		return f.Changed
+	}
+	return f.Value.String() == value
+}
+
+// CheckConditions reports the first violated RequiredIf or ConflictsWith
+// condition among cmd's flags, once Cobra has parsed them. Cobra checks its
+// own flag groups, so they're skipped here.
+func CheckConditions(cmd *cobra.Command) error {
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil {
+			return
+		}
+		for _, cond := range f.Annotations[requiredIfAnnotation] {
+			if !f.Changed && conditionHolds(cmd, cond) {
+				err = fmt.Errorf("--%s is required when %s", f.Name, cond)
+				return
+			}
+		}
+		for _, cond := range f.Annotations[conflictsWithAnnotation] {
+			if f.Changed && conditionHolds(cmd, cond) {
+				err = fmt.Errorf("--%s can't be used when %s", f.Name, cond)
+				return
+			}
+		}
+	})
+	return err
+}
+
+// EnforceConditions makes every command in root's tree run CheckConditions
+// before its PreRun hooks, so a violated condition fails the command with
+// a usage error. Commands added after the call aren't covered.
+func EnforceConditions(root *cobra.Command) {
+	existingE, existing := root.PreRunE, root.PreRun
+	root.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := CheckConditions(cmd); err != nil {
+			return err
+		}
+		if existingE != nil {
+			return existingE(cmd, args)
+		}
+		if existing != nil {
+			existing(cmd, args)
+		}
+		return nil
+	}
+	root.PreRun = nil
+
+	for _, sub := range root.Commands() {
+		EnforceConditions(sub)
+	}
+}
@@ -0,0 +1,116 @@
+package mtp
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newConditionsCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "upload", Short: "Upload", RunE: func(*cobra.Command, []string) error { return nil }}
+	cmd.Flags().Bool("encrypt", false, "Encrypt the upload")
+	cmd.Flags().String("key", "", "Encryption key")
+	cmd.Flags().String("output", "text", "Output format")
+	cmd.Flags().String("columns", "", "Columns to show")
+	cmd.Flags().String("user", "", "User")
+	cmd.Flags().String("password", "", "Password")
+	cmd.Flags().Bool("json", false, "JSON output")
+	cmd.Flags().Bool("yaml", false, "YAML output")
+
+	RequiredIf(cmd, "key", "--encrypt=true")
+	ConflictsWith(cmd, "columns", "--output=json")
+	cmd.MarkFlagsRequiredTogether("user", "password")
+	cmd.MarkFlagsMutuallyExclusive("json", "yaml")
+	return cmd
+}
+
+func TestConditionsExtracted(t *testing.T) {
+	upload := Describe(newConditionsCmd(), nil).Commands[0]
+
+	tests := []struct {
+		flag          string
+		requiredIf    []string
+		conflictsWith []string
+	}{
+		{"--key", []string{"--encrypt=true"}, nil},
+		{"--columns", nil, []string{"--output=json"}},
+		{"--user", []string{"--password"}, nil},
+		{"--password", []string{"--user"}, nil},
+		{"--json", nil, []string{"--yaml"}},
+		{"--encrypt", nil, nil},
+	}
+	for _, tt := range tests {
+		arg := findArg(t, upload, tt.flag)
+		if !slices.Equal(arg.RequiredIf, tt.requiredIf) || !slices.Equal(arg.ConflictsWith, tt.conflictsWith) {
+			t.Errorf("%s: got requiredIf %v, conflictsWith %v; want %v, %v",
+				tt.flag, arg.RequiredIf, arg.ConflictsWith, tt.requiredIf, tt.conflictsWith)
+		}
+	}
+
+	if diags := ValidateSchema(Describe(newConditionsCmd(), nil)); len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestEnforceConditions(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"--encrypt", "--key", "k"}, ""},
+		{[]string{"--encrypt"}, "--key is required when --encrypt=true"},
+		{[]string{"--key", "k"}, ""},
+		{[]string{"--columns", "a,b"}, ""},
+		{[]string{"--output", "json", "--columns", "a,b"}, "--columns can't be used when --output=json"},
+		{[]string{"--output", "json"}, ""},
+	}
+	for _, tt := range tests {
+		cmd := newConditionsCmd()
+		ran := false
+		cmd.PreRun = func(*cobra.Command, []string) { ran = true }
+		EnforceConditions(cmd)
+		cmd.SetArgs(tt.args)
+		cmd.SilenceUsage, cmd.SilenceErrors = true, true
+
+		err := cmd.Execute()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%v: unexpected error %v", tt.args, err)
+		case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+			t.Errorf("%v: got error %v, want %q", tt.args, err, tt.wantErr)
+		case (err == nil) != ran:
+			t.Errorf("%v: existing PreRun ran = %v, want %v", tt.args, ran, err == nil)
+		}
+	}
+}
+
+func TestEnforceConditionsSubcommands(t *testing.T) {
+	root := &cobra.Command{Use: "tool"}
+	root.AddCommand(newConditionsCmd())
+	EnforceConditions(root)
+	root.SetArgs([]string{"upload", "--encrypt"})
+	root.SilenceUsage, root.SilenceErrors = true, true
+
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "--key is required") {
+		t.Errorf("expected subcommand condition error, got %v", err)
+	}
+}
+
+func TestValidateConditions(t *testing.T) {
+	schema := &ToolSchema{Commands: []CommandDescriptor{{
+		Name: "upload",
+		Args: []ArgDescriptor{
+			{Name: "--encrypt", Type: "boolean"},
+			{Name: "--key", Type: "string", RequiredIf: []string{"--encrypt=true", "--cipher"}},
+			{Name: "--columns", Type: "string", ConflictsWith: []string{"output=json"}},
+		},
+	}}}
+	diags := ValidateSchema(schema)
+	if len(diags) != 2 ||
+		diags[0].Path != "commands[upload].args[--key].requiredIf" || !strings.Contains(diags[0].Message, "--cipher") ||
+		diags[1].Path != "commands[upload].args[--columns].conflictsWith" {
+		t.Errorf("expected unknown flag and malformed condition errors, got %v", diags)
+	}
+}
@@ -0,0 +1,61 @@
+// Package credstore saves secrets in the operating system's credential store.
+//
+// Backends:
+//
+//   - macOS: the login keychain, via the security command
+//   - Linux: the Secret Service (GNOME Keyring, KWallet), via secret-tool
+//   - Windows: a file encrypted with DPAPI for the current user
+//   - anywhere else, or when no backend is available: a plain file
+//
+// # Fallback file format
+//
+// The file store is a JSON document, readable only by its owner (mode 0600):
+//
+//	{
+//	  "version": 1,
+//	  "credentials": {
+//	    "<service>": {
+//	      "<account>": "<secret>"
+//	    }
+//	  }
+//	}
+//
+// The default location is <UserConfigDir>/mtp/credentials.json. On Windows,
+// the DPAPI store uses the same layout, with each secret encrypted by
+// CryptProtectData and base64-encoded.
+package credstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotFound is returned by Get and Delete when no secret is stored.
+var ErrNotFound = errors.New("credstore: secret not found")
+
+// Store saves secrets keyed by service and account.
+type Store interface {
+	Get(service, account string) (string, error)
+	Set(service, account, secret string) error
+	Delete(service, account string) error
+}
+
+// Default returns the best available store for this system, falling back
+// to a FileStore at DefaultFilePath.
+func Default() Store {
+	if s := platformStore(); s != nil {
+		return s
+	}
+	return NewFileStore(DefaultFilePath())
+}
+
+// DefaultFilePath returns the fallback file location,
+// <UserConfigDir>/mtp/credentials.json.
+func DefaultFilePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "mtp", "credentials.json")
+}
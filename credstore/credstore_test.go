@@ -0,0 +1,66 @@
+package credstore
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	s := NewFileStore(filepath.Join(t.TempDir(), "nested", "credentials.json"))
+
+	if _, err := s.Get("tool", "default"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound on empty store, got %v", err)
+	}
+	if err := s.Set("tool", "default", "secret-1"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	got, err := s.Get("tool", "default")
+	if err != nil || got != "secret-1" {
+		t.Fatalf("expected secret-1, got %q (%v)", got, err)
+	}
+	if err := s.Delete("tool", "default"); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if err := s.Delete("tool", "default"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound on second delete, got %v", err)
+	}
+}
+
+func TestFileStoreFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	s := NewFileStore(path)
+	s.Set("tool", "alice", "a")
+	s.Set("tool", "bob", "b")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat failed: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected mode 0600, got %o", perm)
+	}
+
+	raw, _ := os.ReadFile(path)
+	var data struct {
+		Version     int                          `json:"version"`
+		Credentials map[string]map[string]string `json:"credentials"`
+	}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatalf("file is not valid JSON: %v", err)
+	}
+	if data.Version != 1 || data.Credentials["tool"]["bob"] != "b" {
+		t.Errorf("unexpected file contents: %s", raw)
+	}
+}
+
+func TestFileStoreRejectsFutureVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	os.WriteFile(path, []byte(`{"version":99,"credentials":{}}`), 0o600)
+
+	if _, err := NewFileStore(path).Get("tool", "default"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected version error, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package credstore
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	crypt32                = syscall.NewLazyDLL("crypt32.dll")
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procCryptProtectData   = crypt32.NewProc("CryptProtectData")
+	procCryptUnprotectData = crypt32.NewProc("CryptUnprotectData")
+	procLocalFree          = kernel32.NewProc("LocalFree")
+)
+
+// cryptprotectUIForbidden fails instead of prompting the user.
+const cryptprotectUIForbidden = 0x1
+
+// dataBlob is the Win32 DATA_BLOB structure.
+type dataBlob struct {
+	cbData uint32
+	pbData *byte
+}
+
+func newBlob(b []byte) *dataBlob {
+	if len(b) == 0 {
+		return &dataBlob{}
+	}
+	return &dataBlob{cbData: uint32(len(b)), pbData: &b[0]}
+}
+
+func (b *dataBlob) bytes() []byte {
+	out := make([]byte, b.cbData)
+	copy(out, unsafe.Slice(b.pbData, b.cbData))
+	return out
+}
+
+// platformStore returns a file store whose secrets are encrypted with DPAPI
+// for the current user. It lives next to the plain fallback file.
+func platformStore() Store {
+	path := filepath.Join(filepath.Dir(DefaultFilePath()), "credentials.dpapi.json")
+	s := NewFileStore(path)
+	s.encode = dpapiEncrypt
+	s.decode = dpapiDecrypt
+	return s
+}
+
+func dpapiEncrypt(secret string) (string, error) {
+	var out dataBlob
+	r, _, err := procCryptProtectData.Call(
+		uintptr(unsafe.Pointer(newBlob([]byte(secret)))),
+		0, 0, 0, 0,
+		cryptprotectUIForbidden,
+		uintptr(unsafe.Pointer(&out)),
+	)
+	if r == 0 {
+		return "", fmt.Errorf("credstore: CryptProtectData: %w", err)
+	}
+	defer procLocalFree.Call(uintptr(unsafe.Pointer(out.pbData)))
+	return base64.StdEncoding.EncodeToString(out.bytes()), nil
+}
+
+func dpapiDecrypt(encoded string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("credstore: decoding secret: %w", err)
+	}
+	var out dataBlob
+	r, _, err := procCryptUnprotectData.Call(
+		uintptr(unsafe.Pointer(newBlob(raw))),
+		0, 0, 0, 0,
+		cryptprotectUIForbidden,
+		uintptr(unsafe.Pointer(&out)),
+	)
+	if r == 0 {
+		return "", fmt.Errorf("credstore: CryptUnprotectData: %w", err)
+	}
+	defer procLocalFree.Call(uintptr(unsafe.Pointer(out.pbData)))
+	return string(out.bytes()), nil
+}
@@ -0,0 +1,30 @@
+//go:build darwin || linux
+
+package credstore
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// run executes a credential helper command with optional stdin and returns
+// its stdout. The exit code is returned alongside so callers can map
+// "not found" codes to ErrNotFound.
+func run(stdin string, name string, args ...string) (string, int, error) {
+	var stdout, stderr bytes.Buffer
+	c := exec.Command(name, args...)
+	c.Stdin = strings.NewReader(stdin)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+
+	err := c.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return "", exitErr.ExitCode(), fmt.Errorf("credstore: %s: %s", name, strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		return "", -1, fmt.Errorf("credstore: %s: %w", name, err)
+	}
+	return stdout.String(), 0, nil
+}
@@ -0,0 +1,148 @@
+package credstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileVersion is the current file format version.
+const fileVersion = 1
+
+// fileData is the on-disk layout described in the package documentation.
+type fileData struct {
+	Version     int                          `json:"version"`
+	Credentials map[string]map[string]string `json:"credentials"`
+}
+
+// FileStore keeps secrets in a JSON file readable only by its owner.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+
+	// encode and decode transform secrets on their way to and from disk.
+	// They are identity functions for the plain file store.
+	encode func(string) (string, error)
+	decode func(string) (string, error)
+}
+
+// NewFileStore returns a store backed by the file at path. The file and its
+// directory are created on first write.
+func NewFileStore(path string) *FileStore {
+	identity := func(s string) (string, error) { return s, nil }
+	return &FileStore{path: path, encode: identity, decode: identity}
+}
+
+// Path returns the file the store reads and writes.
+func (s *FileStore) Path() string {
+	return s.path
+}
+
+// Get returns the secret for service and account, or ErrNotFound.
+func (s *FileStore) Get(service, account string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := data.Credentials[service][account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return s.decode(secret)
+}
+
+// Set stores a secret, replacing any existing one.
+func (s *FileStore) Set(service, account, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	encoded, err := s.encode(secret)
+	if err != nil {
+		return err
+	}
+	if data.Credentials[service] == nil {
+		data.Credentials[service] = map[string]string{}
+	}
+	data.Credentials[service][account] = encoded
+	return s.save(data)
+}
+
+// Delete removes a secret, returning ErrNotFound if there was none.
+func (s *FileStore) Delete(service, account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := data.Credentials[service][account]; !ok {
+		return ErrNotFound
+	}
+	delete(data.Credentials[service], account)
+	if len(data.Credentials[service]) == 0 {
+		delete(data.Credentials, service)
+	}
+	return s.save(data)
+}
+
+func (s *FileStore) load() (*fileData, error) {
+	data := &fileData{Version: fileVersion, Credentials: map[string]map[string]string{}}
+
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, fmt.Errorf("credstore: reading %s: %w", s.path, err)
+	}
+	if data.Version > fileVersion {
+		return nil, fmt.Errorf("credstore: %s has unsupported version %d", s.path, data.Version)
+	}
+	if data.Credentials == nil {
+		data.Credentials = map[string]map[string]string{}
+	}
+	return data, nil
+}
+
+// save writes the file atomically so a crash never leaves it truncated.
+func (s *FileStore) save(data *fileData) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".credentials-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,49 @@
+package credstore
+
+import (
+	"strings"
+)
+
+// keychainNotFound is the exit code security uses when no item matches.
+const keychainNotFound = 44
+
+// keychainStore keeps secrets as generic passwords in the login keychain.
+type keychainStore struct{}
+
+func platformStore() Store {
+	return keychainStore{}
+}
+
+func (keychainStore) Get(service, account string) (string, error) {
+	out, code, err := run("", "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	if code == keychainNotFound {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// Set passes the secret through "security -i" on stdin so that it never
+// appears in the process list.
+func (keychainStore) Set(service, account, secret string) error {
+	script := "add-generic-password -U -s " + quote(service) + " -a " + quote(account) + " -w " + quote(secret) + "\n"
+	_, _, err := run(script, "security", "-i")
+	return err
+}
+
+func (keychainStore) Delete(service, account string) error {
+	_, code, err := run("", "security", "delete-generic-password", "-s", service, "-a", account)
+	if code == keychainNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// quote wraps s in double quotes for security's interactive parser.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && !linux && !windows
+
+package credstore
+
+// platformStore has no native backend on this platform.
+func platformStore() Store {
+	return nil
+}
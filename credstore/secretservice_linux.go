@@ -0,0 +1,48 @@
+package credstore
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// secretServiceStore keeps secrets in the freedesktop Secret Service
+// (GNOME Keyring, KWallet) via the secret-tool command.
+type secretServiceStore struct{}
+
+// platformStore uses the Secret Service only when secret-tool is installed
+// and a session bus is available; headless machines fall back to the file.
+func platformStore() Store {
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return nil
+	}
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return nil
+	}
+	return secretServiceStore{}
+}
+
+func (secretServiceStore) Get(service, account string) (string, error) {
+	out, code, err := run("", "secret-tool", "lookup", "service", service, "account", account)
+	if code == 1 {
+		// secret-tool exits 1 with no output when nothing matches.
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func (secretServiceStore) Set(service, account, secret string) error {
+	_, _, err := run(secret, "secret-tool", "store", "--label="+service+" ("+account+")", "service", service, "account", account)
+	return err
+}
+
+func (s secretServiceStore) Delete(service, account string) error {
+	if _, err := s.Get(service, account); err != nil {
+		return err
+	}
+	_, _, err := run("", "secret-tool", "clear", "service", service, "account", account)
+	return err
+}
@@ -0,0 +1,162 @@
+package mtp
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefinitionRefPrefix begins a $ref to a tool-level definition.
+const DefinitionRefPrefix = "#/definitions/"
+
+// Ref returns a JSON Schema referencing the tool-level definition name, for
+// use anywhere in an IODescriptor's Schema.
+func Ref(name string) map[string]any {
+	return map[string]any{"$ref": DefinitionRefPrefix + name}
+}
+
+// Define registers schema as the tool-level definition name and returns a
+// Ref to it, so a record shape shared by several commands is declared once:
+//
+//	job := opts.Define("Job", jobSchema)
+//	opts.Commands["job get"] = &mtp.CommandAnnotation{Stdout: &mtp.IODescriptor{
+//		ContentType: "application/json", Schema: job,
+//	}}
+func (o *DescribeOptions) Define(name string, schema map[string]any) map[string]any {
+	if o.Definitions == nil {
+		o.Definitions = map[string]map[string]any{}
+	}
+	o.Definitions[name] = schema
+	return Ref(name)
+}
+
+// ResolveRefs returns a copy of schema, an IODescriptor's Schema, that
+// doesn't depend on the tool's Definitions, for consumers that only see one
+// command's schema. Each $ref to a definition is replaced by the definition
+// itself. A definition that refers to itself, directly or not, can't be
+// inlined; it is copied into the result's "$defs", and referenced there.
+// A $ref to a definition the tool doesn't have is an error.
+func (s *ToolSchema) ResolveRefs(schema map[string]any) (map[string]any, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	r := &refResolver{defs: s.Definitions, inlining: map[string]bool{}, recursive: map[string]bool{}}
+	resolved, _ := r.resolve(schema).(map[string]any)
+	if len(r.recursive) > 0 {
+		defs := map[string]any{}
+		// Resolving a recursive definition may find others; repeat until
+		// every one is in defs.
+		for len(defs) < len(r.recursive) {
+			for _, name := range sortedKeys(r.recursive) {
+				if _, ok := defs[name]; !ok {
+					r.inlining[name] = true
+					defs[name] = r.resolve(s.Definitions[name])
+					r.inlining[name] = false
+				}
+			}
+		}
+		if existing, ok := resolved["$defs"].(map[string]any); ok {
+			maps.Copy(defs, existing)
+		}
+		resolved["$defs"] = defs
+	}
+	if len(r.missing) > 0 {
+		return nil, fmt.Errorf("mtp: no definition for %s", strings.Join(r.missing, ", "))
+	}
+	return resolved, nil
+}
+
+// refResolver inlines definitions, tracking those being inlined to catch
+// recursion.
+type refResolver struct {
+	defs      map[string]map[string]any
+	inlining  map[string]bool
+	recursive map[string]bool
+	missing   []string
+}
+
+func (r *refResolver) resolve(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		name, isRef := definitionRef(v)
+		if !isRef {
+			out := make(map[string]any, len(v))
+			for k, sub := range v {
+				out[k] = r.resolve(sub)
+			}
+			return out
+		}
+		def, ok := r.defs[name]
+		if !ok {
+			if ref := fmt.Sprintf("%q", v["$ref"]); !slices.Contains(r.missing, ref) {
+				r.missing = append(r.missing, ref)
+			}
+			return v
+		}
+		var target any
+		if r.inlining[name] {
+			r.recursive[name] = true
+			target = map[string]any{"$ref": "#/$defs/" + name}
+		} else {
+			r.inlining[name] = true
+			target = r.resolve(def)
+			r.inlining[name] = false
+		}
+		if len(v) == 1 {
+			return target
+		}
+		// Keywords beside the $ref still apply alongside the definition.
+		out := map[string]any{"allOf": []any{target}}
+		for k, sub := range v {
+			if k != "$ref" {
+				out[k] = r.resolve(sub)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, sub := range v {
+			out[i] = r.resolve(sub)
+		}
+		return out
+	}
+	return v
+}
+
+// definitionRef returns the definition a schema's $ref names, if it names one.
+func definitionRef(schema map[string]any) (string, bool) {
+	ref, ok := schema["$ref"].(string)
+	if !ok || !strings.HasPrefix(ref, DefinitionRefPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(ref, DefinitionRefPrefix), true
+}
+
+// definitionRefs calls fn with the name of every definition v refers to.
+func definitionRefs(v any, fn func(name string)) {
+	switch v := v.(type) {
+	case map[string]any:
+		if name, ok := definitionRef(v); ok {
+			fn(name)
+		}
+		for _, sub := range v {
+			definitionRefs(sub, fn)
+		}
+	case []any:
+		for _, sub := range v {
+			definitionRefs(sub, fn)
+		}
+	}
+}
+
+// sortedKeys returns m's keys in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
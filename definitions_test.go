@@ -0,0 +1,96 @@
+package mtp
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestDefine(t *testing.T) {
+	root := &cobra.Command{Use: "jobs"}
+	root.AddCommand(
+		&cobra.Command{Use: "get", Run: func(*cobra.Command, []string) {}},
+		&cobra.Command{Use: "list", Run: func(*cobra.Command, []string) {}},
+	)
+	opts := &DescribeOptions{}
+	job := opts.Define("Job", map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"id": map[string]any{"type": "string"}},
+	})
+	opts.Commands = map[string]*CommandAnnotation{
+		"get":  {Stdout: &IODescriptor{ContentType: "application/json", Schema: job}},
+		"list": {Stdout: &IODescriptor{ContentType: "application/json", Schema: map[string]any{"type": "array", "items": job}}},
+	}
+	schema := Describe(root, opts)
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"definitions":{"Job":{`, `"$ref":"#/definitions/Job"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("missing %s in %s", want, data)
+		}
+	}
+	if diags := ValidateSchema(schema); len(diags) > 0 {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+	if errs := ValidateAgainstSpec(data); len(errs) > 0 {
+		t.Errorf("spec errors: %v", errs)
+	}
+	for _, cmd := range schema.Commands {
+		if id, ok := cmd.Stdout.Schema["$id"]; ok {
+			t.Errorf("%s: $id %v set on a schema with a definitions reference", cmd.Name, id)
+		}
+	}
+
+	schema.Commands[0].Stdout.Schema["$id"] = "urn:mtp:jobs:get:stdout"
+	diags := ValidateSchema(schema)
+	if len(diags) != 1 || diags[0].Severity != SeverityWarning || diags[0].Path != "commands[get].stdout.schema" {
+		t.Errorf("expected a warning for $id beside a definitions reference, got %v", diags)
+	}
+}
+
+func TestResolveRefs(t *testing.T) {
+	tool := &ToolSchema{Definitions: map[string]map[string]any{
+		"Job":  {"type": "object", "properties": map[string]any{"owner": Ref("User")}},
+		"User": {"type": "string"},
+		"Node": {"type": "object", "properties": map[string]any{
+			"children": map[string]any{"type": "array", "items": Ref("Node")},
+		}},
+	}}
+
+	got, err := tool.ResolveRefs(map[string]any{"type": "array", "items": Ref("Job")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"type": "array", "items": map[string]any{
+		"type": "object", "properties": map[string]any{"owner": map[string]any{"type": "string"}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got, err = tool.ResolveRefs(map[string]any{"$ref": "#/definitions/Node", "description": "A tree"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := map[string]any{"type": "object", "properties": map[string]any{
+		"children": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/Node"}},
+	}}
+	want = map[string]any{
+		"allOf":       []any{node},
+		"description": "A tree",
+		"$defs":       map[string]any{"Node": node},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recursive: got %v, want %v", got, want)
+	}
+
+	if _, err := tool.ResolveRefs(Ref("Missing")); err == nil || !strings.Contains(err.Error(), `"#/definitions/Missing"`) {
+		t.Errorf("missing definition: %v", err)
+	}
+}
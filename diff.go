@@ -0,0 +1,110 @@
+package mtp
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Change kinds reported by Diff.
+const (
+	ChangeCommandAdded     = "command-added"
+	ChangeCommandRemoved   = "command-removed"
+	ChangeArgAdded         = "arg-added"
+	ChangeArgRemoved       = "arg-removed"
+	ChangeArgTypeChanged   = "arg-type-changed"
+	ChangeArgRequired      = "arg-required" // An optional arg became required
+	ChangeArgOptional      = "arg-optional" // A required arg became optional
+	ChangeEnumValueAdded   = "enum-value-added"
+	ChangeEnumValueRemoved = "enum-value-removed"
+)
+
+// Change is one difference between two versions of a tool's schema.
+type Change struct {
+	Kind     string `json:"kind"` // One of the Change* constants
+	Command  string `json:"command"`
+	Arg      string `json:"arg,omitempty"`
+	Breaking bool   `json:"breaking"` // A call valid against the old schema may fail against the new one
+	Message  string `json:"message"`
+}
+
+// Diff compares two versions of a tool's schema and reports what changed,
+// command by command in the order of old and then of the commands new
+// adds. A change is breaking when a call that was valid against old may be
+// rejected by new: a command or arg removed, an arg's type changed, a new
+// or existing arg made required, or an enum value dropped.
+func Diff(old, new *ToolSchema) []Change {
+	var changes []Change
+	for _, oc := range old.Commands {
+		i := slices.IndexFunc(new.Commands, func(c CommandDescriptor) bool { return c.Name == oc.Name })
+		if i < 0 {
+			changes = append(changes, Change{
+				Kind: ChangeCommandRemoved, Command: oc.Name, Breaking: true,
+				Message: fmt.Sprintf("command %q was removed", oc.Name),
+			})
+			continue
+		}
+		changes = append(changes, diffArgs(oc, new.Commands[i])...)
+	}
+	for _, nc := range new.Commands {
+		if !slices.ContainsFunc(old.Commands, func(c CommandDescriptor) bool { return c.Name == nc.Name }) {
+			changes = append(changes, Change{
+				Kind: ChangeCommandAdded, Command: nc.Name,
+				Message: fmt.Sprintf("command %q was added", nc.Name),
+			})
+		}
+	}
+	return changes
+}
+
+// diffArgs compares the args of two versions of one command.
+func diffArgs(oc, nc CommandDescriptor) []Change {
+	var changes []Change
+	add := func(kind, arg string, breaking bool, format string, args ...any) {
+		changes = append(changes, Change{Kind: kind, Command: oc.Name, Arg: arg, Breaking: breaking, Message: fmt.Sprintf(format, args...)})
+	}
+	for _, oa := range oc.Args {
+		i := slices.IndexFunc(nc.Args, func(a ArgDescriptor) bool { return a.Name == oa.Name })
+		if i < 0 {
+			add(ChangeArgRemoved, oa.Name, true, "%s: %s was removed", oc.Name, oa.Name)
+			continue
+		}
+		na := nc.Args[i]
+		if oa.Type != na.Type {
+			add(ChangeArgTypeChanged, oa.Name, true, "%s: %s changed type from %s to %s", oc.Name, oa.Name, oa.Type, na.Type)
+		}
+		switch {
+		case !oa.Required && na.Required:
+			add(ChangeArgRequired, oa.Name, true, "%s: %s is now required", oc.Name, oa.Name)
+		case oa.Required && !na.Required:
+			add(ChangeArgOptional, oa.Name, false, "%s: %s is no longer required", oc.Name, oa.Name)
+		}
+		if oa.Type == "enum" && na.Type == "enum" {
+			for _, v := range oa.Values {
+				if !slices.Contains(na.Values, v) {
+					add(ChangeEnumValueRemoved, oa.Name, true, "%s: %s no longer accepts %q", oc.Name, oa.Name, v)
+				}
+			}
+			for _, v := range na.Values {
+				if !slices.Contains(oa.Values, v) {
+					add(ChangeEnumValueAdded, oa.Name, false, "%s: %s now accepts %q", oc.Name, oa.Name, v)
+				}
+			}
+		}
+	}
+	for _, na := range nc.Args {
+		if slices.ContainsFunc(oc.Args, func(a ArgDescriptor) bool { return a.Name == na.Name }) {
+			continue
+		}
+		if na.Required {
+			add(ChangeArgAdded, na.Name, true, "%s: required %s was added", oc.Name, na.Name)
+		} else {
+			add(ChangeArgAdded, na.Name, false, "%s: %s was added", oc.Name, na.Name)
+		}
+	}
+	return changes
+}
+
+// HasBreaking reports whether any change is breaking.
+func HasBreaking(changes []Change) bool {
+	return slices.ContainsFunc(changes, func(c Change) bool { return c.Breaking })
+}
@@ -0,0 +1,63 @@
+package mtp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := &ToolSchema{Commands: []CommandDescriptor{
+		{Name: "convert", Args: []ArgDescriptor{
+			{Name: "input", Type: "path", Required: true},
+			{Name: "--format", Type: "enum", Values: []string{"png", "gif"}},
+			{Name: "--quality", Type: "integer"},
+			{Name: "--legacy", Type: "boolean"},
+			{Name: "--output", Type: "path", Required: true},
+		}},
+		{Name: "inspect"},
+	}}
+	new := &ToolSchema{Commands: []CommandDescriptor{
+		{Name: "convert", Args: []ArgDescriptor{
+			{Name: "input", Type: "path", Required: true},
+			{Name: "--format", Type: "enum", Values: []string{"png", "webp"}},
+			{Name: "--quality", Type: "number"},
+			{Name: "--output", Type: "path"},
+			{Name: "--strip", Type: "boolean"},
+		}},
+		{Name: "resize"},
+	}}
+
+	var got []string
+	for _, c := range Diff(old, new) {
+		mark := ""
+		if c.Breaking {
+			mark = "!"
+		}
+		got = append(got, mark+c.Kind+" "+c.Command+" "+c.Arg)
+	}
+	want := []string{
+		"!enum-value-removed convert --format",
+		"enum-value-added convert --format",
+		"!arg-type-changed convert --quality",
+		"!arg-removed convert --legacy",
+		"arg-optional convert --output",
+		"arg-added convert --strip",
+		"!command-removed inspect ",
+		"command-added resize ",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got\n%v\nwant\n%v", got, want)
+	}
+
+	if changes := Diff(old, old); len(changes) != 0 || HasBreaking(changes) {
+		t.Errorf("a schema should not differ from itself: %v", changes)
+	}
+	additive := &ToolSchema{Commands: append(slices.Clone(old.Commands), CommandDescriptor{Name: "resize"})}
+	if HasBreaking(Diff(old, additive)) {
+		t.Error("adding a command should not be breaking")
+	}
+	required := &ToolSchema{Commands: []CommandDescriptor{{Name: "inspect", Args: []ArgDescriptor{{Name: "--id", Type: "string", Required: true}}}}}
+	if changes := Diff(&ToolSchema{Commands: []CommandDescriptor{{Name: "inspect"}}}, required); !HasBreaking(changes) {
+		t.Errorf("a new required arg should be breaking: %v", changes)
+	}
+}
@@ -0,0 +1,101 @@
+package mtp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/spf13/cobra"
+)
+
+// Elicitation lets a running command ask its caller for input mid-execution.
+//
+// The command writes an ElicitRequest to stderr as a single JSON line and
+// reads a single-line ElicitResponse from stdin. Commands that do this must
+// declare MayElicit in their annotation so hosts know to keep stdin open and
+// watch stderr.
+
+// ElicitRequest is the message a command emits when it needs input.
+type ElicitRequest struct {
+	Type   string         `json:"type"` // Always "elicit"
+	ID     string         `json:"id"`
+	Prompt string         `json:"prompt"`
+	Schema map[string]any `json:"schema,omitempty"` // JSON Schema for the expected value
+}
+
+// ElicitResponse is the host's answer to an ElicitRequest.
+type ElicitResponse struct {
+	Type      string          `json:"type"` // Always "elicit_response"
+	ID        string          `json:"id"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	Cancelled bool            `json:"cancelled,omitempty"`
+}
+
+// ErrElicitCancelled is returned by Ask when the host declines to answer.
+var ErrElicitCancelled = errors.New("mtp: elicitation cancelled")
+
+var elicitSeq atomic.Uint64
+
+// Ask sends an elicitation request on cmd's stderr and waits for the answer
+// on cmd's stdin. The returned value is the raw JSON the host supplied; it
+// should conform to schema.
+//
+//	raw, err := mtp.Ask(cmd, "Which match did you mean?", map[string]any{
+//	    "type": "string", "enum": []string{"a", "b", "c"},
+//	})
+func Ask(cmd *cobra.Command, prompt string, schema map[string]any) (json.RawMessage, error) {
+	req := ElicitRequest{
+		Type:   "elicit",
+		ID:     strconv.FormatUint(elicitSeq.Add(1), 10),
+		Prompt: prompt,
+		Schema: schema,
+	}
+	if err := json.NewEncoder(cmd.ErrOrStderr()).Encode(req); err != nil {
+		return nil, fmt.Errorf("writing elicit request: %w", err)
+	}
+
+	line, err := readLine(cmd.InOrStdin())
+	if err != nil {
+		return nil, fmt.Errorf("reading elicit response: %w", err)
+	}
+
+	var resp ElicitResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("decoding elicit response: %w", err)
+	}
+	if resp.Type != "elicit_response" {
+		return nil, fmt.Errorf("unexpected message type %q", resp.Type)
+	}
+	if resp.ID != req.ID {
+		return nil, fmt.Errorf("elicit response id %q does not match request %q", resp.ID, req.ID)
+	}
+	if resp.Cancelled {
+		return nil, ErrElicitCancelled
+	}
+	return resp.Value, nil
+}
+
+// readLine reads up to and including the next newline, one byte at a time,
+// so that no input past the response is consumed from a shared stdin.
+func readLine(r io.Reader) ([]byte, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return line, nil
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				return line, nil
+			}
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package mtp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// EncodeSchema writes the schema for root to w as JSON, streaming each
+// command descriptor as soon as it is extracted instead of building the
+// whole ToolSchema first. Peak memory stays flat however many commands the
+// tree has. The output is identical to encoding Describe(root, opts) with
+// a json.Encoder.
+//
+// MaxBytes trimming needs the whole schema to choose what to drop, so when
+// opts.MaxBytes is set EncodeSchema falls back to encoding Describe's result.
+func EncodeSchema(w io.Writer, root *cobra.Command, opts *DescribeOptions) error {
+	if opts != nil && opts.MaxBytes > 0 {
+		return json.NewEncoder(w).Encode(Describe(root, opts))
+	}
+
+	// Encode the tool with an empty command list and stream the commands
+	// into the gap. Every field before "commands" is a string, in which any
+	// quote is escaped, so the first unescaped match is the key itself.
+	tool := describeTool(root, opts)
+	tool.Commands = []CommandDescriptor{}
+	frame, err := json.Marshal(tool)
+	if err != nil {
+		return err
+	}
+	const marker = `"commands":[`
+	i := bytes.Index(frame, []byte(marker+"]"))
+	if i < 0 {
+		return fmt.Errorf("encoding schema: commands field not found")
+	}
+	head, tail := frame[:i+len(marker)], frame[i+len(marker):]
+
+	bw := bufio.NewWriter(w)
+	bw.Write(head)
+	first := true
+	maxLen := maxDescriptionLen(opts)
+	err = visitCommands(root, "", opts, func(cd CommandDescriptor) error {
+		sanitizeCommand(&cd, maxLen)
+		data, err := json.Marshal(cd)
+		if err != nil {
+			return err
+		}
+		if !first {
+			bw.WriteByte(',')
+		}
+		first = false
+		_, err = bw.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	bw.Write(tail)
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
@@ -0,0 +1,75 @@
+package mtp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrorCode is a machine-readable error a tool may report. Codes listed in
+// DescribeOptions.ErrorCodes appear in the schema's "errorCodes", so agents
+// can branch on them instead of parsing messages.
+type ErrorCode struct {
+	Code        string `json:"code"`                  // e.g. "NOT_FOUND"
+	Description string `json:"description,omitempty"` // When it happens and what to do about it
+	ExitCode    int    `json:"exitCode,omitempty"`    // Process exit status; 0 means the default, 1
+	Retryable   bool   `json:"retryable,omitempty"`   // Running the same command again may succeed
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
+}
+
+// lookupErrorCode returns the declaration of code in opts.ErrorCodes.
+func lookupErrorCode(opts *DescribeOptions, code string) (ErrorCode, bool) {
+	if opts == nil {
+		return ErrorCode{}, false
+	}
+	for _, c := range opts.ErrorCodes {
+		if c.Code == code {
+			return c, true
+		}
+	}
+	return ErrorCode{}, false
+}
+
+// Error is an error carrying an error code. Its message starts
+// with the code ("NOT_FOUND: no such bucket"), so a client reading stderr
+// can extract it.
+type Error struct {
+	Code    string
+	Message string
+	Err     error // Underlying cause, if any
+}
+
+func (e *Error) Error() string { return e.Code + ": " + e.Message }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// NewError returns an error with code and a formatted message. A %w verb
+// wraps its argument, as with fmt.Errorf.
+//
+// code should be listed in DescribeOptions.ErrorCodes, so the schema names
+// every code the tool can report. Run mtpgen errcodes from go generate to
+// catch codes that aren't, before they ship.
+func NewError(code, format string, args ...any) *Error {
+	err := fmt.Errorf(format, args...)
+	return &Error{Code: code, Message: err.Error(), Err: errors.Unwrap(err)}
+}
+
+// ExitCode returns the process exit status for err: 0 for nil, the
+// ExitCode opts.ErrorCodes declares for the first *Error in its chain, or 1.
+//
+//	if err := root.Execute(); err != nil {
+//		os.Exit(mtp.ExitCode(err, opts))
+//	}
+func ExitCode(err error, opts *DescribeOptions) int {
+	if err == nil {
+		return 0
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		if c, ok := lookupErrorCode(opts, e.Code); ok && c.ExitCode != 0 {
+			return c.ExitCode
+		}
+	}
+	return 1
+}
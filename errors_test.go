@@ -0,0 +1,79 @@
+package mtp
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestErrorCodesInSchema(t *testing.T) {
+	opts := &DescribeOptions{ErrorCodes: []ErrorCode{
+		{Code: "NOT_FOUND", Description: "No such bucket", ExitCode: 4},
+		{Code: "RATE_LIMITED", Retryable: true},
+	}}
+
+	schema := Describe(&cobra.Command{Use: "tool"}, opts)
+	if len(schema.ErrorCodes) != 2 || schema.ErrorCodes[0].ExitCode != 4 || !schema.ErrorCodes[1].Retryable {
+		t.Errorf("unexpected error codes %+v", schema.ErrorCodes)
+	}
+	if diags := ValidateAgainstSpec([]byte(mustJSON(schema))); len(diags) != 0 {
+		t.Errorf("schema with error codes should satisfy the spec: %v", diags)
+	}
+	if other := Describe(&cobra.Command{Use: "other"}, nil); other.ErrorCodes != nil {
+		t.Errorf("codes leaked into another tool's schema: %+v", other.ErrorCodes)
+	}
+}
+
+func TestNewError(t *testing.T) {
+	opts := &DescribeOptions{ErrorCodes: []ErrorCode{{Code: "NOT_FOUND", ExitCode: 4}, {Code: "CONFLICT"}}}
+
+	err := NewError("NOT_FOUND", "no bucket %q: %w", "logs", fs.ErrNotExist)
+	if err.Error() != `NOT_FOUND: no bucket "logs": file does not exist` {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("NewError should wrap %w arguments")
+	}
+
+	tests := []struct {
+		err  error
+		opts *DescribeOptions
+		want int
+	}{
+		{nil, opts, 0},
+		{err, opts, 4},
+		{err, nil, 1},
+		{NewError("CONFLICT", "busy"), opts, 1},
+		{NewError("TEAPOT", "short and stout"), opts, 1},
+		{errors.New("plain"), opts, 1},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err, tt.opts); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestValidateErrorCodes(t *testing.T) {
+	schema := &ToolSchema{ErrorCodes: []ErrorCode{
+		{Code: "NOT_FOUND"},
+		{Code: "NOT_FOUND"},
+		{Code: "notFound"},
+		{Code: "BAD_EXIT", ExitCode: 300},
+	}}
+	diags := ValidateSchema(schema)
+	want := []string{"errorCodes[1].code", "errorCodes[2].code", "errorCodes[3].exitCode"}
+	if len(diags) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %v", len(want), diags)
+	}
+	for i, d := range diags {
+		if d.Path != want[i] {
+			t.Errorf("diagnostic %d: got path %s, want %s", i, d.Path, want[i])
+		}
+	}
+	if diags[1].Severity != SeverityWarning {
+		t.Errorf("naming should be a warning, got %v", diags[1])
+	}
+}
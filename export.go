@@ -0,0 +1,217 @@
+package mtp
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Helpers shared by the agent-framework exporters.
+
+// maxToolNameLength is the longest function name the major model APIs
+// accept for a tool.
+const maxToolNameLength = 64
+
+var nonIdentChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// exportName returns an identifier for cmd that is safe as a function or
+// tool name in every framework: "tool_db_migrate" for "db migrate", or the
+// tool name alone for the root. Different commands can map to the same
+// name; use exportNames for a whole schema.
+func exportName(tool string, cmd CommandDescriptor) string {
+	name := tool
+	if !isRootCommand(tool, cmd) {
+		name += " " + cmd.Name
+	}
+	return identifier(name)
+}
+
+// exportNames returns exportName for each of schema's commands, with "_2",
+// "_3", ... appended where two would otherwise collide (e.g. "db-migrate"
+// and "db migrate").
+func exportNames(schema *ToolSchema) []string {
+	names := make([]string, len(schema.Commands))
+	for i, cmd := range schema.Commands {
+		names[i] = exportName(schema.Name, cmd)
+	}
+	return uniqueNames(names)
+}
+
+// identifier turns s into a name of at most maxToolNameLength letters,
+// digits, and underscores that starts with a letter, as Python and the
+// model APIs require: "tool_7zip" for "7zip".
+func identifier(s string) string {
+	name := strings.Trim(nonIdentChars.ReplaceAllString(s, "_"), "_")
+	if name == "" || !isLetter(name[0]) {
+		name = "tool_" + name
+	}
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	return strings.TrimRight(name, "_")
+}
+
+// isLetter reports whether c is an ASCII letter.
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// uniqueNames returns names with a numeric suffix added to each repeat of
+// an earlier name, keeping every name within maxToolNameLength.
+func uniqueNames(names []string) []string {
+	used := make(map[string]bool, len(names))
+	unique := make([]string, len(names))
+	for i, name := range names {
+		candidate := name
+		for n := 2; used[candidate]; n++ {
+			suffix := "_" + strconv.Itoa(n)
+			candidate = name[:min(len(name), maxToolNameLength-len(suffix))] + suffix
+		}
+		used[candidate] = true
+		unique[i] = candidate
+	}
+	return unique
+}
+
+// commandArgv returns the fixed argv prefix that invokes cmd.
+func commandArgv(tool string, cmd CommandDescriptor) []string {
+	return append([]string{tool}, commandPath(tool, cmd)...)
+}
+
+// argKey returns the parameter name for an argument: the flag name without
+// its dashes, or the positional name.
+func argKey(arg ArgDescriptor) string {
+	return strings.TrimLeft(arg.Name, "-")
+}
+
+// isFlag reports whether arg is a flag rather than a positional.
+func isFlag(arg ArgDescriptor) bool {
+	return strings.HasPrefix(arg.Name, "-")
+}
+
+// argsJSONSchema returns a JSON Schema object describing cmd's arguments as
+// named parameters, the shape every function-calling API expects.
+func argsJSONSchema(cmd CommandDescriptor) map[string]any {
+	props := map[string]any{}
+	required := []string{}
+	for _, arg := range cmd.Args {
+		props[argKey(arg)] = argJSONSchema(arg)
+		if arg.Required {
+			required = append(required, argKey(arg))
+		}
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+// argJSONSchema returns the JSON Schema for a single argument.
+func argJSONSchema(arg ArgDescriptor) map[string]any {
+	s := map[string]any{}
+	switch arg.Type {
+	case "boolean", "integer", "number", "object":
+		s["type"] = arg.Type
+	case "array":
+		s["type"] = "array"
+		s["items"] = map[string]any{"type": "string"}
+	case "enum":
+		s["type"] = "string"
+		s["enum"] = arg.Values
+	default:
+		s["type"] = "string"
+	}
+	if desc := argDescription(arg); desc != "" {
+		s["description"] = desc
+	}
+	if def := typedDefault(arg); def != nil {
+		s["default"] = def
+	}
+	return s
+}
+
+// argDescription returns arg's description followed by the metadata that
+// formats without a field for it would otherwise lose: its unit, its
+// content hint, and where its default comes from when the schema carries
+// that instead of a value.
+func argDescription(arg ArgDescriptor) string {
+	var notes []string
+	if arg.Unit != "" {
+		notes = append(notes, "Unit: "+arg.Unit+".")
+	}
+	if arg.ContentHint != "" {
+		notes = append(notes, "Format: "+arg.ContentHint+".")
+	}
+	if arg.DefaultFrom != "" {
+		notes = append(notes, "Defaults to "+arg.DefaultFrom+".")
+	}
+	if len(notes) == 0 {
+		return arg.Description
+	}
+	if arg.Description != "" {
+		notes = append([]string{strings.TrimRight(arg.Description, ". ") + "."}, notes...)
+	}
+	return strings.Join(notes, " ")
+}
+
+// commandDescription returns desc, a command's description, followed by
+// the guidance formats without a field for it would otherwise lose: when
+// to use the command, and mistakes to avoid.
+func commandDescription(desc string, cmd CommandDescriptor) string {
+	var notes []string
+	if cmd.WhenToUse != "" {
+		notes = append(notes, "When to use: "+strings.TrimRight(cmd.WhenToUse, ". ")+".")
+	}
+	var mistakes []string
+	for _, m := range cmd.CommonMistakes {
+		if m = strings.TrimRight(strings.TrimSpace(m), ". "); m != "" {
+			mistakes = append(mistakes, m)
+		}
+	}
+	if len(mistakes) > 0 {
+		notes = append(notes, "Common mistakes: "+strings.Join(mistakes, "; ")+".")
+	}
+	if len(notes) == 0 {
+		return desc
+	}
+	if desc != "" {
+		notes = append([]string{strings.TrimRight(desc, ". ") + "."}, notes...)
+	}
+	return strings.Join(notes, " ")
+}
+
+// typedDefault converts a default that Describe emitted as a string (Cobra's
+// DefValue) to the argument's JSON type, so exported schemas validate.
+// Defaults that don't parse are dropped.
+func typedDefault(arg ArgDescriptor) any {
+	s, ok := arg.Default.(string)
+	if !ok {
+		return arg.Default
+	}
+	switch arg.Type {
+	case "integer":
+		if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return n
+		}
+		return nil
+	case "number":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+		return nil
+	case "boolean":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+		return nil
+	case "array":
+		// pflag renders slice defaults as "[a,b]".
+		inner := strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+		if inner == "" {
+			return nil
+		}
+		return strings.Split(inner, ",")
+	}
+	return s
+}
@@ -0,0 +1,315 @@
+package mtp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newExportTool() *ToolSchema {
+	root := &cobra.Command{Use: "imgtool", Short: "Image tools", Version: "1.2.0"}
+	convert := &cobra.Command{Use: "convert <input> [output]", Short: "Convert an image"}
+	convert.Flags().String("format", "png", "Output format")
+	EnumValues(convert, "format", []string{"png", "jpeg"})
+	convert.Flags().Int("quality", 90, "Quality")
+	convert.Flags().Bool("strip", false, "Strip metadata")
+	convert.Flags().StringSlice("tag", nil, "Tags to add")
+	root.AddCommand(convert)
+	return Describe(root, nil)
+}
+
+// ── Shared exporter helper tests ─────────────────────────────────────
+
+func TestExportName(t *testing.T) {
+	tests := []struct{ tool, cmd, want string }{
+		{"imgtool", "convert", "imgtool_convert"},
+		{"my-tool", "db migrate", "my_tool_db_migrate"},
+		{"tool", "_root", "tool"},
+		{"t", strings.Repeat("x", 80), "t_" + strings.Repeat("x", 62)},
+		{"7zip", "add", "tool_7zip_add"},
+		{"-", "_root", "tool"},
+	}
+	for _, tt := range tests {
+		if got := exportName(tt.tool, CommandDescriptor{Name: tt.cmd}); got != tt.want {
+			t.Errorf("exportName(%q, %q) = %q, want %q", tt.tool, tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestExportNamesCollide(t *testing.T) {
+	long := strings.Repeat("x", 80)
+	schema := &ToolSchema{Name: "tool", Commands: []CommandDescriptor{
+		{Name: "db-migrate"}, {Name: "db migrate"}, {Name: "db_migrate"},
+		{Name: long}, {Name: long + "y"},
+	}}
+	got := exportNames(schema)
+	want := []string{
+		"tool_db_migrate", "tool_db_migrate_2", "tool_db_migrate_3",
+		"tool_" + strings.Repeat("x", 59), "tool_" + strings.Repeat("x", 57) + "_2",
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("name %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if name := ToAutoGenTools(schema)[1].Name; name != "tool_db_migrate_2" {
+		t.Errorf("AutoGen name = %q", name)
+	}
+	if name := ToLangChainTools(schema)[2].Name; name != "tool_db_migrate_3" {
+		t.Errorf("LangChain name = %q", name)
+	}
+	plugin := ToSemanticKernelPlugin(schema)
+	if plugin.Functions[0].Name != "db_migrate" || plugin.Functions[1].Name != "db_migrate_2" {
+		t.Errorf("Semantic Kernel names = %q, %q", plugin.Functions[0].Name, plugin.Functions[1].Name)
+	}
+}
+
+func TestArgsJSONSchema(t *testing.T) {
+	s := argsJSONSchema(newExportTool().Commands[0])
+	props := s["properties"].(map[string]any)
+
+	if got := props["format"].(map[string]any); got["type"] != "string" || len(got["enum"].([]string)) != 2 {
+		t.Errorf("enum flag = %v", got)
+	}
+	if got := props["quality"].(map[string]any); got["default"] != int64(90) {
+		t.Errorf("integer default should be a number, got %#v", got["default"])
+	}
+	if got := props["tag"].(map[string]any); got["type"] != "array" {
+		t.Errorf("slice flag = %v", got)
+	}
+	if got := props["strip"].(map[string]any); got["type"] != "boolean" {
+		t.Errorf("bool flag = %v", got)
+	}
+	if req := s["required"].([]string); len(req) != 1 || req[0] != "input" {
+		t.Errorf("required = %v", req)
+	}
+}
+
+// ── LangChain export tests ───────────────────────────────────────────
+
+func TestToLangChainTools(t *testing.T) {
+	tools := ToLangChainTools(newExportTool())
+	if len(tools) != 1 {
+		t.Fatalf("expected 1 tool, got %d", len(tools))
+	}
+	tool := tools[0]
+	if tool.Name != "imgtool_convert" || tool.Description != "Convert an image" {
+		t.Errorf("unexpected tool: %s / %s", tool.Name, tool.Description)
+	}
+	if !strings.Contains(tool.Invocation, `_mtp_run(["imgtool","convert"], ["input","output"], kwargs)`) {
+		t.Errorf("invocation missing argv and positionals:\n%s", tool.Invocation)
+	}
+
+	data, err := json.Marshal(tools)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"args_schema"`) {
+		t.Errorf("expected snake_case args_schema key: %s", data)
+	}
+}
+
+func TestLangChainModule(t *testing.T) {
+	src := LangChainModule(newExportTool())
+	for _, want := range []string{
+		"from langchain_core.tools import StructuredTool",
+		"def _mtp_run(argv, positionals, kwargs):",
+		"imgtool_convert = StructuredTool.from_function(",
+		"TOOLS = [imgtool_convert]",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("module missing %q", want)
+		}
+	}
+	if strings.Contains(src, "null") || strings.Contains(src, "true,") {
+		t.Errorf("module contains JSON literals that aren't Python:\n%s", src)
+	}
+}
+
+// ── Semantic Kernel and AutoGen export tests ─────────────────────────
+
+func TestToSemanticKernelPlugin(t *testing.T) {
+	plugin := ToSemanticKernelPlugin(newExportTool())
+	if plugin.Name != "imgtool" || len(plugin.Functions) != 1 {
+		t.Fatalf("unexpected plugin: %+v", plugin)
+	}
+	fn := plugin.Functions[0]
+	if fn.Name != "convert" {
+		t.Errorf("function name = %q", fn.Name)
+	}
+	if strings.Join(fn.Command, " ") != "imgtool convert" {
+		t.Errorf("command = %v", fn.Command)
+	}
+	var input, quality *SemanticKernelParameter
+	for i := range fn.Parameters {
+		switch fn.Parameters[i].Name {
+		case "input":
+			input = &fn.Parameters[i]
+		case "quality":
+			quality = &fn.Parameters[i]
+		}
+	}
+	if input == nil || !input.IsRequired {
+		t.Errorf("input parameter = %+v", input)
+	}
+	if quality == nil || quality.DefaultValue != int64(90) || quality.Schema["type"] != "integer" {
+		t.Errorf("quality parameter = %+v", quality)
+	}
+}
+
+func TestToAutoGenTools(t *testing.T) {
+	tools := ToAutoGenTools(newExportTool())
+	if len(tools) != 1 || tools[0].Name != "imgtool_convert" {
+		t.Fatalf("unexpected tools: %+v", tools)
+	}
+	data, _ := json.Marshal(tools[0])
+	for _, key := range []string{`"name"`, `"description"`, `"parameters"`, `"strict":false`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("AutoGen tool JSON missing %s: %s", key, data)
+		}
+	}
+}
+
+// ── GitHub Action export tests ───────────────────────────────────────
+
+func newActionSchema() *ToolSchema {
+	schema := newExportTool()
+	schema.Commands[0].Stdout = &IODescriptor{
+		ContentType: "application/json",
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path":  map[string]any{"type": "string"},
+				"bytes": map[string]any{"type": "integer"},
+			},
+		},
+	}
+	return schema
+}
+
+func TestToGitHubAction(t *testing.T) {
+	action, err := ToGitHubAction(newActionSchema(), "convert")
+	if err != nil {
+		t.Fatalf("ToGitHubAction failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`name: "imgtool convert"`,
+		"  input:\n    description: \"\"\n    required: true\n",
+		"  format:\n    description: \"Output format (one of: png, jpeg)\"\n    required: false\n    default: \"png\"\n",
+		"  bytes:\n",
+		"value: ${{ steps.run.outputs.path }}",
+		"MTP_INPUT_QUALITY: ${{ inputs.quality }}",
+		`using: "composite"`,
+	} {
+		if !strings.Contains(action.ActionYAML, want) {
+			t.Errorf("action.yml missing %q:\n%s", want, action.ActionYAML)
+		}
+	}
+	if strings.Contains(action.Entrypoint, "${{") {
+		t.Error("entrypoint must not interpolate expressions")
+	}
+	for _, want := range []string{
+		"args=('imgtool' 'convert')",
+		`if [ "${MTP_INPUT_STRIP:-}" = "true" ]; then args+=('--strip'); fi`,
+		`jq -r --arg k 'bytes'`,
+	} {
+		if !strings.Contains(action.Entrypoint, want) {
+			t.Errorf("entrypoint missing %q:\n%s", want, action.Entrypoint)
+		}
+	}
+}
+
+func TestToGitHubActionUnknownCommand(t *testing.T) {
+	if _, err := ToGitHubAction(newActionSchema(), "resize"); err == nil {
+		t.Error("expected error for unknown command")
+	}
+}
+
+// ── Nushell export tests ─────────────────────────────────────────────
+
+func TestToNushell(t *testing.T) {
+	schema := newExportTool()
+	schema.Commands[0].Args = append(schema.Commands[0].Args, ArgDescriptor{Name: "extra-files", Type: "array", Variadic: true})
+	src := ToNushell(schema)
+
+	for _, want := range []string{
+		`def "nu-complete imgtool convert format" [] { ["png" "jpeg"] }`,
+		"# Convert an image\nexport extern \"imgtool convert\" [\n",
+		"  input: string\n",
+		"  output?: string\n",
+		`  --format: string@"nu-complete imgtool convert format"  # Output format`,
+		"  --quality: int  # Quality",
+		"  --strip  # Strip metadata",
+		"  ...extra_files: string",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("nushell output missing %q:\n%s", want, src)
+		}
+	}
+}
+
+// ── Protobuf export tests ────────────────────────────────────────────
+
+func TestToProto(t *testing.T) {
+	schema := newExportTool()
+	schema.Commands[0].Args = append(schema.Commands[0].Args, ArgDescriptor{Name: "extra-files", Type: "array", Variadic: true})
+	schema.Commands[0].Stdout = &IODescriptor{ContentType: "application/json"}
+	src := ToProto(schema)
+
+	for _, want := range []string{
+		"syntax = \"proto3\";\n\npackage imgtool;\n",
+		"// Convert an image\nmessage ConvertRequest {\n",
+		"  string input = 1;\n",
+		"  optional string output = 2;\n",
+		"  enum Format {\n    FORMAT_UNSPECIFIED = 0;\n    FORMAT_PNG = 1; // \"png\"\n    FORMAT_JPEG = 2; // \"jpeg\"\n  }\n",
+		"  // Output format (--format) Default: \"png\".\n  Format format = 3;\n",
+		"  // Quality (--quality) Default: 90.\n  optional int64 quality = 4;\n",
+		"  optional bool strip = 5;\n",
+		"  repeated string tag = 6;\n",
+		"  repeated string extra_files = 7;\n",
+		"message ConvertResponse {\n  int32 exit_code = 1;\n  // application/json\n  bytes stdout = 2;\n",
+		"// Image tools\nservice Imgtool {\n  // Convert an image\n  rpc Convert(ConvertRequest) returns (ConvertResponse);\n}\n",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("proto output missing %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestToProtoIdentifiers(t *testing.T) {
+	schema := &ToolSchema{Name: "my-tool", Version: "1", Commands: []CommandDescriptor{
+		{Name: "_root", Description: "Root"},
+		{Name: "db migrate", Args: []ArgDescriptor{
+			{Name: "--dry-run", Type: "boolean"},
+			{Name: "--dry_run", Type: "boolean"},
+			{Name: "--mode", Type: "enum", Values: []string{"a-b", "a b", "2x"}},
+			{Name: "--tables", Type: "array", Values: []string{"users", "orders"}},
+			{Name: "regions", Type: "enum", Values: []string{"us", "eu"}, Variadic: true},
+		}},
+	}}
+	src := ToProto(schema)
+
+	for _, want := range []string{
+		"package my_tool;\n",
+		"message MyToolRequest {",
+		"rpc MyTool(MyToolRequest) returns (MyToolResponse);",
+		"message DbMigrateRequest {",
+		"optional bool dry_run = 1;",
+		"optional bool dry_run2 = 2;",
+		"MODE_A_B = 1;",
+		"MODE_A_B2 = 2;",
+		"MODE_2X = 3;",
+		"  Mode mode = 3;\n",
+		"  repeated Tables tables = 4;\n",
+		"  repeated Regions regions = 5;\n",
+		"service MyTool {",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("proto output missing %q:\n%s", want, src)
+		}
+	}
+}
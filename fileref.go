@@ -0,0 +1,95 @@
+package mtp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// FileRefKey is the JSON key that marks an object as a file reference.
+const FileRefKey = "$file"
+
+// FileRef is a content reference used in place of inline data.
+// Commands exchanging large or binary artifacts write {"$file": "/tmp/out.bin"}
+// instead of embedding the bytes in their JSON input or output.
+type FileRef struct {
+	File string `json:"$file"`
+}
+
+// FileRefSchema returns the JSON Schema for a file reference object.
+// Use it inside IODescriptor.Schema wherever a value may be passed by reference:
+//
+//	"properties": map[string]any{
+//	    "image": mtp.FileRefSchema(),
+//	}
+func FileRefSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			FileRefKey: map[string]any{
+				"type":        "string",
+				"description": "Path to a file holding the content",
+			},
+		},
+		"required":             []string{FileRefKey},
+		"additionalProperties": false,
+	}
+}
+
+// AsFileRef reports whether v is a file reference. It accepts a FileRef,
+// a *FileRef, or a decoded JSON object ({"$file": "..."}) with no other keys.
+func AsFileRef(v any) (FileRef, bool) {
+	switch ref := v.(type) {
+	case FileRef:
+		return ref, ref.File != ""
+	case *FileRef:
+		if ref == nil {
+			return FileRef{}, false
+		}
+		return *ref, ref.File != ""
+	case map[string]any:
+		if len(ref) != 1 {
+			return FileRef{}, false
+		}
+		path, ok := ref[FileRefKey].(string)
+		if !ok || path == "" {
+			return FileRef{}, false
+		}
+		return FileRef{File: path}, true
+	case json.RawMessage:
+		var m map[string]any
+		if err := json.Unmarshal(ref, &m); err != nil {
+			return FileRef{}, false
+		}
+		return AsFileRef(m)
+	}
+	return FileRef{}, false
+}
+
+// ReadFileRef returns the content a file reference points to.
+func ReadFileRef(ref FileRef) ([]byte, error) {
+	if ref.File == "" {
+		return nil, fmt.Errorf("file reference has empty %s", FileRefKey)
+	}
+	return os.ReadFile(ref.File)
+}
+
+// WriteFileRef writes data to path and returns a reference to it.
+// If path is empty, a temporary file is created.
+func WriteFileRef(path string, data []byte) (FileRef, error) {
+	if path == "" {
+		f, err := os.CreateTemp("", "mtp-*")
+		if err != nil {
+			return FileRef{}, err
+		}
+		defer f.Close()
+		if _, err := f.Write(data); err != nil {
+			return FileRef{}, err
+		}
+		return FileRef{File: f.Name()}, nil
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return FileRef{}, err
+	}
+	return FileRef{File: path}, nil
+}
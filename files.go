@@ -0,0 +1,68 @@
+package mtp
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// resolveFiles loads the external files an annotation points at:
+// Example.CommandFile and OutputFile, and IODescriptor.SchemaFile. Files
+// are read from opts.FS, or the OS filesystem if it is nil. A file that
+// can't be read or parsed leaves its field unresolved, which ValidateSchema
+// reports as an error; an example whose command is unresolved is dropped
+// when the schema is encoded.
+func resolveFiles(cd *CommandDescriptor, opts *DescribeOptions) {
+	var fsys fs.FS
+	if opts != nil {
+		fsys = opts.FS
+	}
+
+	for i := range cd.Examples {
+		ex := &cd.Examples[i]
+		if ex.CommandFile != "" && ex.Command == "" {
+			if data, err := readFile(fsys, ex.CommandFile); err == nil {
+				ex.Command = strings.TrimSpace(string(data))
+				ex.CommandFile = ""
+			}
+		}
+		if ex.OutputFile != "" && ex.Output == "" {
+			if data, err := readFile(fsys, ex.OutputFile); err == nil {
+				ex.Output = strings.TrimRight(string(data), "\n")
+				ex.OutputFile = ""
+			}
+		}
+	}
+
+	cd.Stdin = resolveSchemaFile(cd.Stdin, fsys)
+	cd.Stdout = resolveSchemaFile(cd.Stdout, fsys)
+}
+
+// resolveSchemaFile returns d with SchemaFile loaded into Schema. d is
+// shared with the caller's annotation, so a resolved descriptor is a copy.
+func resolveSchemaFile(d *IODescriptor, fsys fs.FS) *IODescriptor {
+	if d == nil || d.SchemaFile == "" || d.Schema != nil {
+		return d
+	}
+	data, err := readFile(fsys, d.SchemaFile)
+	if err != nil {
+		return d
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return d
+	}
+	resolved := *d
+	resolved.Schema = schema
+	resolved.SchemaFile = ""
+	return &resolved
+}
+
+// readFile reads path from fsys, or from the OS filesystem if fsys is nil.
+func readFile(fsys fs.FS, path string) ([]byte, error) {
+	if fsys == nil {
+		return os.ReadFile(path)
+	}
+	return fs.ReadFile(fsys, path)
+}
@@ -0,0 +1,176 @@
+package mtp
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// GitHubAction holds the files that publish one command as a GitHub
+// composite action. Write them to the root of the action's repository.
+type GitHubAction struct {
+	ActionYAML string // action.yml
+	Entrypoint string // entrypoint.sh, which must be executable
+}
+
+// ToGitHubAction generates a GitHub Action that runs the named command.
+// Each argument becomes an input. If the command's stdout schema is an
+// object, each top-level property becomes an output, read from the
+// command's JSON output with jq; the raw output is always available as
+// the "stdout" output. The tool itself must be on the runner's PATH.
+//
+// Inputs reach the entrypoint through environment variables, never by
+// interpolation into the script, so input values can't inject shell code.
+func ToGitHubAction(schema *ToolSchema, command string) (*GitHubAction, error) {
+	var cmd *CommandDescriptor
+	for i := range schema.Commands {
+		if schema.Commands[i].Name == command {
+			cmd = &schema.Commands[i]
+			break
+		}
+	}
+	if cmd == nil {
+		return nil, fmt.Errorf("command %q not found in schema", command)
+	}
+
+	return &GitHubAction{
+		ActionYAML: actionYAML(schema, cmd),
+		Entrypoint: actionEntrypoint(schema, cmd),
+	}, nil
+}
+
+// actionInputEnv returns the environment variable carrying an input.
+func actionInputEnv(arg ArgDescriptor) string {
+	return "MTP_INPUT_" + strings.ToUpper(nonIdentChars.ReplaceAllString(argKey(arg), "_"))
+}
+
+// actionOutputName matches property names usable as action output IDs.
+var actionOutputName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// stdoutKeys returns the sorted top-level properties of cmd's stdout schema
+// that are valid output IDs.
+func stdoutKeys(schema *ToolSchema, cmd *CommandDescriptor) []string {
+	if cmd.Stdout == nil {
+		return nil
+	}
+	stdout := cmd.Stdout.Schema
+	if resolved, err := schema.ResolveRefs(stdout); err == nil {
+		stdout = resolved
+	}
+	props, _ := stdout["properties"].(map[string]any)
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		if actionOutputName.MatchString(k) && k != "stdout" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func actionYAML(schema *ToolSchema, cmd *CommandDescriptor) string {
+	var b strings.Builder
+	title := strings.Join(commandArgv(schema.Name, *cmd), " ")
+	desc := cmd.Description
+	if desc == "" {
+		desc = schema.Description
+	}
+
+	// Every scalar is a JSON string, which is also a valid YAML
+	// double-quoted scalar, so no value needs YAML-specific escaping.
+	fmt.Fprintf(&b, "# Generated by mtpgen from the MTP schema for %s %s.\n", schema.Name, schema.Version)
+	fmt.Fprintf(&b, "name: %s\n", mustJSON(title))
+	fmt.Fprintf(&b, "description: %s\n", mustJSON(desc))
+
+	b.WriteString("inputs:\n")
+	if len(cmd.Args) == 0 {
+		b.WriteString("  {}\n")
+	}
+	for _, arg := range cmd.Args {
+		fmt.Fprintf(&b, "  %s:\n", argKey(arg))
+		d := argDescription(arg)
+		if len(arg.Values) > 0 {
+			d = strings.TrimSpace(d + " (one of: " + strings.Join(arg.Values, ", ") + ")")
+		}
+		if arg.Type == "array" {
+			d = strings.TrimSpace(d + " (one value per line)")
+		}
+		fmt.Fprintf(&b, "    description: %s\n", mustJSON(d))
+		fmt.Fprintf(&b, "    required: %t\n", arg.Required)
+		if def := typedDefault(arg); def != nil && arg.Type != "array" {
+			fmt.Fprintf(&b, "    default: %s\n", mustJSON(fmt.Sprint(def)))
+		}
+	}
+
+	b.WriteString("outputs:\n")
+	b.WriteString("  stdout:\n")
+	b.WriteString("    description: \"Everything the command wrote to stdout\"\n")
+	b.WriteString("    value: ${{ steps.run.outputs.stdout }}\n")
+	for _, key := range stdoutKeys(schema, cmd) {
+		fmt.Fprintf(&b, "  %s:\n", key)
+		fmt.Fprintf(&b, "    description: %s\n", mustJSON("The "+key+" field of the command's JSON output"))
+		fmt.Fprintf(&b, "    value: ${{ steps.run.outputs.%s }}\n", key)
+	}
+
+	b.WriteString("runs:\n")
+	b.WriteString("  using: \"composite\"\n")
+	b.WriteString("  steps:\n")
+	b.WriteString("    - id: run\n")
+	b.WriteString("      shell: bash\n")
+	b.WriteString("      run: \"\\\"$GITHUB_ACTION_PATH/entrypoint.sh\\\"\"\n")
+	if len(cmd.Args) > 0 {
+		b.WriteString("      env:\n")
+		for _, arg := range cmd.Args {
+			fmt.Fprintf(&b, "        %s: ${{ inputs.%s }}\n", actionInputEnv(arg), argKey(arg))
+		}
+	}
+	return b.String()
+}
+
+func actionEntrypoint(schema *ToolSchema, cmd *CommandDescriptor) string {
+	var b strings.Builder
+	b.WriteString("#!/usr/bin/env bash\n")
+	fmt.Fprintf(&b, "# Generated by mtpgen from the MTP schema for %s %s.\n", schema.Name, schema.Version)
+	b.WriteString("set -euo pipefail\n\n")
+
+	argv := commandArgv(schema.Name, *cmd)
+	quoted := make([]string, len(argv))
+	for i, a := range argv {
+		quoted[i] = shellQuote(a)
+	}
+	fmt.Fprintf(&b, "args=(%s)\n", strings.Join(quoted, " "))
+
+	for _, arg := range cmd.Args {
+		env := actionInputEnv(arg)
+		switch {
+		case !isFlag(arg) && (arg.Type == "array" || arg.Variadic):
+			fmt.Fprintf(&b, "while IFS= read -r v; do if [ -n \"$v\" ]; then args+=(\"$v\"); fi; done <<< \"${%s:-}\"\n", env)
+		case !isFlag(arg):
+			fmt.Fprintf(&b, "if [ -n \"${%s:-}\" ]; then args+=(\"$%s\"); fi\n", env, env)
+		case arg.Type == "boolean":
+			fmt.Fprintf(&b, "if [ \"${%s:-}\" = \"true\" ]; then args+=(%s); fi\n", env, shellQuote(arg.Name))
+		case arg.Type == "array":
+			fmt.Fprintf(&b, "while IFS= read -r v; do if [ -n \"$v\" ]; then args+=(%s \"$v\"); fi; done <<< \"${%s:-}\"\n", shellQuote(arg.Name), env)
+		default:
+			fmt.Fprintf(&b, "if [ -n \"${%s:-}\" ]; then args+=(%s \"$%s\"); fi\n", env, shellQuote(arg.Name), env)
+		}
+	}
+
+	b.WriteString("\nout=$(\"${args[@]}\")\n")
+	b.WriteString("printf '%s\\n' \"$out\"\n\n")
+	b.WriteString("delim=\"mtp_$(od -An -N8 -tx1 /dev/urandom | tr -d ' \\n')\"\n")
+	b.WriteString("{\n")
+	b.WriteString("  printf 'stdout<<%s\\n%s\\n%s\\n' \"$delim\" \"$out\" \"$delim\"\n")
+	for _, key := range stdoutKeys(schema, cmd) {
+		fmt.Fprintf(&b, "  printf '%%s<<%%s\\n%%s\\n%%s\\n' %s \"$delim\" \"$(jq -r --arg k %s '.[$k] | if type == \"string\" then . else tojson end' <<< \"$out\")\" \"$delim\"\n",
+			shellQuote(key), shellQuote(key))
+	}
+	b.WriteString("} >> \"$GITHUB_OUTPUT\"\n")
+	return b.String()
+}
+
+// shellQuote single-quotes s for bash.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
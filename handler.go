@@ -0,0 +1,237 @@
+package mtp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Handler implements a command added with Register. It receives the input
+// Bind fills from the invocation, and its output is written to stdout as
+// JSON.
+type Handler[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// HandlerMeta describes a registered command beyond what its types say.
+type HandlerMeta struct {
+	Short string // One-line description
+	Long  string
+	// Annotation adds what the types can't carry, such as examples, auth,
+	// or capabilities. Its fields win over those Register derives.
+	Annotation *CommandAnnotation
+	// Middleware wraps this command's handler, inside the tool's, added
+	// with Use.
+	Middleware []Middleware
+}
+
+// registeredAnnotations holds the annotations Register derived, by
+// command. Describe merges them below DescribeOptions' own annotations.
+var registeredAnnotations struct {
+	sync.RWMutex
+	m map[*cobra.Command]*CommandAnnotation
+}
+
+// registeredAnnotation returns the annotation Register stored for cmd.
+func registeredAnnotation(cmd *cobra.Command) *CommandAnnotation {
+	registeredAnnotations.RLock()
+	defer registeredAnnotations.RUnlock()
+	return registeredAnnotations.m[cmd]
+}
+
+// Register adds the command name (e.g. "image convert") below root,
+// implemented by handler, and declares its metadata from the same types,
+// so the schema can't drift from the code:
+//
+//	mtp.Register(root, "image convert", convert, mtp.HandlerMeta{Short: "Convert an image"})
+//
+//	func convert(ctx context.Context, in convertInput) (convertResult, error) { ... }
+//
+// In's fields tagged arg become the positional arguments (see ArgsOf),
+// those tagged flag become flags, and one tagged stdin becomes the JSON
+// stdin (see Bind). A flag field's help tag is its usage, default its
+// default, enum its allowed values, and type its MTP type; the option
+// required marks it required. SchemaOf[Out] is the stdout schema, unless
+// Out is struct{}, in which case nothing is written. The handler runs
+// inside the middleware added with Use and meta.Middleware.
+//
+// Missing parent commands are created. The annotations apply whether or
+// not Describe is given DescribeOptions, and DescribeOptions' own
+// annotations win over them field by field. Register returns the new
+// command. It panics if the command already exists, or In's tags can't
+// be turned into arguments and flags.
+func Register[In, Out any](root *cobra.Command, name string, handler Handler[In, Out], meta HandlerMeta) *cobra.Command {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		panic("mtp: Register needs a command name")
+	}
+	parent := root
+	for _, w := range words[:len(words)-1] {
+		parent = childCommand(parent, w, true)
+	}
+	if childCommand(parent, words[len(words)-1], false) != nil {
+		panic(fmt.Sprintf("mtp: Register: %q is already a command", name))
+	}
+
+	inType := reflect.TypeOf((*In)(nil)).Elem()
+	outType := reflect.TypeOf((*Out)(nil)).Elem()
+	hasOutput := outType != reflect.TypeOf(struct{}{})
+	invoke := func(ctx context.Context, call *Call) (any, error) {
+		in, ok := call.Input.(In)
+		if !ok {
+			return nil, fmt.Errorf("mtp: %s: middleware replaced the %s input with a %T", name, inType, call.Input)
+		}
+		out, err := handler(ctx, in)
+		if err != nil || !hasOutput {
+			return nil, err
+		}
+		return out, nil
+	}
+	local := slices.Clone(meta.Middleware)
+	ann := &CommandAnnotation{Args: argsOfType(inType)}
+	cmd := &cobra.Command{
+		Use:   useLine(words[len(words)-1], ann.Args),
+		Short: meta.Short,
+		Long:  meta.Long,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var in In
+			if err := Bind(cmd, args, &in); err != nil {
+				return err
+			}
+			out, err := chain(invoke, cmd.Root(), local)(cmd.Context(), &Call{Command: cmd, Args: args, Input: in})
+			if err != nil || out == nil {
+				return err
+			}
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(out)
+		},
+	}
+	addFlags(cmd, inType, ann)
+	if stdin := taggedFields(inType, "stdin"); len(stdin) > 0 {
+		ann.Stdin = &IODescriptor{ContentType: "application/json", Schema: schemaOfType(stdin[0].Type)}
+	}
+	if hasOutput {
+		ann.Stdout = &IODescriptor{ContentType: "application/json", Schema: schemaOfType(outType)}
+	}
+	parent.AddCommand(cmd)
+
+	registeredAnnotations.Lock()
+	defer registeredAnnotations.Unlock()
+	if registeredAnnotations.m == nil {
+		registeredAnnotations.m = map[*cobra.Command]*CommandAnnotation{}
+	}
+	registeredAnnotations.m[cmd] = mergeAnnotation(meta.Annotation, ann)
+	return cmd
+}
+
+// childCommand returns parent's subcommand named name. If there's none
+// and create is set, it adds one for grouping.
+func childCommand(parent *cobra.Command, name string, create bool) *cobra.Command {
+	for _, c := range parent.Commands() {
+		if c.Name() == name {
+			return c
+		}
+	}
+	if !create {
+		return nil
+	}
+	c := &cobra.Command{Use: name}
+	parent.AddCommand(c)
+	return c
+}
+
+// useLine returns a Use string naming a command's positionals, in the
+// convention Describe parses.
+func useLine(name string, args []ArgDescriptor) string {
+	words := []string{name}
+	for _, a := range args {
+		w := "<" + a.Name + ">"
+		if !a.Required {
+			w = "[" + a.Name + "]"
+		}
+		if a.Variadic {
+			w += "..."
+		}
+		words = append(words, w)
+	}
+	return strings.Join(words, " ")
+}
+
+// addFlags defines a flag for each of t's fields tagged flag, recording
+// type tags in ann.
+func addFlags(cmd *cobra.Command, t reflect.Type, ann *CommandAnnotation) {
+	fs := cmd.Flags()
+	for _, f := range taggedFields(t, "flag") {
+		help := f.Tag.Get("help")
+		def := f.Tag.Get("default")
+		bad := func(err error) {
+			panic(fmt.Sprintf("mtp: Register: %s.%s: default %q: %v", t.Name(), f.Name, def, err))
+		}
+		switch kind := f.Type.Kind(); {
+		case f.Type == durationType:
+			var d time.Duration
+			if def != "" {
+				var err error
+				if d, err = time.ParseDuration(def); err != nil {
+					bad(err)
+				}
+			}
+			fs.Duration(f.name, d, help)
+		case kind == reflect.String:
+			fs.String(f.name, def, help)
+		case kind == reflect.Bool:
+			b := false
+			if def != "" {
+				var err error
+				if b, err = strconv.ParseBool(def); err != nil {
+					bad(err)
+				}
+			}
+			fs.Bool(f.name, b, help)
+		case argKindType(f.Type) == "integer":
+			var n int64
+			if def != "" {
+				var err error
+				if n, err = strconv.ParseInt(def, 10, 64); err != nil {
+					bad(err)
+				}
+			}
+			fs.Int64(f.name, n, help)
+		case argKindType(f.Type) == "number":
+			var n float64
+			if def != "" {
+				var err error
+				if n, err = strconv.ParseFloat(def, 64); err != nil {
+					bad(err)
+				}
+			}
+			fs.Float64(f.name, n, help)
+		case kind == reflect.Slice && argKindType(f.Type) == "array":
+			var values []string
+			if def != "" {
+				values = strings.Split(def, ",")
+			}
+			fs.StringSlice(f.name, values, help)
+		default:
+			panic(fmt.Sprintf("mtp: Register: %s.%s: a %s can't hold a flag", t.Name(), f.Name, f.Type))
+		}
+
+		if enum := enumTag(f); enum != nil {
+			EnumValues(cmd, f.name, enum)
+		}
+		if typ := f.Tag.Get("type"); typ != "" {
+			if ann.ArgTypes == nil {
+				ann.ArgTypes = map[string]string{}
+			}
+			ann.ArgTypes[f.name] = typ
+		}
+		if f.has("required") {
+			cmd.MarkFlagRequired(f.name)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package mtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type resizeInput struct {
+	Input   string        `arg:"input_file" type:"path" help:"Image to resize"`
+	Width   int           `flag:"width,required" help:"Width in pixels"`
+	Format  string        `flag:"format" enum:"png,webp" default:"png" help:"Output format"`
+	Out     string        `flag:"out" type:"path"`
+	Options resizeOptions `stdin:"optional"`
+}
+
+type resizeOptions struct {
+	Sharpen bool `json:"sharpen,omitempty"`
+}
+
+type resizeResult struct {
+	Path  string `json:"path"`
+	Width int    `json:"width"`
+}
+
+func resize(_ context.Context, in resizeInput) (resizeResult, error) {
+	return resizeResult{Path: strings.TrimSuffix(in.Input, ".jpg") + "." + in.Format, Width: in.Width}, nil
+}
+
+func TestRegister(t *testing.T) {
+	root := &cobra.Command{Use: "img"}
+	Register(root, "image resize", resize, HandlerMeta{
+		Short:      "Resize an image",
+		Annotation: &CommandAnnotation{Capabilities: []string{CapFSWrite}},
+	})
+
+	var stdout bytes.Buffer
+	root.SetOut(&stdout)
+	root.SetIn(strings.NewReader(`{"sharpen": true}`))
+	root.SetArgs([]string{"image", "resize", "cat.jpg", "--width", "64"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(stdout.String()); got != `{"path":"cat.png","width":64}` {
+		t.Errorf("stdout %s", got)
+	}
+
+	schema := Describe(root, nil)
+	if len(schema.Commands) != 1 {
+		t.Fatalf("commands %+v", schema.Commands)
+	}
+	cmd := schema.Commands[0]
+	if cmd.Name != "image resize" || cmd.Description != "Resize an image" || !slices.Equal(cmd.Capabilities, []string{CapFSWrite}) {
+		t.Errorf("command %+v", cmd)
+	}
+	args := map[string]ArgDescriptor{}
+	for _, a := range cmd.Args {
+		args[a.Name] = a
+	}
+	if a := args["input_file"]; a.Type != "path" || !a.Required || a.Description != "Image to resize" {
+		t.Errorf("input_file %+v", a)
+	}
+	if a := args["--width"]; a.Type != "integer" || !a.Required {
+		t.Errorf("--width %+v", a)
+	}
+	if a := args["--format"]; a.Type != "enum" || !slices.Equal(a.Values, []string{"png", "webp"}) || a.Default != "png" {
+		t.Errorf("--format %+v", a)
+	}
+	if a := args["--out"]; a.Type != "path" {
+		t.Errorf("--out %+v", a)
+	}
+	if cmd.Stdin == nil || cmd.Stdin.Schema["type"] != "object" || cmd.Stdout == nil {
+		t.Fatalf("stdin %+v, stdout %+v", cmd.Stdin, cmd.Stdout)
+	}
+	props, _ := cmd.Stdout.Schema["properties"].(map[string]any)
+	if _, ok := props["width"]; !ok {
+		t.Errorf("stdout schema %v", cmd.Stdout.Schema)
+	}
+	data, _ := json.Marshal(schema)
+	if diags := ValidateAgainstSpec(data); len(diags) > 0 {
+		t.Errorf("spec errors: %v", diags)
+	}
+}
+
+func TestRegisterInvalidInput(t *testing.T) {
+	root := &cobra.Command{Use: "img", SilenceErrors: true, SilenceUsage: true}
+	Register(root, "resize", resize, HandlerMeta{})
+	root.SetOut(&bytes.Buffer{})
+	root.SetArgs([]string{"resize", "cat.jpg", "--width", "64", "--format", "gif"})
+	err := root.Execute()
+	if err == nil || !strings.HasPrefix(err.Error(), "INVALID_INPUT: ") {
+		t.Errorf("got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering resize twice didn't panic")
+		}
+	}()
+	Register(root, "resize", resize, HandlerMeta{})
+}
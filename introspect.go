@@ -1,7 +1,11 @@
 package mtp
 
 import (
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"unicode"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -56,17 +60,83 @@ func flagDefault(f *pflag.Flag) any {
 var skippedFlags = map[string]bool{
 	"help":         true,
 	"mtp-describe": true,
+	"mtp-check":    true,
+	"mtp-env":      true,
 	"version":      true,
 }
 
-// extractFlags builds ArgDescriptors from a command's flags.
-func extractFlags(cmd *cobra.Command, ann *CommandAnnotation) []ArgDescriptor {
-	var args []ArgDescriptor
+// flagConflict records a flag name defined with different types at two
+// levels of the command tree (e.g. a root persistent flag shadowed by a
+// subcommand's local flag).
+type flagConflict struct {
+	name    string
+	kept    *pflag.Flag
+	dropped *pflag.Flag
+}
+
+// collectFlags returns every flag that applies to cmd: its local flags,
+// its own persistent flags, and persistent flags inherited from ancestors.
+// A name defined at more than one level appears once, with precedence
+// local > own persistent > nearest ancestor. Shadowed flags whose type
+// differs from the winner are returned as conflicts.
+func collectFlags(cmd *cobra.Command) ([]*pflag.Flag, []flagConflict) {
+	// pflag doesn't expose a flag count; 16 covers most commands
+	// without regrowing.
+	flags := make([]*pflag.Flag, 0, 16)
+	var conflicts []flagConflict
+	seen := make(map[string]*pflag.Flag, 16)
 
-	cmd.Flags().VisitAll(func(f *pflag.Flag) {
-		if skippedFlags[f.Name] || f.Hidden {
+	add := func(f *pflag.Flag) {
+		kept, ok := seen[f.Name]
+		if !ok {
+			seen[f.Name] = f
+			flags = append(flags, f)
 			return
 		}
+		// Cobra merges persistent flags into Flags() by pointer, so the
+		// same flag can be visited twice; that's not a conflict.
+		if kept != f && kept.Value.Type() != f.Value.Type() {
+			conflicts = append(conflicts, flagConflict{name: f.Name, kept: kept, dropped: f})
+		}
+	}
+
+	cmd.Flags().VisitAll(add)
+	cmd.PersistentFlags().VisitAll(add)
+	for p := cmd.Parent(); p != nil; p = p.Parent() {
+		p.PersistentFlags().VisitAll(add)
+	}
+
+	if cmd.Flags().SortFlags {
+		slices.SortStableFunc(flags, func(a, b *pflag.Flag) int { return strings.Compare(a.Name, b.Name) })
+	}
+	return flags, conflicts
+}
+
+// flagArgNames interns "--name" strings so repeated describes of the same
+// tree (serve mode, DescribeCached misses) don't rebuild them.
+var flagArgNames sync.Map // flag name -> "--" + name
+
+// flagArgName returns the interned "--name" for a flag.
+func flagArgName(name string) string {
+	if s, ok := flagArgNames.Load(name); ok {
+		return s.(string)
+	}
+	s, _ := flagArgNames.LoadOrStore(name, "--"+name)
+	return s.(string)
+}
+
+// extractFlags appends ArgDescriptors for a command's flags, as returned
+// by collectFlags, to args.
+func extractFlags(args []ArgDescriptor, flags []*pflag.Flag, ann *CommandAnnotation) []ArgDescriptor {
+	if args == nil {
+		args = make([]ArgDescriptor, 0, len(flags))
+	}
+	for _, f := range flags {
+		// pflag hides deprecated flags from help, but clients with old
+		// plans still need to know about them.
+		if skippedFlags[f.Name] || (f.Hidden && f.Deprecated == "") {
+			continue
+		}
 
 		typ := pflagTypeToMTP(f)
 		if ann != nil {
@@ -76,7 +146,7 @@ func extractFlags(cmd *cobra.Command, ann *CommandAnnotation) []ArgDescriptor {
 		}
 
 		arg := ArgDescriptor{
-			Name:        "--" + f.Name,
+			Name:        flagArgName(f.Name),
 			Type:        typ,
 			Description: f.Usage,
 		}
@@ -86,10 +156,41 @@ func extractFlags(cmd *cobra.Command, ann *CommandAnnotation) []ArgDescriptor {
 			arg.Required = true
 		}
 
-		if def := flagDefault(f); def != nil {
+		// Never leak a secret default (e.g. --api-key set from the
+		// environment), nor one resolved from a documented source.
+		if from := f.Annotations[defaultFromAnnotation]; len(from) > 0 {
+			arg.DefaultFrom = from[0]
+		} else if def := flagDefault(f); def != nil && !isSensitiveFlag(f) {
 			arg.Default = def
 		}
 
+		var dep *Deprecation
+		if ann != nil {
+			dep = ann.DeprecatedArgs[f.Name]
+		}
+		arg.Deprecated = deprecation(f.Deprecated, dep)
+
+		// Grouping stored via the ArgGroup and Advanced helpers.
+		if g := f.Annotations[groupAnnotation]; len(g) > 0 {
+			arg.Group = g[0]
+		}
+		if _, ok := f.Annotations[advancedAnnotation]; ok {
+			arg.Advanced = true
+		}
+		arg.RequiredIf, arg.ConflictsWith = flagConditions(f)
+		if _, ok := f.Annotations[sensitiveAnnotation]; ok {
+			arg.Sensitive = true
+		}
+		if u := f.Annotations[unitAnnotation]; len(u) > 0 {
+			arg.Unit = u[0]
+		}
+		if h := f.Annotations[contentHintAnnotation]; len(h) > 0 {
+			arg.ContentHint = h[0]
+		}
+		if s := f.Annotations[styleAnnotation]; len(s) > 0 {
+			arg.Style = s[0]
+		}
+
 		// Enum values stored via EnumValues helper.
 		if vals, ok := f.Annotations["values"]; ok && len(vals) > 0 {
 			arg.Type = "enum"
@@ -97,41 +198,14 @@ func extractFlags(cmd *cobra.Command, ann *CommandAnnotation) []ArgDescriptor {
 		}
 
 		args = append(args, arg)
-	})
-
-	return args
-}
-
-// parseUseArgs extracts positional arg descriptors from a Cobra Use string.
-// Convention: "command <required> [optional]"
-func parseUseArgs(use string) []ArgDescriptor {
-	parts := strings.Fields(use)
-	if len(parts) <= 1 {
-		return nil
 	}
 
-	var args []ArgDescriptor
-	for _, part := range parts[1:] {
-		if strings.HasPrefix(part, "<") && strings.HasSuffix(part, ">") {
-			name := strings.Trim(part, "<>")
-			args = append(args, ArgDescriptor{
-				Name:     name,
-				Type:     "string",
-				Required: true,
-			})
-		} else if strings.HasPrefix(part, "[") && strings.HasSuffix(part, "]") {
-			name := strings.Trim(part, "[]")
-			args = append(args, ArgDescriptor{
-				Name: name,
-				Type: "string",
-			})
-		}
-	}
 	return args
 }
 
-// extractCommand builds a CommandDescriptor from a single Cobra command.
-func extractCommand(cmd *cobra.Command, name string, ann *CommandAnnotation) CommandDescriptor {
+// extractCommand builds a CommandDescriptor from a single Cobra command
+// and the flags collectFlags returned for it.
+func extractCommand(cmd *cobra.Command, name string, ann *CommandAnnotation, flags []*pflag.Flag) CommandDescriptor {
 	desc := strings.TrimSpace(cmd.Short)
 	if desc == "" {
 		desc = strings.TrimSpace(cmd.Long)
@@ -145,58 +219,226 @@ func extractCommand(cmd *cobra.Command, name string, ann *CommandAnnotation) Com
 	// Positional args: annotation overrides Use string parsing.
 	if ann != nil && len(ann.Args) > 0 {
 		cd.Args = append(cd.Args, ann.Args...)
+		for i := range cd.Args {
+			if cd.Args[i].Sensitive {
+				cd.Args[i].Default = nil
+			}
+		}
 	} else {
-		cd.Args = append(cd.Args, parseUseArgs(cmd.Use)...)
+		cd.Args = parseUseArgs(cmd.Use)
 	}
 
 	// Flags
-	cd.Args = append(cd.Args, extractFlags(cmd, ann)...)
+	cd.Args = extractFlags(cd.Args, flags, ann)
 
 	// Annotation-only fields
 	if ann != nil {
+		cd.WhenToUse = ann.WhenToUse
+		cd.CommonMistakes = ann.CommonMistakes
+		cd.SeeAlso = ann.SeeAlso
 		cd.Stdin = ann.Stdin
 		cd.Stdout = ann.Stdout
-		cd.Examples = ann.Examples
+		cd.Examples = slices.Clone(ann.Examples) // Loaded and redacted once the path is known
 		cd.Auth = ann.Auth
+		cd.MayElicit = ann.MayElicit
+		cd.Endpoints = ann.Endpoints
+		cd.Filesystem = ann.Filesystem
+		cd.Capabilities = annotationCapabilities(ann)
+		cd.Cost = ann.Cost
+		cd.Locks = ann.Locks
+		cd.Requires = ann.Requires
+		cd.Version = ann.Version
+		cd.Stability = ann.Stability
+	}
+	if cd.Stability == "" {
+		cd.Stability = groupStability(cmd)
+	}
+
+	var dep *Deprecation
+	if ann != nil {
+		dep = ann.Deprecated
 	}
+	cd.Deprecated = deprecation(cmd.Deprecated, dep)
 
 	return cd
 }
 
+// deprecation merges Cobra's deprecation message with annotated metadata.
+// It returns nil when neither marks the command or flag deprecated.
+func deprecation(message string, ann *Deprecation) *Deprecation {
+	if ann == nil {
+		if message == "" {
+			return nil
+		}
+		return &Deprecation{Message: message}
+	}
+	d := *ann
+	if d.Message == "" {
+		d.Message = message
+	}
+	return &d
+}
+
+// stabilityLevels are the Stability* constants, least mature first.
+var stabilityLevels = []string{StabilityAlpha, StabilityBeta, StabilityStable}
+
+// groupStability infers a command's stability from the Cobra group it's in:
+// a group whose ID or title has "alpha", "beta", or "stable" as a word
+// (e.g. "Beta Commands:") marks its commands that level. It returns "" when
+// there's no such group.
+func groupStability(cmd *cobra.Command) string {
+	if cmd.GroupID == "" || cmd.Parent() == nil {
+		return ""
+	}
+	for _, g := range cmd.Parent().Groups() {
+		if g.ID != cmd.GroupID {
+			continue
+		}
+		words := strings.FieldsFunc(strings.ToLower(g.ID+" "+g.Title), func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})
+		for _, level := range stabilityLevels {
+			if slices.Contains(words, level) {
+				return level
+			}
+		}
+	}
+	return ""
+}
+
 // skippedCommands are auto-generated commands that should be excluded.
 var skippedCommands = map[string]bool{
 	"help":       true,
 	"completion": true,
 }
 
+// rootName returns the schema name of the root command.
+func rootName(opts *DescribeOptions) string {
+	if opts != nil && opts.RootName != "" {
+		return opts.RootName
+	}
+	return "_root"
+}
+
+// isRootCommand reports whether cmd is the root of the tool named tool: its
+// Path is empty or, for schemas without paths, its name is "_root" or the
+// tool's name.
+func isRootCommand(tool string, cmd CommandDescriptor) bool {
+	if cmd.Path != nil {
+		return len(cmd.Path) == 0
+	}
+	return cmd.Name == "_root" || cmd.Name == tool
+}
+
+// commandPath returns cmd's Path, deriving it from the name for schemas
+// written before paths were emitted.
+func commandPath(tool string, cmd CommandDescriptor) []string {
+	if cmd.Path != nil {
+		return cmd.Path
+	}
+	if isRootCommand(tool, cmd) {
+		return []string{}
+	}
+	return strings.Fields(cmd.Name)
+}
+
 // walkCommands recursively extracts CommandDescriptors from a Cobra command tree.
 func walkCommands(cmd *cobra.Command, prefix string, opts *DescribeOptions) []CommandDescriptor {
 	var commands []CommandDescriptor
+	visitCommands(cmd, prefix, opts, func(cd CommandDescriptor) error {
+		commands = append(commands, cd)
+		return nil
+	})
+	return commands
+}
+
+// visitCommands extracts CommandDescriptors in schema order and passes each
+// to emit as soon as it is built. It stops at the first error from emit.
+func visitCommands(cmd *cobra.Command, prefix string, opts *DescribeOptions, emit func(CommandDescriptor) error) error {
+	name := prefix
+	if name == "" {
+		name = rootName(opts)
+	}
+	ann := lookupAnnotation(cmd, name, opts)
+	extract := func() CommandDescriptor {
+		flags, _ := collectFlags(cmd)
+		cd := extractCommand(cmd, name, ann, flags)
+		cd.Path = strings.Fields(prefix)
+		resolveFiles(&cd, opts)
+		cd.Examples = redactExamples(cd.Examples, newCommandRedaction(flags, &cd))
+		identifySchemas(&cd, cmd.Root().Name())
+		return cd
+	}
 
 	visible := visibleSubcommands(cmd)
 	if len(visible) == 0 {
 		// Leaf command (or single-command tool)
-		name := prefix
-		if name == "" {
-			name = "_root"
+		return emit(extract())
+	}
+
+	// A parent that runs on its own (e.g. "tool status" alongside
+	// "tool status watch") is a command in its own right.
+	if isRunnable(cmd) {
+		if err := emit(extract()); err != nil {
+			return err
 		}
-		var ann *CommandAnnotation
-		if opts != nil && opts.Commands != nil {
-			ann = opts.Commands[name]
+	}
+
+	if opts != nil && opts.SortCommands != "" {
+		sortCommands(cmd, visible, opts.SortCommands)
+	}
+
+	// Fan out once, at the top: each top-level subtree is one job.
+	if prefix == "" && opts != nil && opts.Parallelism > 1 {
+		for _, cd := range walkParallel(cmd, visible, prefix, opts) {
+			if err := emit(cd); err != nil {
+				return err
+			}
 		}
-		commands = append(commands, extractCommand(cmd, name, ann))
-		return commands
+		return nil
 	}
 
 	for _, sub := range visible {
-		subName := sub.Name()
-		if prefix != "" {
-			subName = prefix + " " + sub.Name()
+		if err := visitCommands(sub, childName(prefix, sub), opts, emit); err != nil {
+			return err
 		}
-		commands = append(commands, walkCommands(sub, subName, opts)...)
 	}
+	return nil
+}
 
-	return commands
+// sortCommands orders the visible subcommands of parent in place.
+func sortCommands(parent *cobra.Command, cmds []*cobra.Command, order CommandOrder) {
+	groupRank := map[string]int{}
+	for i, g := range parent.Groups() {
+		groupRank[g.ID] = i
+	}
+	rank := func(c *cobra.Command) int {
+		if r, ok := groupRank[c.GroupID]; ok {
+			return r
+		}
+		return len(groupRank)
+	}
+
+	sort.SliceStable(cmds, func(i, j int) bool {
+		if order == SortByGroup {
+			if ri, rj := rank(cmds[i]), rank(cmds[j]); ri != rj {
+				return ri < rj
+			}
+		}
+		return cmds[i].Name() < cmds[j].Name()
+	})
+}
+
+// syntheticRunE records root commands whose RunE was installed by
+// WithDescribe only to make --mtp-describe work; they aren't really runnable.
+var syntheticRunE sync.Map
+
+// isRunnable reports whether cmd does something itself when invoked.
+func isRunnable(cmd *cobra.Command) bool {
+	if _, ok := syntheticRunE.Load(cmd); ok {
+		return false
+	}
+	return cmd.Runnable()
 }
 
 // visibleSubcommands returns non-hidden, non-skipped subcommands.
@@ -0,0 +1,273 @@
+package mtp
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect of IO schemas, declared as
+// their "$schema".
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// identifySchemas declares the dialect and an identifier on cd's stdin and
+// stdout schemas, so JSON Schema tooling can treat each as a standalone
+// document. The $id is a URN naming the tool, command path, and stream
+// (e.g. "urn:mtp:img:convert:stdout"). Values the annotation set are kept.
+//
+// A schema that refers to the tool's shared definitions gets no $id: a
+// "#/definitions/..." reference would then resolve against the URN, where
+// it doesn't exist. ToolSchema.ResolveRefs makes such a schema standalone.
+func identifySchemas(cd *CommandDescriptor, tool string) {
+	words := []string{"urn", "mtp", url.PathEscape(tool)}
+	for _, w := range cd.Path {
+		words = append(words, url.PathEscape(w))
+	}
+	cd.Stdin = identifySchema(cd.Stdin, strings.Join(append(words, "stdin"), ":"))
+	cd.Stdout = identifySchema(cd.Stdout, strings.Join(append(words, "stdout"), ":"))
+}
+
+// identifySchema returns d with $schema and $id set on its Schema. d and
+// its Schema are shared with the caller's annotation, so both are copied.
+func identifySchema(d *IODescriptor, id string) *IODescriptor {
+	if d == nil || len(d.Schema) == 0 {
+		return d
+	}
+	_, hasDraft := d.Schema["$schema"]
+	_, hasID := d.Schema["$id"]
+	if hasDraft && hasID {
+		return d
+	}
+	schema := maps.Clone(d.Schema)
+	if !hasDraft {
+		schema["$schema"] = JSONSchemaDraft
+	}
+	if !hasID && !refersToDefinitions(schema) {
+		schema["$id"] = id
+	}
+	identified := *d
+	identified.Schema = schema
+	return &identified
+}
+
+// refersToDefinitions reports whether schema has a $ref into the tool's
+// shared definitions.
+func refersToDefinitions(schema map[string]any) bool {
+	found := false
+	definitionRefs(schema, func(string) { found = true })
+	return found
+}
+
+// jsonTypes are the values of the "type" keyword.
+var jsonTypes = []string{"array", "boolean", "integer", "null", "number", "object", "string"}
+
+// Keywords by the kind of value they take.
+var (
+	schemaKeywords     = []string{"items", "additionalProperties", "additionalItems", "not", "contains", "propertyNames", "if", "then", "else", "unevaluatedProperties", "unevaluatedItems"}
+	schemaListKeywords = []string{"allOf", "anyOf", "oneOf", "prefixItems"}
+	schemaMapKeywords  = []string{"properties", "patternProperties", "$defs", "definitions", "dependentSchemas"}
+	countKeywords      = []string{"minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties", "minContains", "maxContains"}
+	numberKeywords     = []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf"}
+	stringKeywords     = []string{"$ref", "$anchor", "$comment", "title", "description", "format", "contentMediaType", "contentEncoding"}
+	booleanKeywords    = []string{"uniqueItems", "readOnly", "writeOnly", "deprecated"}
+)
+
+// validateJSONSchema checks that schema, one of a command's IO schemas or
+// a tool definition, is itself a valid draft 2020-12 JSON Schema: each
+// keyword it uses has a value of the right kind, down through every
+// subschema. Keywords it doesn't know are left alone, as JSON Schema
+// allows. path locates the schema in the tool schema.
+func validateJSONSchema(path string, schema map[string]any) []Diagnostic {
+	if schema == nil {
+		return nil
+	}
+	// Round-trip through JSON, so Go-typed values ([]string, int) are
+	// checked as the JSON they encode to.
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return []Diagnostic{{Severity: SeverityError, Path: path, Message: fmt.Sprintf("schema doesn't encode as JSON: %v", err)}}
+	}
+	doc, err := decodeJSON(data)
+	if err != nil {
+		return []Diagnostic{{Severity: SeverityError, Path: path, Message: err.Error()}}
+	}
+
+	var diags []Diagnostic
+	obj := doc.(map[string]any)
+	if draft, ok := obj["$schema"].(string); ok && strings.TrimSuffix(draft, "#") != JSONSchemaDraft {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Path:     path + ".$schema",
+			Message:  fmt.Sprintf("$schema %q is not draft 2020-12, which IO schemas use", draft),
+		})
+	}
+	var c schemaChecker
+	c.check(path, obj)
+	return append(diags, c.diags...)
+}
+
+// schemaChecker collects the problems validateJSONSchema finds.
+type schemaChecker struct {
+	diags []Diagnostic
+}
+
+func (c *schemaChecker) errorf(path, format string, args ...any) {
+	c.diags = append(c.diags, Diagnostic{Severity: SeverityError, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// check checks one schema, which JSON Schema allows to be a boolean.
+func (c *schemaChecker) check(path string, v any) {
+	if _, ok := v.(bool); ok {
+		return
+	}
+	schema, ok := v.(map[string]any)
+	if !ok {
+		c.errorf(path, "a schema must be an object or a boolean, got %s", jsonType(v))
+		return
+	}
+
+	for _, k := range sortedKeys(schema) {
+		value, p := schema[k], joinPath(path, k)
+		switch {
+		case k == "type":
+			c.checkType(p, value)
+		case k == "enum":
+			if _, ok := value.([]any); !ok {
+				c.errorf(p, "enum must be an array, got %s", jsonType(value))
+			}
+		case k == "$schema" || k == "$id":
+			c.checkURI(p, k, value)
+		case k == "pattern":
+			c.checkPattern(p, value)
+		case slices.Contains(schemaKeywords, k):
+			c.check(p, value)
+		case slices.Contains(schemaListKeywords, k):
+			list, ok := value.([]any)
+			if !ok || len(list) == 0 {
+				c.errorf(p, "%s must be a non-empty array of schemas", k)
+				continue
+			}
+			for i, sub := range list {
+				c.check(p+"["+strconv.Itoa(i)+"]", sub)
+			}
+		case slices.Contains(schemaMapKeywords, k):
+			m, ok := value.(map[string]any)
+			if !ok {
+				c.errorf(p, "%s must be an object of schemas, got %s", k, jsonType(value))
+				continue
+			}
+			for _, name := range sortedKeys(m) {
+				if k == "patternProperties" {
+					c.checkPattern(joinPath(p, name), name)
+				}
+				c.check(joinPath(p, name), m[name])
+			}
+		case slices.Contains(countKeywords, k):
+			if n, ok := value.(json.Number); !ok || !isCount(n) {
+				c.errorf(p, "%s must be a non-negative integer, got %s", k, mustJSON(value))
+			}
+		case slices.Contains(numberKeywords, k):
+			n, ok := value.(json.Number)
+			if !ok {
+				c.errorf(p, "%s must be a number, got %s", k, jsonType(value))
+			} else if f, _ := n.Float64(); k == "multipleOf" && f <= 0 {
+				c.errorf(p, "multipleOf must be greater than 0, got %s", n)
+			}
+		case slices.Contains(stringKeywords, k):
+			if _, ok := value.(string); !ok {
+				c.errorf(p, "%s must be a string, got %s", k, jsonType(value))
+			}
+		case slices.Contains(booleanKeywords, k):
+			if _, ok := value.(bool); !ok {
+				c.errorf(p, "%s must be a boolean, got %s", k, jsonType(value))
+			}
+		case k == "required":
+			c.checkStrings(p, k, value)
+		}
+	}
+}
+
+func (c *schemaChecker) checkType(path string, v any) {
+	types, ok := v.([]any)
+	if !ok {
+		types = []any{v}
+	} else if len(types) == 0 {
+		c.errorf(path, "type must not be an empty array")
+	}
+	seen := map[string]bool{}
+	for _, t := range types {
+		s, ok := t.(string)
+		switch {
+		case !ok || !slices.Contains(jsonTypes, s):
+			c.errorf(path, "unknown type %s; use one of %s", mustJSON(t), strings.Join(jsonTypes, ", "))
+		case seen[s]:
+			c.errorf(path, "type %q is listed more than once", s)
+		}
+		seen[s] = true
+	}
+}
+
+func (c *schemaChecker) checkStrings(path, keyword string, v any) {
+	list, ok := v.([]any)
+	if !ok {
+		c.errorf(path, "%s must be an array of strings, got %s", keyword, jsonType(v))
+		return
+	}
+	seen := map[string]bool{}
+	for i, item := range list {
+		s, ok := item.(string)
+		switch {
+		case !ok:
+			c.errorf(path+"["+strconv.Itoa(i)+"]", "%s entries must be strings, got %s", keyword, jsonType(item))
+		case seen[s]:
+			c.errorf(path+"["+strconv.Itoa(i)+"]", "%q is listed more than once", s)
+		}
+		seen[s] = true
+	}
+}
+
+// checkURI checks $schema and $id, which must be absolute URIs. An $id
+// may carry at most an empty fragment.
+func (c *schemaChecker) checkURI(path, keyword string, v any) {
+	s, ok := v.(string)
+	if !ok {
+		c.errorf(path, "%s must be a string, got %s", keyword, jsonType(v))
+		return
+	}
+	u, err := url.Parse(s)
+	switch {
+	case err != nil || !u.IsAbs():
+		c.errorf(path, "%s %q is not an absolute URI", keyword, s)
+	case keyword == "$id" && u.Fragment != "":
+		c.errorf(path, "$id %q must not have a fragment", s)
+	}
+}
+
+// checkPattern reports a pattern Go can't compile. JSON Schema patterns
+// are ECMA-262 regular expressions, so this is only a warning: the pattern
+// may use syntax RE2 lacks, such as lookahead.
+func (c *schemaChecker) checkPattern(path string, v any) {
+	s, ok := v.(string)
+	if !ok {
+		c.errorf(path, "pattern must be a string, got %s", jsonType(v))
+		return
+	}
+	if _, err := regexp.Compile(s); err != nil {
+		c.diags = append(c.diags, Diagnostic{
+			Severity: SeverityWarning,
+			Path:     path,
+			Message:  fmt.Sprintf("pattern %q doesn't compile as RE2: %v", s, err),
+		})
+	}
+}
+
+// isCount reports whether n is a non-negative integer.
+func isCount(n json.Number) bool {
+	i, err := strconv.ParseInt(n.String(), 10, 64)
+	return err == nil && i >= 0
+}
@@ -0,0 +1,128 @@
+package mtp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// LangChainTool describes one command as a LangChain StructuredTool.
+type LangChainTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	ArgsSchema  map[string]any `json:"args_schema"` // JSON Schema for the tool's keyword arguments
+	// Invocation is Python source that defines the tool. It calls
+	// _mtp_run, which LangChainModule defines.
+	Invocation string `json:"invocation"`
+}
+
+// ToLangChainTools converts each command in schema to a LangChain tool
+// definition. Use LangChainModule for a ready-to-import Python file.
+func ToLangChainTools(schema *ToolSchema) []LangChainTool {
+	tools := make([]LangChainTool, 0, len(schema.Commands))
+	names := exportNames(schema)
+	for i, cmd := range schema.Commands {
+		name := names[i]
+		desc := cmd.Description
+		if desc == "" {
+			desc = schema.Description
+		}
+		desc = commandDescription(desc, cmd)
+		argsSchema := argsJSONSchema(cmd)
+
+		positionals := []string{}
+		for _, arg := range cmd.Args {
+			if !isFlag(arg) {
+				positionals = append(positionals, argKey(arg))
+			}
+		}
+
+		tools = append(tools, LangChainTool{
+			Name:        name,
+			Description: desc,
+			ArgsSchema:  argsSchema,
+			Invocation: fmt.Sprintf(`%s = StructuredTool.from_function(
+    func=lambda **kwargs: _mtp_run(%s, %s, kwargs),
+    name=%s,
+    description=%s,
+    args_schema=json.loads(%s),
+)
+`, name, pyLiteral(commandArgv(schema.Name, cmd)), pyLiteral(positionals),
+				pyLiteral(name), pyLiteral(desc), pyLiteral(mustJSON(argsSchema))),
+		})
+	}
+	return tools
+}
+
+// langChainPrelude is the Python shared by every generated tool. Positionals
+// are passed in order (lists are spread for variadics); flags become
+// --name value, with True booleans as bare --name and lists repeated.
+const langChainPrelude = `import json
+import subprocess
+
+from langchain_core.tools import StructuredTool
+
+
+def _mtp_run(argv, positionals, kwargs):
+    argv = list(argv)
+    for name in positionals:
+        value = kwargs.get(name)
+        if value is None:
+            continue
+        if isinstance(value, list):
+            argv.extend(str(v) for v in value)
+        else:
+            argv.append(str(value))
+    for name, value in kwargs.items():
+        if name in positionals or value is None or value is False:
+            continue
+        if value is True:
+            argv.append("--" + name)
+        elif isinstance(value, list):
+            for v in value:
+                argv.extend(["--" + name, str(v)])
+        else:
+            argv.extend(["--" + name, str(value)])
+    result = subprocess.run(argv, capture_output=True, text=True)
+    if result.returncode != 0:
+        raise RuntimeError(result.stderr or "exit status %d" % result.returncode)
+    return result.stdout
+`
+
+// LangChainModule returns a Python module defining a StructuredTool for
+// every command in schema, plus a TOOLS list to hand to an agent:
+//
+//	mtp.LangChainModule(schema) // save as mytool_tools.py
+//
+//	from mytool_tools import TOOLS
+//	agent = create_react_agent(llm, TOOLS)
+func LangChainModule(schema *ToolSchema) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated from the MTP schema for %s %s. Do not edit.\n", schema.Name, schema.Version)
+	b.WriteString(langChainPrelude)
+
+	tools := ToLangChainTools(schema)
+	names := make([]string, len(tools))
+	for i, t := range tools {
+		b.WriteString("\n\n")
+		b.WriteString(t.Invocation)
+		names[i] = t.Name
+	}
+	fmt.Fprintf(&b, "\n\nTOOLS = [%s]\n", strings.Join(names, ", "))
+	return b.String()
+}
+
+// pyLiteral renders a string or []string as a Python literal. JSON string
+// syntax is a subset of Python's.
+func pyLiteral(v any) string {
+	return mustJSON(v)
+}
+
+// mustJSON encodes v, which must be JSON-safe.
+func mustJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
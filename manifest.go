@@ -0,0 +1,113 @@
+package mtp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Manifests are schema files written ahead of time, so clients can discover
+// a tool without executing it. A repository publishes its schema at
+// RepoManifestPath; an installed tool puts it in the XDG data directory at
+// mtp/tools/<name>.json (see InstalledManifestPath).
+
+// RepoManifestPath is where a repository publishes its tool's schema,
+// relative to the repository root.
+const RepoManifestPath = ".well-known/mtp/tool.json"
+
+// ErrManifestNotFound is returned by FindManifest when no installed
+// manifest exists for a tool.
+var ErrManifestNotFound = errors.New("mtp: manifest not found")
+
+// InstalledManifestPath returns where an installed tool's manifest lives:
+// $XDG_DATA_HOME/mtp/tools/<name>.json, with XDG_DATA_HOME defaulting to
+// ~/.local/share.
+func InstalledManifestPath(name string) (string, error) {
+	if err := checkManifestName(name); err != nil {
+		return "", err
+	}
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "mtp", "tools", name+".json"), nil
+}
+
+// ManifestDirs returns the directories searched for installed manifests,
+// most specific first: $XDG_DATA_HOME, then each of $XDG_DATA_DIRS
+// (default /usr/local/share:/usr/share).
+func ManifestDirs() []string {
+	var dirs []string
+	if p, err := InstalledManifestPath("x"); err == nil {
+		dirs = append(dirs, filepath.Dir(p))
+	}
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+	for _, d := range filepath.SplitList(dataDirs) {
+		if d != "" {
+			dirs = append(dirs, filepath.Join(d, "mtp", "tools"))
+		}
+	}
+	return dirs
+}
+
+// WriteManifest writes schema to path as indented JSON, creating parent
+// directories as needed.
+func WriteManifest(path string, schema *ToolSchema) error {
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// LoadManifest reads a manifest file. Unknown fields are preserved.
+func LoadManifest(path string) (*ToolSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := ParseSchema(data, ParseOptions{PreserveUnknown: true})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// FindManifest loads the installed manifest for the named tool, searching
+// the XDG data directories in order. It returns the path it loaded.
+func FindManifest(name string) (*ToolSchema, string, error) {
+	if err := checkManifestName(name); err != nil {
+		return nil, "", err
+	}
+	for _, dir := range ManifestDirs() {
+		path := filepath.Join(dir, name+".json")
+		schema, err := LoadManifest(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return schema, path, err
+	}
+	return nil, "", fmt.Errorf("%w: %s", ErrManifestNotFound, name)
+}
+
+// checkManifestName rejects tool names that can't be used as a file name.
+func checkManifestName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid tool name %q", name)
+	}
+	return nil
+}
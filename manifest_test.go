@@ -0,0 +1,61 @@
+package mtp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ── Manifest tests ───────────────────────────────────────────────────
+
+func TestInstalledManifestRoundTrip(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", home)
+	t.Setenv("XDG_DATA_DIRS", filepath.Join(t.TempDir(), "none"))
+
+	schema := Describe(newLargeTree(1, 1, 1, 1), nil)
+	path, err := InstalledManifestPath(schema.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(home, "mtp", "tools", "cloud.json") {
+		t.Errorf("unexpected path: %s", path)
+	}
+	if err := WriteManifest(path, schema); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	got, from, err := FindManifest("cloud")
+	if err != nil {
+		t.Fatalf("find failed: %v", err)
+	}
+	if from != path || got.Name != "cloud" || len(got.Commands) != 1 {
+		t.Errorf("unexpected manifest from %s: %+v", from, got)
+	}
+}
+
+func TestFindManifestSearchesDataDirs(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	system := t.TempDir()
+	t.Setenv("XDG_DATA_DIRS", filepath.Join(t.TempDir(), "empty")+string(os.PathListSeparator)+system)
+
+	path := filepath.Join(system, "mtp", "tools", "cloud.json")
+	if err := WriteManifest(path, &ToolSchema{Name: "cloud"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, from, err := FindManifest("cloud"); err != nil || from != path {
+		t.Errorf("expected manifest from %s, got %s (%v)", path, from, err)
+	}
+	if _, _, err := FindManifest("other"); !errors.Is(err, ErrManifestNotFound) {
+		t.Errorf("expected ErrManifestNotFound, got %v", err)
+	}
+}
+
+func TestManifestNameRejectsPaths(t *testing.T) {
+	for _, name := range []string{"", "..", "../etc/passwd", `a\b`} {
+		if _, err := InstalledManifestPath(name); err == nil {
+			t.Errorf("expected error for %q", name)
+		}
+	}
+}
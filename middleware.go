@@ -0,0 +1,99 @@
+package mtp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// Call is one invocation of a command added with Register, as middleware
+// sees it.
+type Call struct {
+	Command *cobra.Command
+	Args    []string // Positional arguments
+	Input   any      // The handler's input, already bound; a value of its In type
+}
+
+// HandlerFunc is a registered handler with its types erased, the form
+// middleware wraps. It returns the value written to stdout, or nil for
+// nothing.
+type HandlerFunc func(ctx context.Context, call *Call) (any, error)
+
+// Middleware wraps a handler to implement a cross-cutting concern, such
+// as auth checks, extra validation, logging, or recovering from panics,
+// once for every command rather than in each one:
+//
+//	func logCalls(next mtp.HandlerFunc) mtp.HandlerFunc {
+//		return func(ctx context.Context, call *mtp.Call) (any, error) {
+//			start := time.Now()
+//			out, err := next(ctx, call)
+//			log.Printf("%s took %s: %v", call.Command.CommandPath(), time.Since(start), err)
+//			return out, err
+//		}
+//	}
+//
+// A middleware can change the context or the input before calling next,
+// or return an error without calling it at all.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// toolMiddleware holds the middleware added with Use, by root command,
+// outermost first.
+var toolMiddleware struct {
+	sync.RWMutex
+	m map[*cobra.Command][]Middleware
+}
+
+// Use adds middleware around the handler of every command registered in
+// root's tree, whenever the command was registered. The first one given is
+// the outermost, and the tool's middleware wraps that of HandlerMeta:
+//
+//	mtp.Use(root, mtp.Recover, requireAuth, logCalls)
+func Use(root *cobra.Command, mw ...Middleware) {
+	toolMiddleware.Lock()
+	defer toolMiddleware.Unlock()
+	if toolMiddleware.m == nil {
+		toolMiddleware.m = map[*cobra.Command][]Middleware{}
+	}
+	toolMiddleware.m[root] = append(toolMiddleware.m[root], mw...)
+}
+
+// chain wraps h in the middleware of root's tool and then local, so the
+// tool's first one runs first.
+func chain(h HandlerFunc, root *cobra.Command, local []Middleware) HandlerFunc {
+	toolMiddleware.RLock()
+	all := append(slices.Clone(toolMiddleware.m[root]), local...)
+	toolMiddleware.RUnlock()
+	for i := len(all) - 1; i >= 0; i-- {
+		h = all[i](h)
+	}
+	return h
+}
+
+// Internal is the error code Recover reports. Add it to
+// DescribeOptions.ErrorCodes so it's listed in the schema.
+var Internal = ErrorCode{
+	Code:        "INTERNAL",
+	Description: "The tool hit a bug; retrying won't help, so report it",
+}
+
+// Recover is middleware that turns a panic in the handler into an
+// *Error with the code INTERNAL, so the command fails like any other
+// rather than crashing with a stack trace.
+func Recover(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, call *Call) (out any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				cause, _ := r.(error)
+				out, err = nil, &Error{
+					Code:    Internal.Code,
+					Message: fmt.Sprintf("%s panicked: %v", call.Command.CommandPath(), r),
+					Err:     cause,
+				}
+			}
+		}()
+		return next(ctx, call)
+	}
+}
@@ -0,0 +1,89 @@
+package mtp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestMiddleware(t *testing.T) {
+	var trace []string
+	tracing := func(label string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, call *Call) (any, error) {
+				trace = append(trace, label+" "+call.Command.Name())
+				return next(ctx, call)
+			}
+		}
+	}
+	denyPNG := func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, call *Call) (any, error) {
+			if call.Input.(resizeInput).Format == "png" {
+				return nil, errors.New("png is off limits")
+			}
+			return next(ctx, call)
+		}
+	}
+	double := func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, call *Call) (any, error) {
+			in := call.Input.(resizeInput)
+			in.Width *= 2
+			call.Input = in
+			return next(ctx, call)
+		}
+	}
+
+	root := &cobra.Command{Use: "img", SilenceErrors: true, SilenceUsage: true}
+	Register(root, "resize", resize, HandlerMeta{Middleware: []Middleware{tracing("local"), denyPNG, double}})
+	Use(root, tracing("outer"), tracing("inner")) // Added after Register, and still applied
+	run := func(args ...string) (string, error) {
+		var stdout bytes.Buffer
+		root.SetOut(&stdout)
+		root.SetArgs(args)
+		err := root.Execute()
+		return strings.TrimSpace(stdout.String()), err
+	}
+
+	out, err := run("resize", "cat.jpg", "--width", "64", "--format", "webp")
+	if err != nil || out != `{"path":"cat.webp","width":128}` {
+		t.Errorf("got %s, %v", out, err)
+	}
+	if want := []string{"outer resize", "inner resize", "local resize"}; !slices.Equal(trace, want) {
+		t.Errorf("trace %q, want %q", trace, want)
+	}
+
+	out, err = run("resize", "cat.jpg", "--width", "64", "--format", "png")
+	if err == nil || err.Error() != "png is off limits" || out != "" {
+		t.Errorf("got %s, %v", out, err)
+	}
+
+	trace = nil
+	other := &cobra.Command{Use: "other"}
+	Register(other, "resize", resize, HandlerMeta{})
+	other.SetOut(&bytes.Buffer{})
+	other.SetArgs([]string{"resize", "cat.jpg", "--width", "64"})
+	if err := other.Execute(); err != nil || trace != nil {
+		t.Errorf("another tool ran %q, %v", trace, err)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	boom := errors.New("boom")
+	root := &cobra.Command{Use: "tool", SilenceErrors: true, SilenceUsage: true}
+	Use(root, Recover)
+	Register(root, "crash", func(context.Context, struct{}) (struct{}, error) {
+		panic(boom)
+	}, HandlerMeta{})
+	root.SetArgs([]string{"crash"})
+
+	err := root.Execute()
+	var mtpErr *Error
+	if !errors.As(err, &mtpErr) || mtpErr.Code != "INTERNAL" || mtpErr.Message != "tool crash panicked: boom" || !errors.Is(err, boom) {
+		t.Errorf("got %#v", err)
+	}
+}
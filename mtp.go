@@ -1,12 +1,17 @@
 package mtp
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // MTPSpecVersion is the version of the MTP specification implemented by this SDK.
@@ -16,6 +21,21 @@ const MTPSpecVersion = "2026-02-07"
 // This is a pure function with no side effects, useful for testing
 // or programmatic access to the schema.
 func Describe(root *cobra.Command, opts *DescribeOptions) *ToolSchema {
+	schema := describeTool(root, opts)
+	schema.Commands = walkCommands(root, "", opts)
+	for i := range schema.Commands {
+		sanitizeCommand(&schema.Commands[i], maxDescriptionLen(opts))
+	}
+
+	if opts != nil && opts.MaxBytes > 0 {
+		trimSchema(schema, opts.MaxBytes)
+	}
+
+	return schema
+}
+
+// describeTool builds the tool-level part of the schema, without commands.
+func describeTool(root *cobra.Command, opts *DescribeOptions) *ToolSchema {
 	desc := strings.TrimSpace(root.Short)
 	if desc == "" {
 		desc = strings.TrimSpace(root.Long)
@@ -25,21 +45,83 @@ func Describe(root *cobra.Command, opts *DescribeOptions) *ToolSchema {
 		SpecVersion: MTPSpecVersion,
 		Name:        root.Name(),
 		Version:     root.Version,
-		Description: desc,
-		Commands:    walkCommands(root, "", opts),
+		Description: sanitizeText(desc, maxDescriptionLen(opts)),
 	}
 
 	if opts != nil && opts.Auth != nil {
 		schema.Auth = opts.Auth
 	}
-
+	if opts != nil && opts.Requires != nil {
+		schema.Requires = opts.Requires
+	}
+	if opts != nil && opts.Install != nil {
+		schema.Install = opts.Install
+	}
+	if opts != nil && len(opts.Changelog) > 0 {
+		schema.Changelog = sanitizeChangelog(opts.Changelog, maxDescriptionLen(opts))
+	}
+	if opts != nil && len(opts.ErrorCodes) > 0 {
+		schema.ErrorCodes = slices.Clone(opts.ErrorCodes)
+	}
+	if opts != nil && len(opts.Definitions) > 0 {
+		schema.Definitions = maps.Clone(opts.Definitions)
+	}
 	return schema
 }
 
+// maxDescriptionLen returns the description cap from opts, or 0 for none.
+func maxDescriptionLen(opts *DescribeOptions) int {
+	if opts == nil {
+		return 0
+	}
+	return opts.MaxDescriptionLength
+}
+
 // WithDescribe adds a --describe flag to the root command.
 // When --describe is passed, it prints the JSON schema to stdout and exits 0.
+// It also adds --mtp-check, which verifies declared requirements and prints
+// a CheckReport, exiting 1 if any requirement is unmet, and --mtp-env, which
+// selects a deployment environment (see AuthConfig.Environments).
 func WithDescribe(root *cobra.Command, opts *DescribeOptions) {
-	var describeFlag bool
+	installFlags(root,
+		func(cmd *cobra.Command) int { return runDescribe(cmd, root, opts, os.Stdout, os.Stderr) },
+		func() (*ToolSchema, error) { return Describe(root, opts), nil },
+	)
+}
+
+// WithEmbeddedSchema is WithDescribe for a schema computed at build time,
+// typically with mtpgen schema and //go:embed. --mtp-describe prints schema
+// as-is, without walking the command tree, so it costs nothing at startup
+// even when annotations are large or generated. The bytes are only parsed
+// when --mtp-env or --mtp-check needs them.
+//
+// When LiveSchemaEnvVar is set, as mtpgen sets it, the flags describe the
+// live tree with opts instead, as WithDescribe would, so regenerating the
+// file and mtpgen schema --check see the code rather than the stale copy.
+// Run the check in CI: nothing else notices that schema has drifted.
+func WithEmbeddedSchema(root *cobra.Command, schema []byte, opts *DescribeOptions) {
+	installFlags(root,
+		func(cmd *cobra.Command) int {
+			return runEmbeddedDescribe(cmd, root, schema, opts, os.Stdout, os.Stderr)
+		},
+		func() (*ToolSchema, error) {
+			if os.Getenv(LiveSchemaEnvVar) != "" {
+				return Describe(root, opts), nil
+			}
+			return ParseSchema(schema, ParseOptions{PreserveUnknown: true})
+		},
+	)
+}
+
+// LiveSchemaEnvVar is set by mtpgen when it runs a tool to capture its
+// schema. A tool that serves an embedded schema should describe its live
+// tree instead when it is set, or regenerating would copy the stale file.
+const LiveSchemaEnvVar = "MTP_LIVE_SCHEMA"
+
+// installFlags adds the --mtp-* flags to root. describe runs --mtp-describe
+// and returns the exit code; schema supplies the schema for --mtp-check.
+func installFlags(root *cobra.Command, describe func(cmd *cobra.Command) int, schema func() (*ToolSchema, error)) {
+	var describeFlag, checkFlag bool
 
 	root.PersistentFlags().BoolVar(
 		&describeFlag,
@@ -47,15 +129,31 @@ func WithDescribe(root *cobra.Command, opts *DescribeOptions) {
 		false,
 		"Output machine-readable JSON schema for this tool",
 	)
+	root.PersistentFlags().BoolVar(
+		&checkFlag,
+		"mtp-check",
+		false,
+		"Verify this tool's environment requirements and output a JSON report",
+	)
+	addEnvFlag(root)
 
-	printAndExit := func() {
-		schema := Describe(root, opts)
-		enc := json.NewEncoder(os.Stdout)
-		if err := enc.Encode(schema); err != nil {
-			fmt.Fprintf(os.Stderr, "Error encoding schema: %v\n", err)
-			os.Exit(1)
+	handleFlags := func(cmd *cobra.Command) {
+		switch {
+		case describeFlag:
+			os.Exit(describe(cmd))
+		case checkFlag:
+			s, err := schema()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			report := Check(s)
+			code := 0
+			if !report.OK {
+				code = 1
+			}
+			printJSONAndExit(report, code)
 		}
-		os.Exit(0)
 	}
 
 	// Chain with any existing PersistentPreRunE or PersistentPreRun.
@@ -63,9 +161,7 @@ func WithDescribe(root *cobra.Command, opts *DescribeOptions) {
 	existingPlain := root.PersistentPreRun
 
 	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-		if describeFlag {
-			printAndExit()
-		}
+		handleFlags(cmd)
 
 		if existingE != nil {
 			return existingE(cmd, args)
@@ -82,15 +178,119 @@ func WithDescribe(root *cobra.Command, opts *DescribeOptions) {
 	// shows help instead of executing hooks. Set RunE so --describe works
 	// when invoked on the root command directly (e.g. "tool --describe").
 	if root.RunE == nil && root.Run == nil {
+		syntheticRunE.Store(root, true)
 		root.RunE = func(cmd *cobra.Command, args []string) error {
-			if describeFlag {
-				printAndExit()
-			}
+			handleFlags(cmd)
 			return cmd.Help()
 		}
 	}
 }
 
+// addEnvFlag adds the persistent --mtp-env flag read by Environment to
+// root, unless WithDescribe or WithAuthCommands already has.
+func addEnvFlag(root *cobra.Command) {
+	if root.PersistentFlags().Lookup("mtp-env") != nil {
+		return
+	}
+	root.PersistentFlags().String(
+		"mtp-env",
+		"",
+		"Deployment environment to use (overrides $"+EnvironmentEnvVar+")",
+	)
+}
+
+// introspecting reports whether cmd was invoked with --mtp-describe or
+// --mtp-check, which report on the tool instead of running the command.
+func introspecting(cmd *cobra.Command) bool {
+	for _, name := range []string{"mtp-describe", "mtp-check"} {
+		if set, err := cmd.Flags().GetBool(name); err == nil && set {
+			return true
+		}
+	}
+	return false
+}
+
+// runDescribe validates and prints the schema for --mtp-describe and returns
+// the exit code. Diagnostics go to stderr as a single JSON object so that
+// stdout only ever carries a schema. In strict mode, errors suppress the
+// schema and exit 1.
+func runDescribe(cmd, root *cobra.Command, opts *DescribeOptions, stdout, stderr io.Writer) int {
+	schema := Describe(root, opts)
+	if env := Environment(cmd); env != "" && schema.Auth != nil {
+		auth, err := ResolveAuthEnvironment(schema.Auth, env)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		schema.Auth = auth
+	}
+
+	diags := append(ValidateCommandTree(root, opts), ValidateSchema(schema)...)
+	if len(diags) > 0 {
+		json.NewEncoder(stderr).Encode(struct {
+			Diagnostics []Diagnostic `json:"diagnostics"`
+		}{diags})
+	}
+	if opts != nil && opts.Strict && HasErrors(diags) {
+		return 1
+	}
+
+	if err := json.NewEncoder(stdout).Encode(schema); err != nil {
+		fmt.Fprintf(stderr, "Error encoding JSON: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runEmbeddedDescribe prints a precomputed schema for --mtp-describe and
+// returns the exit code. The schema is only decoded when an environment is
+// selected, to resolve its auth. When LiveSchemaEnvVar is set, root's live
+// tree is described with opts instead.
+func runEmbeddedDescribe(cmd, root *cobra.Command, data []byte, opts *DescribeOptions, stdout, stderr io.Writer) int {
+	if os.Getenv(LiveSchemaEnvVar) != "" {
+		return runDescribe(cmd, root, opts, stdout, stderr)
+	}
+	env := Environment(cmd)
+	if env == "" {
+		if !json.Valid(data) {
+			fmt.Fprintln(stderr, "Error: embedded schema is not valid JSON")
+			return 1
+		}
+		stdout.Write(data)
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			io.WriteString(stdout, "\n")
+		}
+		return 0
+	}
+
+	schema, err := ParseSchema(data, ParseOptions{PreserveUnknown: true})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: embedded schema: %v\n", err)
+		return 1
+	}
+	if schema.Auth != nil {
+		if schema.Auth, err = ResolveAuthEnvironment(schema.Auth, env); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	if err := json.NewEncoder(stdout).Encode(schema); err != nil {
+		fmt.Fprintf(stderr, "Error encoding JSON: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printJSONAndExit writes v to stdout as JSON and exits with code.
+func printJSONAndExit(v any, code int) {
+	enc := json.NewEncoder(os.Stdout)
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(code)
+}
+
 // EnumValues annotates a flag with allowed enum values.
 // Call after adding the flag to the command:
 //
@@ -106,3 +306,108 @@ func EnumValues(cmd *cobra.Command, flagName string, values []string) {
 	}
 	f.Annotations["values"] = values
 }
+
+// Flag annotation keys used by ArgGroup and Advanced.
+const (
+	groupAnnotation    = "mtp_group"
+	advancedAnnotation = "mtp_advanced"
+)
+
+// ArgGroup assigns flags to a named group (e.g. "Output", "Networking"),
+// which clients can use to organize long flag lists.
+//
+//	mtp.ArgGroup(cmd, "Networking", "proxy", "timeout", "retries")
+func ArgGroup(cmd *cobra.Command, group string, flagNames ...string) {
+	for _, name := range flagNames {
+		setFlagAnnotation(cmd, name, groupAnnotation, group)
+	}
+}
+
+// Advanced marks flags as rarely needed, so clients and models can steer
+// toward the command's other flags on commands with many of them.
+func Advanced(cmd *cobra.Command, flagNames ...string) {
+	for _, name := range flagNames {
+		setFlagAnnotation(cmd, name, advancedAnnotation, "true")
+	}
+}
+
+// defaultFromAnnotation is the flag annotation key used by DefaultFrom.
+const defaultFromAnnotation = "mtp_default_from"
+
+// DefaultFrom records where a flag's default comes from, such as an
+// environment variable read at startup:
+//
+//	mtp.DefaultFrom(cmd, "home", "$TOOL_HOME")
+//
+// The schema then reports "defaultFrom": "$TOOL_HOME" and omits the
+// default, which would otherwise be the value resolved on the machine that
+// ran --mtp-describe.
+func DefaultFrom(cmd *cobra.Command, flagName, source string) {
+	setFlagAnnotation(cmd, flagName, defaultFromAnnotation, source)
+}
+
+// sensitiveAnnotation is the flag annotation key used by Sensitive.
+const sensitiveAnnotation = "mtp_sensitive"
+
+// Sensitive marks flags that hold secrets. The schema sets "sensitive" on
+// them, leaves out their defaults, and masks their values in examples; and
+// clients are expected not to log what a model passes for them. Flags named
+// like secrets ("--api-key", "--password") already have their defaults and
+// example values masked; Sensitive covers names the heuristic misses.
+func Sensitive(cmd *cobra.Command, flagNames ...string) {
+	for _, name := range flagNames {
+		setFlagAnnotation(cmd, name, sensitiveAnnotation, "true")
+	}
+}
+
+// unitAnnotation is the flag annotation key used by Unit.
+const unitAnnotation = "mtp_unit"
+
+// Unit records the unit a numeric flag is measured in, such as "seconds",
+// "bytes", "MiB", or "percent", so a model doesn't pass milliseconds to a
+// flag that takes seconds.
+func Unit(cmd *cobra.Command, flagName, unit string) {
+	setFlagAnnotation(cmd, flagName, unitAnnotation, unit)
+}
+
+// contentHintAnnotation is the flag annotation key used by ContentHint.
+const contentHintAnnotation = "mtp_content_hint"
+
+// ContentHint records what kind of text a string flag takes, such as
+// HintSQL for a --query flag, so clients can offer a suitable editor and
+// models write the right syntax.
+func ContentHint(cmd *cobra.Command, flagName, hint string) {
+	setFlagAnnotation(cmd, flagName, contentHintAnnotation, hint)
+}
+
+// styleAnnotation is the flag annotation key used by Style.
+const styleAnnotation = "mtp_style"
+
+// Style records how clients should write an array or boolean flag, one of
+// the Style* constants. Declare StyleComma for a flag that takes one
+// comma-separated value, and StyleExplicit for a boolean that must be
+// given as --flag=true or --flag=false.
+func Style(cmd *cobra.Command, flagName, style string) {
+	setFlagAnnotation(cmd, flagName, styleAnnotation, style)
+}
+
+// setFlagAnnotation stores a single-valued annotation on one of cmd's
+// local or persistent flags. Unknown flags are ignored, as in EnumValues.
+func setFlagAnnotation(cmd *cobra.Command, flagName, key, value string) {
+	f := lookupFlag(cmd, flagName)
+	if f == nil {
+		return
+	}
+	if f.Annotations == nil {
+		f.Annotations = map[string][]string{}
+	}
+	f.Annotations[key] = []string{value}
+}
+
+// lookupFlag finds one of cmd's local or persistent flags.
+func lookupFlag(cmd *cobra.Command, name string) *pflag.Flag {
+	if f := cmd.Flags().Lookup(name); f != nil {
+		return f
+	}
+	return cmd.PersistentFlags().Lookup(name)
+}
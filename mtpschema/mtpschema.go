@@ -0,0 +1,404 @@
+// Package mtpschema builds the JSON Schemas of MTP IO descriptors with
+// typed Go calls instead of nested map[string]any literals:
+//
+//	item := mtpschema.Object().
+//		Prop("name", mtpschema.String().Desc("Item name")).
+//		Prop("count", mtpschema.Integer().Min(0)).
+//		Required("name")
+//
+//	mtp.Annotate("add").StdinJSON(item.Map())
+//
+// Mistakes a literal would carry silently into the schema, such as a
+// minimum on a string or a required property that was never declared,
+// panic while the schema is built, like regexp.MustCompile on a bad
+// pattern, so they surface in the first test that describes the tool.
+package mtpschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+// Schema is a JSON Schema under construction. Its methods set keywords
+// and return the schema, so calls chain; Map compiles it.
+type Schema struct {
+	kind     string         // JSON type; empty for references and unions
+	nullable bool           // Also accepts null
+	keywords map[string]any // Values may hold *Schema and []*Schema, compiled by Map
+	props    []property     // In declaration order
+	required []string
+}
+
+type property struct {
+	name   string
+	schema *Schema
+}
+
+func newSchema(kind string) *Schema {
+	return &Schema{kind: kind, keywords: map[string]any{}}
+}
+
+// Object returns a schema for a JSON object; see Prop and Required.
+func Object() *Schema { return newSchema("object") }
+
+// String returns a schema for a string.
+func String() *Schema { return newSchema("string") }
+
+// Integer returns a schema for an integer.
+func Integer() *Schema { return newSchema("integer") }
+
+// Number returns a schema for any number.
+func Number() *Schema { return newSchema("number") }
+
+// Boolean returns a schema for true or false.
+func Boolean() *Schema { return newSchema("boolean") }
+
+// Array returns a schema for an array whose elements match items.
+func Array(items *Schema) *Schema {
+	s := newSchema("array")
+	s.keywords["items"] = mustSchema("Array", items)
+	return s
+}
+
+// Ref returns a reference to the tool-level definition name (see
+// mtp.DescribeOptions.Define).
+func Ref(name string) *Schema {
+	if name == "" {
+		panic("mtpschema: Ref needs a definition name")
+	}
+	s := newSchema("")
+	s.keywords["$ref"] = mtp.DefinitionRefPrefix + name
+	return s
+}
+
+// OneOf returns a schema matched by values that match exactly one of
+// options.
+func OneOf(options ...*Schema) *Schema { return union("oneOf", options) }
+
+// AnyOf returns a schema matched by values that match at least one of
+// options.
+func AnyOf(options ...*Schema) *Schema { return union("anyOf", options) }
+
+func union(keyword string, options []*Schema) *Schema {
+	if len(options) == 0 {
+		panic("mtpschema: " + keyword + " needs at least one option")
+	}
+	for _, o := range options {
+		mustSchema(keyword, o)
+	}
+	s := newSchema("")
+	s.keywords[keyword] = slices.Clone(options)
+	return s
+}
+
+// Desc sets the description.
+func (s *Schema) Desc(text string) *Schema {
+	s.keywords["description"] = text
+	return s
+}
+
+// Title sets the title.
+func (s *Schema) Title(text string) *Schema {
+	s.keywords["title"] = text
+	return s
+}
+
+// Nullable lets the value also be null.
+func (s *Schema) Nullable() *Schema {
+	s.need("Nullable", "object", "string", "integer", "number", "boolean", "array")
+	s.nullable = true
+	return s
+}
+
+// Default sets the value assumed when the field is absent. It must be of
+// the schema's type.
+func (s *Schema) Default(v any) *Schema {
+	s.keywords["default"] = s.mustFit("Default", v)
+	return s
+}
+
+// Examples adds sample values, each of the schema's type.
+func (s *Schema) Examples(values ...any) *Schema {
+	examples, _ := s.keywords["examples"].([]any)
+	for _, v := range values {
+		examples = append(examples, s.mustFit("Examples", v))
+	}
+	s.keywords["examples"] = examples
+	return s
+}
+
+// Enum limits a string to values.
+func (s *Schema) Enum(values ...string) *Schema {
+	s.need("Enum", "string")
+	if len(values) == 0 {
+		panic("mtpschema: Enum needs at least one value")
+	}
+	enum := make([]any, 0, len(values))
+	for i, v := range values {
+		if slices.Contains(values[:i], v) {
+			panic(fmt.Sprintf("mtpschema: Enum lists %q twice", v))
+		}
+		enum = append(enum, v)
+	}
+	s.keywords["enum"] = enum
+	return s
+}
+
+// Format sets a string's format, such as "date-time", "email", or "uri".
+func (s *Schema) Format(format string) *Schema {
+	s.need("Format", "string")
+	s.keywords["format"] = format
+	return s
+}
+
+// Pattern requires a string to match a regular expression. JSON Schema
+// patterns are ECMA-262; the pattern must also compile in Go, so tools
+// can check their own output.
+func (s *Schema) Pattern(pattern string) *Schema {
+	s.need("Pattern", "string")
+	if _, err := regexp.Compile(pattern); err != nil {
+		panic(fmt.Sprintf("mtpschema: Pattern %q: %v", pattern, err))
+	}
+	s.keywords["pattern"] = pattern
+	return s
+}
+
+// MinLength sets a string's minimum length in characters.
+func (s *Schema) MinLength(n int) *Schema {
+	s.need("MinLength", "string")
+	return s.count("minLength", "maxLength", n)
+}
+
+// MaxLength sets a string's maximum length in characters.
+func (s *Schema) MaxLength(n int) *Schema {
+	s.need("MaxLength", "string")
+	return s.count("maxLength", "minLength", n)
+}
+
+// Min sets a number's inclusive minimum.
+func (s *Schema) Min(n float64) *Schema {
+	s.need("Min", "integer", "number")
+	return s.bound("minimum", "maximum", n)
+}
+
+// Max sets a number's inclusive maximum.
+func (s *Schema) Max(n float64) *Schema {
+	s.need("Max", "integer", "number")
+	return s.bound("maximum", "minimum", n)
+}
+
+// MinItems sets an array's minimum length.
+func (s *Schema) MinItems(n int) *Schema {
+	s.need("MinItems", "array")
+	return s.count("minItems", "maxItems", n)
+}
+
+// MaxItems sets an array's maximum length.
+func (s *Schema) MaxItems(n int) *Schema {
+	s.need("MaxItems", "array")
+	return s.count("maxItems", "minItems", n)
+}
+
+// Unique requires an array's elements to differ from each other.
+func (s *Schema) Unique() *Schema {
+	s.need("Unique", "array")
+	s.keywords["uniqueItems"] = true
+	return s
+}
+
+// Prop declares an object property. Properties are optional unless
+// listed with Required.
+func (s *Schema) Prop(name string, schema *Schema) *Schema {
+	s.need("Prop", "object")
+	mustSchema("Prop", schema)
+	if slices.ContainsFunc(s.props, func(p property) bool { return p.name == name }) {
+		panic(fmt.Sprintf("mtpschema: property %q is declared twice", name))
+	}
+	s.props = append(s.props, property{name, schema})
+	return s
+}
+
+// Required marks properties as required. Each must be declared with
+// Prop, before or after this call.
+func (s *Schema) Required(names ...string) *Schema {
+	s.need("Required", "object")
+	for _, name := range names {
+		if !slices.Contains(s.required, name) {
+			s.required = append(s.required, name)
+		}
+	}
+	return s
+}
+
+// Closed rejects properties that weren't declared with Prop.
+func (s *Schema) Closed() *Schema {
+	s.need("Closed", "object")
+	s.keywords["additionalProperties"] = false
+	return s
+}
+
+// Map compiles the schema to the map form IODescriptor.Schema holds. Each
+// call returns a new map. It panics if a required property wasn't
+// declared, or a default isn't one of the enum values.
+func (s *Schema) Map() map[string]any {
+	m := make(map[string]any, len(s.keywords)+3)
+	for k, v := range s.keywords {
+		m[k] = compile(v)
+	}
+	switch {
+	case s.kind != "" && s.nullable:
+		m["type"] = []any{s.kind, "null"}
+	case s.kind != "":
+		m["type"] = s.kind
+	}
+	if len(s.props) > 0 {
+		props := make(map[string]any, len(s.props))
+		for _, p := range s.props {
+			props[p.name] = p.schema.Map()
+		}
+		m["properties"] = props
+	}
+	if len(s.required) > 0 {
+		required := make([]any, len(s.required))
+		for i, name := range s.required {
+			if !slices.ContainsFunc(s.props, func(p property) bool { return p.name == name }) {
+				panic(fmt.Sprintf("mtpschema: required property %q is not declared", name))
+			}
+			required[i] = name
+		}
+		m["required"] = required
+	}
+	if enum, ok := s.keywords["enum"].([]any); ok {
+		if def, ok := s.keywords["default"]; ok && def != nil && !slices.Contains(enum, def) {
+			panic(fmt.Sprintf("mtpschema: default %v is not one of the enum values", def))
+		}
+	}
+	return m
+}
+
+// MarshalJSON encodes the compiled schema.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Map())
+}
+
+// compile turns nested builders in a keyword value into maps.
+func compile(v any) any {
+	switch v := v.(type) {
+	case *Schema:
+		return v.Map()
+	case []*Schema:
+		out := make([]any, len(v))
+		for i, s := range v {
+			out[i] = s.Map()
+		}
+		return out
+	case []any:
+		return slices.Clone(v)
+	}
+	return v
+}
+
+// need panics unless the schema has one of kinds.
+func (s *Schema) need(method string, kinds ...string) {
+	if slices.Contains(kinds, s.kind) {
+		return
+	}
+	kind := s.kind + " schema"
+	if s.kind == "" {
+		kind = "a reference or union"
+	}
+	panic(fmt.Sprintf("mtpschema: %s applies to %s schemas, not %s", method, strings.Join(kinds, " and "), kind))
+}
+
+// count sets a length keyword, checking it against its opposite bound.
+func (s *Schema) count(keyword, opposite string, n int) *Schema {
+	if n < 0 {
+		panic(fmt.Sprintf("mtpschema: %s must not be negative, got %d", keyword, n))
+	}
+	s.keywords[keyword] = n
+	s.checkBounds(keyword, opposite)
+	return s
+}
+
+// bound sets a numeric bound, checking it against its opposite.
+func (s *Schema) bound(keyword, opposite string, n float64) *Schema {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		panic(fmt.Sprintf("mtpschema: %s must be finite, got %v", keyword, n))
+	}
+	s.keywords[keyword] = n
+	s.checkBounds(keyword, opposite)
+	return s
+}
+
+func (s *Schema) checkBounds(a, b string) {
+	lo, hi := s.keywords[a], s.keywords[b]
+	if strings.HasPrefix(a, "max") {
+		lo, hi = hi, lo
+	}
+	if lo == nil || hi == nil {
+		return
+	}
+	if toFloat(lo) > toFloat(hi) {
+		panic(fmt.Sprintf("mtpschema: minimum %v is greater than maximum %v", lo, hi))
+	}
+}
+
+func toFloat(v any) float64 {
+	if n, ok := v.(int); ok {
+		return float64(n)
+	}
+	return v.(float64)
+}
+
+// mustFit returns v, a value given for the schema, after checking that it
+// has the schema's type. References and unions accept anything.
+func (s *Schema) mustFit(method string, v any) any {
+	if s.kind == "" || (v == nil && s.nullable) {
+		return v
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("mtpschema: %s: %v", method, err))
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var decoded any
+	dec.Decode(&decoded)
+	var got string
+	switch d := decoded.(type) {
+	case string:
+		got = "string"
+	case bool:
+		got = "boolean"
+	case json.Number:
+		got = "number"
+		if f, err := d.Float64(); err == nil && f == math.Trunc(f) {
+			got = "integer"
+		}
+	case map[string]any:
+		got = "object"
+	case []any:
+		got = "array"
+	default:
+		got = "null"
+	}
+	if got != s.kind && !(s.kind == "number" && got == "integer") {
+		panic(fmt.Sprintf("mtpschema: %s value %s is not of type %s", method, data, s.kind))
+	}
+	return v
+}
+
+// mustSchema panics if a schema passed to method is nil.
+func mustSchema(method string, s *Schema) *Schema {
+	if s == nil {
+		panic("mtpschema: nil schema passed to " + method)
+	}
+	return s
+}
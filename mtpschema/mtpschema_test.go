@@ -0,0 +1,94 @@
+package mtpschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+func TestMap(t *testing.T) {
+	item := Object().
+		Prop("name", String().Desc("Item name").MinLength(1).Pattern(`^\S`)).
+		Prop("count", Integer().Min(0).Default(1)).
+		Prop("tags", Array(String().Enum("new", "sale")).Unique()).
+		Prop("owner", Ref("User").Desc("Who added it")).
+		Prop("note", String().Nullable()).
+		Required("name").
+		Closed()
+
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "description": "Item name", "minLength": 1, "pattern": `^\S`},
+			"count": map[string]any{"type": "integer", "minimum": 0.0, "default": 1},
+			"tags": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string", "enum": []any{"new", "sale"}},
+				"uniqueItems": true,
+			},
+			"owner": map[string]any{"$ref": "#/definitions/User", "description": "Who added it"},
+			"note":  map[string]any{"type": []any{"string", "null"}},
+		},
+		"required":             []any{"name"},
+		"additionalProperties": false,
+	}
+	got := item.Map()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"required":["name"]`) {
+		t.Errorf("MarshalJSON: %s", data)
+	}
+
+	tool := &mtp.ToolSchema{
+		Definitions: map[string]map[string]any{"User": String().Map()},
+		Commands: []mtp.CommandDescriptor{{
+			Name:  "add",
+			Stdin: &mtp.IODescriptor{ContentType: "application/json", Schema: got},
+		}},
+	}
+	if diags := mtp.ValidateSchema(tool); len(diags) > 0 {
+		t.Errorf("compiled schema has diagnostics: %v", diags)
+	}
+}
+
+func TestPanics(t *testing.T) {
+	for _, tc := range []struct {
+		build func()
+		want  string
+	}{
+		{func() { String().Min(1) }, "Min applies to integer and number schemas, not string schema"},
+		{func() { Ref("User").Enum("a") }, "Enum applies to string schemas, not a reference or union"},
+		{func() { Integer().Min(5).Max(1) }, "minimum 5 is greater than maximum 1"},
+		{func() { String().MaxLength(2).MinLength(3) }, "minimum 3 is greater than maximum 2"},
+		{func() { Array(String()).MinItems(-1) }, "minItems must not be negative"},
+		{func() { String().Pattern(`(?=x)`) }, "Pattern"},
+		{func() { Integer().Default("3") }, `Default value "3" is not of type integer`},
+		{func() { Object().Prop("a", String()).Prop("a", Integer()) }, `property "a" is declared twice`},
+		{func() { Object().Prop("a", nil) }, "nil schema passed to Prop"},
+		{func() { Object().Required("nmae").Prop("name", String()).Map() }, `required property "nmae" is not declared`},
+		{func() { String().Enum("a", "b").Default("c").Map() }, "default c is not one of the enum values"},
+		{func() { OneOf() }, "oneOf needs at least one option"},
+	} {
+		got := func() (msg string) {
+			defer func() { msg = fmt.Sprint(recover()) }()
+			tc.build()
+			return
+		}()
+		if !strings.Contains(got, tc.want) {
+			t.Errorf("panic %q, want %q", got, tc.want)
+		}
+	}
+
+	// Declaring a required property after Required is fine.
+	Object().Required("name").Prop("name", String()).Map()
+}
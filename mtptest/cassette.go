@@ -0,0 +1,179 @@
+package mtptest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// RecordEnvVar switches cassettes to record mode when set to a non-empty
+// value: MTP_RECORD=1 go test ./...
+const RecordEnvVar = "MTP_RECORD"
+
+// Interaction is one recorded run of a tool.
+type Interaction struct {
+	Tool     string   `json:"tool"` // Base name of the executable
+	Args     []string `json:"args"`
+	Stdin    string   `json:"stdin,omitempty"`
+	Stdout   string   `json:"stdout"`
+	Stderr   string   `json:"stderr,omitempty"`
+	ExitCode int      `json:"exitCode"`
+}
+
+// Cassette runs MTP tools for an agent integration test. In record mode it
+// runs them for real and saves every interaction to a file; in replay mode
+// it serves the saved results without running anything, so the test is
+// hermetic and repeatable, and the tools' side effects don't happen.
+//
+// Interactions are matched on the tool's base name, args, and stdin.
+// Identical runs replay in the order they were recorded. Output is stored
+// as text, so tools whose output isn't UTF-8 don't replay faithfully.
+type Cassette struct {
+	Path   string
+	Record bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+type cassetteFile struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// UseCassette opens the cassette at path for the duration of the test. It
+// replays unless $MTP_RECORD is set, in which case it records and writes
+// the file when the test ends. A missing file in replay mode fails the
+// test.
+func UseCassette(t testing.TB, path string) *Cassette {
+	t.Helper()
+	c := &Cassette{Path: path, Record: os.Getenv(RecordEnvVar) != ""}
+	if c.Record {
+		t.Cleanup(func() {
+			if err := c.Save(); err != nil {
+				t.Errorf("saving cassette: %v", err)
+			}
+		})
+		return c
+	}
+	if err := c.Load(); err != nil {
+		t.Fatalf("loading cassette (set %s=1 to record it): %v", RecordEnvVar, err)
+	}
+	return c
+}
+
+// Load reads the cassette's interactions from Path.
+func (c *Cassette) Load() error {
+	data, err := os.ReadFile(c.Path)
+	if err != nil {
+		return err
+	}
+	var f cassetteFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("%s: %w", c.Path, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions = f.Interactions
+	c.used = make([]bool, len(f.Interactions))
+	return nil
+}
+
+// Save writes the recorded interactions to Path as indented JSON, creating
+// parent directories as needed.
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(cassetteFile{Interactions: c.interactions}, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.Path, append(data, '\n'), 0o644)
+}
+
+// Interactions returns what the cassette holds: the interactions recorded
+// so far, or those loaded for replay.
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.interactions)
+}
+
+// Exec runs the tool at path, an executable path or a name looked up on
+// $PATH, with args and stdin, copying its output to stdout and stderr,
+// which may be nil. It returns the tool's exit code. The error is non-nil
+// only when the tool couldn't be run, or, in replay mode, when no unused
+// recorded interaction matches.
+func (c *Cassette) Exec(ctx context.Context, path string, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	var in []byte
+	if stdin != nil {
+		var err error
+		if in, err = io.ReadAll(stdin); err != nil {
+			return 0, err
+		}
+	}
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	it := Interaction{Tool: filepath.Base(path), Args: args, Stdin: string(in)}
+	if it.Args == nil {
+		it.Args = []string{}
+	}
+	if !c.Record {
+		rec, err := c.replay(it)
+		if err != nil {
+			return 0, err
+		}
+		io.WriteString(stdout, rec.Stdout)
+		io.WriteString(stderr, rec.Stderr)
+		return rec.ExitCode, nil
+	}
+
+	var outBuf, errBuf bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = io.MultiWriter(&outBuf, stdout)
+	cmd.Stderr = io.MultiWriter(&errBuf, stderr)
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return 0, err
+		}
+		it.ExitCode = exitErr.ExitCode()
+	}
+	it.Stdout, it.Stderr = outBuf.String(), errBuf.String()
+	c.mu.Lock()
+	c.interactions = append(c.interactions, it)
+	c.used = append(c.used, true)
+	c.mu.Unlock()
+	return it.ExitCode, nil
+}
+
+// replay returns the first unused interaction matching want.
+func (c *Cassette) replay(want Interaction) (Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, it := range c.interactions {
+		if !c.used[i] && it.Tool == want.Tool && it.Stdin == want.Stdin && slices.Equal(it.Args, want.Args) {
+			c.used[i] = true
+			return it, nil
+		}
+	}
+	return Interaction{}, fmt.Errorf("mtptest: %s has no recorded run of %s %s", c.Path, want.Tool, strings.Join(want.Args, " "))
+}
@@ -0,0 +1,68 @@
+package mtptest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCassette(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the tool")
+	}
+	dir := t.TempDir()
+	effects := filepath.Join(dir, "effects")
+	tool := filepath.Join(dir, "greet")
+	script := "#!/bin/sh\necho ran >> " + effects + "\nread name\necho \"hello $name $1\"\necho warning >&2\nexit 3\n"
+	if err := os.WriteFile(tool, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "testdata", "greet.json")
+	ctx := context.Background()
+	run := func(c *Cassette, arg string) (string, string, int) {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		code, err := c.Exec(ctx, tool, []string{arg}, strings.NewReader("ada\n"), &stdout, &stderr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stdout.String(), stderr.String(), code
+	}
+
+	t.Run("record", func(t *testing.T) {
+		t.Setenv(RecordEnvVar, "1")
+		c := UseCassette(t, path)
+		if out, errOut, code := run(c, "!"); out != "hello ada !\n" || errOut != "warning\n" || code != 3 {
+			t.Errorf("recording: %q, %q, %d", out, errOut, code)
+		}
+		run(c, "?")
+	})
+	data, _ := os.ReadFile(effects)
+	if n := strings.Count(string(data), "ran"); n != 2 {
+		t.Fatalf("recording should run the tool, ran %d times", n)
+	}
+
+	c := UseCassette(t, path)
+	if len(c.Interactions()) != 2 {
+		t.Fatalf("expected two recorded interactions, got %+v", c.Interactions())
+	}
+	if out, errOut, code := run(c, "?"); out != "hello ada ?\n" || errOut != "warning\n" || code != 3 {
+		t.Errorf("replaying: %q, %q, %d", out, errOut, code)
+	}
+	run(c, "!")
+	data, _ = os.ReadFile(effects)
+	if n := strings.Count(string(data), "ran"); n != 2 {
+		t.Errorf("replaying should not run the tool, ran %d times in all", n)
+	}
+
+	if _, err := c.Exec(ctx, tool, []string{"!"}, strings.NewReader("ada\n"), nil, nil); err == nil {
+		t.Error("each recorded run should replay once")
+	}
+	if _, err := c.Exec(ctx, tool, []string{"!"}, strings.NewReader("bob\n"), nil, nil); err == nil {
+		t.Error("different stdin should not match")
+	}
+}
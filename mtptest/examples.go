@@ -0,0 +1,144 @@
+package mtptest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/spf13/cobra"
+)
+
+// RunExamples runs every example in root's schema as a subtest, so
+// published examples can't silently rot. Each example runs in a fresh
+// temporary directory holding opts.Fixtures. It must succeed, and if it
+// declares Output, its stdout must match after normalization:
+//
+//   - line endings become \n, trailing whitespace on each line and
+//     leading and trailing blank lines are dropped
+//   - the temporary directory's path becomes "."
+//   - opts.Normalize functions are applied in order
+//
+// When both outputs parse as JSON they are compared as values, so key
+// order and formatting don't matter.
+//
+// Leading NAME=value words set environment variables for the run.
+// Examples that need a shell (pipes, redirection, substitution), that
+// invoke another program, or whose secrets were redacted in the schema are
+// skipped with the reason.
+func RunExamples(t *testing.T, root *cobra.Command, opts *Options) {
+	t.Helper()
+	schema := mtp.Describe(root, opts.describe())
+	for _, cmd := range schema.Commands {
+		for i, ex := range cmd.Examples {
+			t.Run(cmd.Name+"/"+strconv.Itoa(i), func(t *testing.T) {
+				runExample(t, root, opts, ex)
+			})
+		}
+	}
+}
+
+func runExample(t *testing.T, root *cobra.Command, opts *Options, ex mtp.Example) {
+	t.Helper()
+	if strings.Contains(ex.Command, "***") {
+		t.Skipf("command has redacted values: %s", ex.Command)
+	}
+	words, err := splitCommand(ex.Command)
+	if err != nil {
+		t.Skipf("can't run %q: %v", ex.Command, err)
+	}
+	for len(words) > 0 && isAssignment(words[0]) {
+		name, value, _ := strings.Cut(words[0], "=")
+		t.Setenv(name, value)
+		words = words[1:]
+	}
+	if len(words) == 0 || filepath.Base(words[0]) != root.Name() {
+		t.Skipf("%q doesn't invoke %s", ex.Command, root.Name())
+	}
+
+	dir := t.TempDir()
+	if err := copyFixtures(opts.fixtures(), dir); err != nil {
+		t.Fatalf("copying fixtures: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	res := execute(root, words[1:], strings.NewReader(""))
+	if res.err != nil {
+		t.Fatalf("%s: %v\nstderr:\n%s", ex.Command, res.err, res.stderr)
+	}
+	if ex.Output == "" {
+		return
+	}
+
+	got := normalize(res.stdout, dir, opts)
+	want := normalize(ex.Output, dir, opts)
+	if got != want && !jsonEqual(got, want) {
+		t.Errorf("%s: output mismatch\n--- got ---\n%s\n--- want ---\n%s", ex.Command, got, want)
+	}
+}
+
+var assignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// isAssignment reports whether word is a NAME=value environment prefix.
+func isAssignment(word string) bool {
+	return assignment.MatchString(word)
+}
+
+// ReplaceRegexp returns a normalizer that replaces every match of pattern
+// with repl (which may use $1-style references), for Options.Normalize:
+//
+//	mtptest.ReplaceRegexp(`\d{4}-\d{2}-\d{2}T[\d:.]+Z`, "<time>")
+func ReplaceRegexp(pattern, repl string) func(string) string {
+	re := regexp.MustCompile(pattern)
+	return func(s string) string { return re.ReplaceAllString(s, repl) }
+}
+
+// normalize applies the built-in output rules and then opts.Normalize.
+func normalize(s, dir string, opts *Options) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	for _, d := range tempDirPaths(dir) {
+		s = strings.ReplaceAll(s, d, ".")
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	s = strings.Trim(strings.Join(lines, "\n"), "\n")
+	if opts != nil {
+		for _, fn := range opts.Normalize {
+			s = fn(s)
+		}
+	}
+	return s
+}
+
+// tempDirPaths returns dir and, if different, its symlink-resolved form
+// (on macOS, /var is a link to /private/var).
+func tempDirPaths(dir string) []string {
+	paths := []string{dir}
+	if real, err := filepath.EvalSymlinks(dir); err == nil && real != dir {
+		paths = append([]string{real}, paths...)
+	}
+	return paths
+}
+
+// jsonEqual reports whether a and b are both JSON and encode equal values.
+func jsonEqual(a, b string) bool {
+	var va, vb any
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,268 @@
+package mtptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"testing"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/spf13/cobra"
+)
+
+// maxSeedsPerCommand caps the seed corpus Fuzz derives for one command.
+const maxSeedsPerCommand = 64
+
+// Fuzz fuzzes root's argument parsing and stdin handling:
+//
+//	func FuzzTool(f *testing.F) {
+//		mtptest.Fuzz(f, newRootCmd(), nil)
+//	}
+//
+// Each input picks a command from the schema, the words after the
+// command's path (NUL-separated), and stdin. The seed corpus is derived
+// from the schema: every example, then each arg in turn set to values
+// chosen for its type (enum values and near misses, integer and float
+// limits, awkward paths, empty and oversized strings) alongside the
+// required args, and for commands that read stdin, empty, malformed, and
+// schema-shaped JSON. The fuzzer mutates from there, so most inputs stay
+// close to real invocations.
+//
+// Commands may fail on bad input; the test fails only if one panics. Each
+// input runs in a fresh temporary directory holding opts.Fixtures, but
+// the tool's other side effects are real, so fuzz commands that only
+// touch their working directory.
+func Fuzz(f *testing.F, root *cobra.Command, opts *Options) {
+	f.Helper()
+	schema := mtp.Describe(root, opts.describe())
+	cmds := schema.Commands
+	if len(cmds) == 0 {
+		f.Fatal("the schema has no commands")
+	}
+	for i, cmd := range cmds {
+		for _, seed := range fuzzSeeds(root.Name(), cmd) {
+			f.Add(uint(i), strings.Join(seed.args, "\x00"), []byte(seed.stdin))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, i uint, words string, stdin []byte) {
+		cmd := cmds[i%uint(len(cmds))]
+		argv := slices.Clone(cmd.Path)
+		if words != "" {
+			argv = append(argv, strings.Split(words, "\x00")...)
+		}
+
+		dir := t.TempDir()
+		if err := copyFixtures(opts.fixtures(), dir); err != nil {
+			t.Fatalf("copying fixtures: %v", err)
+		}
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chdir(wd)
+
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("%s %q with stdin %q panicked: %v\n%s", root.Name(), argv, stdin, r, debug.Stack())
+			}
+		}()
+		execute(root, argv, bytes.NewReader(stdin))
+	})
+}
+
+type fuzzSeed struct {
+	args  []string
+	stdin string
+}
+
+// fuzzSeeds derives seed inputs for cmd of tool from its schema.
+func fuzzSeeds(tool string, cmd mtp.CommandDescriptor) []fuzzSeed {
+	var seeds []fuzzSeed
+	for _, ex := range cmd.Examples {
+		words, ok := exampleArgs(tool, ex.Command)
+		if ok && len(words) >= len(cmd.Path) && slices.Equal(words[:len(cmd.Path)], cmd.Path) {
+			seeds = append(seeds, fuzzSeed{args: words[len(cmd.Path):]})
+		}
+	}
+
+	values := make([][]string, len(cmd.Args))
+	base := map[int]string{}
+	for i, arg := range cmd.Args {
+		values[i] = seedValues(arg)
+		if arg.Required {
+			base[i] = values[i][0]
+		}
+	}
+	seeds = append(seeds, fuzzSeed{args: invocation(cmd.Args, base)})
+	if cmd.Stdin != nil {
+		for _, in := range stdinSeeds(cmd.Stdin) {
+			seeds = append(seeds, fuzzSeed{args: invocation(cmd.Args, base), stdin: in})
+		}
+	}
+	for i := range cmd.Args {
+		for _, v := range values[i] {
+			set := map[int]string{i: v}
+			for j, bv := range base {
+				if j != i {
+					set[j] = bv
+				}
+			}
+			seeds = append(seeds, fuzzSeed{args: invocation(cmd.Args, set)})
+		}
+	}
+	if len(seeds) > maxSeedsPerCommand {
+		seeds = seeds[:maxSeedsPerCommand]
+	}
+	return seeds
+}
+
+// exampleArgs splits an example command line into the words after the
+// tool's name, or reports false if it isn't a plain invocation of the tool.
+func exampleArgs(tool, line string) ([]string, bool) {
+	if strings.Contains(line, "***") {
+		return nil, false
+	}
+	words, err := splitCommand(line)
+	if err != nil {
+		return nil, false
+	}
+	for len(words) > 0 && isAssignment(words[0]) {
+		words = words[1:]
+	}
+	if len(words) == 0 || filepath.Base(words[0]) != tool {
+		return nil, false
+	}
+	return words[1:], true
+}
+
+// invocation builds the words for args with the given values, by index:
+// flags as --name=value, then positionals in order.
+func invocation(args []mtp.ArgDescriptor, set map[int]string) []string {
+	var flags, positionals []string
+	for i, arg := range args {
+		v, ok := set[i]
+		if !ok {
+			continue
+		}
+		if isFlagArg(arg) {
+			flags = append(flags, arg.Name+"="+v)
+		} else {
+			positionals = append(positionals, v)
+		}
+	}
+	return append(flags, positionals...)
+}
+
+// seedValues returns values worth trying for arg, a plausible one first.
+func seedValues(arg mtp.ArgDescriptor) []string {
+	var vs []string
+	if arg.Default != nil {
+		vs = append(vs, fmt.Sprint(arg.Default))
+	}
+	switch arg.Type {
+	case "enum":
+		vs = append(vs, arg.Values...)
+		if len(arg.Values) > 0 {
+			vs = append(vs, strings.ToUpper(arg.Values[0]), arg.Values[0]+" ")
+		}
+		vs = append(vs, "")
+	case "boolean":
+		vs = append(vs, "true", "false", "1", "yes")
+	case "integer":
+		vs = append(vs, "1", "0", "-1", "9223372036854775807", "9223372036854775808", "1.5", "0x10", "")
+	case "number":
+		vs = append(vs, "1.5", "0", "-0", "1e308", "1e309", "NaN", "-Inf", "")
+	case "path":
+		vs = append(vs, "input.txt", ".", "missing/file", "../outside", "/dev/null", "-", "")
+	case "array":
+		vs = append(vs, "a", "a,b", ",", "", `"a,b",c`)
+	default:
+		vs = append(vs, "value", "", "-", "--", " ", "日本語", "$(id)", strings.Repeat("a", 4096))
+	}
+	return vs
+}
+
+// stdinSeeds returns stdin inputs for a command that reads in: empty and
+// malformed input, and for a JSON schema, a document of its shape.
+func stdinSeeds(in *mtp.IODescriptor) []string {
+	seeds := []string{"", "\n", "not json", "{", "null", "[]", "{}"}
+	if in.Schema != nil {
+		if data, err := json.Marshal(sampleJSON(in.Schema, 0)); err == nil {
+			seeds = append([]string{string(data)}, seeds...)
+		}
+	}
+	return seeds
+}
+
+// sampleJSON returns a value matching a JSON Schema: its first example,
+// enum value, or default if it has one, otherwise a value of its type,
+// with every property filled in for objects.
+func sampleJSON(schema map[string]any, depth int) any {
+	if depth > 8 {
+		return nil
+	}
+	if ex, ok := firstOf(schema["examples"]); ok {
+		return ex
+	}
+	if enum, ok := firstOf(schema["enum"]); ok {
+		return enum
+	}
+	if def, ok := schema["default"]; ok {
+		return def
+	}
+	typ, _ := schema["type"].(string)
+	if t, ok := firstOf(schema["type"]); ok {
+		typ, _ = t.(string)
+	}
+	switch typ {
+	case "object":
+		obj := map[string]any{}
+		props, _ := schema["properties"].(map[string]any)
+		for name, p := range props {
+			if ps, ok := p.(map[string]any); ok {
+				obj[name] = sampleJSON(ps, depth+1)
+			}
+		}
+		return obj
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		if items == nil {
+			return []any{}
+		}
+		return []any{sampleJSON(items, depth+1)}
+	case "string":
+		return "value"
+	case "integer":
+		return 1
+	case "number":
+		return 1.5
+	case "boolean":
+		return true
+	}
+	return nil
+}
+
+// firstOf returns the first element of a JSON Schema list, written in Go
+// ([]string, []any) or decoded from JSON.
+func firstOf(v any) (any, bool) {
+	switch v := v.(type) {
+	case []any:
+		if len(v) > 0 {
+			return v[0], true
+		}
+	case []string:
+		if len(v) > 0 {
+			return v[0], true
+		}
+	}
+	return nil, false
+}
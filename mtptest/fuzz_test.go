@@ -0,0 +1,76 @@
+package mtptest
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+)
+
+// FuzzGreet runs the derived seed corpus under go test, and fuzzes with
+// go test -fuzz FuzzGreet.
+func FuzzGreet(f *testing.F) {
+	Fuzz(f, newTool(), nil)
+}
+
+func TestFuzzSeeds(t *testing.T) {
+	cmd := mtp.CommandDescriptor{
+		Name: "convert",
+		Path: []string{"convert"},
+		Args: []mtp.ArgDescriptor{
+			{Name: "input", Type: "path", Required: true},
+			{Name: "--format", Type: "enum", Values: []string{"png", "gif"}},
+			{Name: "--quality", Type: "integer", Default: 80},
+		},
+		Stdin: &mtp.IODescriptor{Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"mode": map[string]any{"type": "string", "enum": []string{"fast", "slow"}},
+				"tags": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			},
+		}},
+		Examples: []mtp.Example{
+			{Command: "imgtool convert a.png --format gif"},
+			{Command: "other convert a.png"},
+		},
+	}
+
+	var lines []string
+	stdins := map[string]bool{}
+	for _, s := range fuzzSeeds("imgtool", cmd) {
+		lines = append(lines, strings.Join(s.args, " "))
+		stdins[s.stdin] = true
+	}
+	for _, want := range []string{
+		"a.png --format gif", // From the example
+		"input.txt",          // Required args only
+		"--format=png input.txt",
+		"--format=PNG input.txt",
+		"--quality=80 input.txt", // The default first
+		"--quality=9223372036854775808 input.txt",
+		"../outside",
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing seed %q in\n%s", want, strings.Join(lines, "\n"))
+		}
+	}
+	if slices.Contains(lines, "convert a.png") {
+		t.Error("an example of another tool should not be a seed")
+	}
+
+	var sample map[string]any
+	for in := range stdins {
+		if json.Unmarshal([]byte(in), &sample) == nil && sample["mode"] != nil {
+			break
+		}
+		sample = nil
+	}
+	if sample["mode"] != "fast" || len(sample["tags"].([]any)) != 1 {
+		t.Errorf("expected a schema-shaped stdin seed, got %v", stdins)
+	}
+	if !stdins["{"] || !stdins[""] {
+		t.Errorf("expected malformed and empty stdin seeds, got %v", stdins)
+	}
+}
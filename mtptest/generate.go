@@ -0,0 +1,258 @@
+package mtptest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/spf13/cobra"
+)
+
+// Generator produces random invocations that are valid according to a
+// command's schema: required args are always present, enums take declared
+// values, and RequiredIf and ConflictsWith conditions (including Cobra's
+// flag groups) are satisfied.
+//
+// The schema doesn't declare numeric ranges, so integers are drawn from
+// 0 to 100 and numbers from 0 to 1, both of which suit percentages, counts,
+// and ratios alike.
+type Generator struct {
+	Rand *rand.Rand
+	// Paths are the values path args take, typically fixture files. When
+	// empty, paths are "input.txt".
+	Paths []string
+}
+
+// Invocation returns a random valid invocation of cmd: its path followed
+// by flags, as --name=value, and positionals.
+func (g *Generator) Invocation(cmd mtp.CommandDescriptor) []string {
+	values := map[string][]string{} // By arg name; absent args aren't passed
+	for _, arg := range cmd.Args {
+		if arg.Required || g.Rand.Intn(2) == 0 {
+			values[arg.Name] = g.values(arg)
+		}
+	}
+	// An optional positional can only be given if the ones before it are.
+	missing := false
+	for _, arg := range cmd.Args {
+		if isFlagArg(arg) {
+			continue
+		}
+		if missing {
+			delete(values, arg.Name)
+		}
+		_, given := values[arg.Name]
+		missing = missing || !given
+	}
+	g.satisfyConditions(cmd.Args, values)
+
+	argv := slices.Clone(cmd.Path)
+	var positionals []string
+	for _, arg := range cmd.Args {
+		vs, ok := values[arg.Name]
+		if !ok {
+			continue
+		}
+		if !isFlagArg(arg) {
+			positionals = append(positionals, vs...)
+			continue
+		}
+		for _, v := range vs {
+			argv = append(argv, arg.Name+"="+v)
+		}
+	}
+	return append(argv, positionals...)
+}
+
+// values returns random values for arg: several for arrays and variadic
+// positionals, one otherwise.
+func (g *Generator) values(arg mtp.ArgDescriptor) []string {
+	n := 1
+	if arg.Type == "array" || arg.Variadic {
+		n = 1 + g.Rand.Intn(3)
+	}
+	vs := make([]string, n)
+	for i := range vs {
+		vs[i] = g.value(arg)
+	}
+	return vs
+}
+
+func (g *Generator) value(arg mtp.ArgDescriptor) string {
+	switch arg.Type {
+	case "enum":
+		if len(arg.Values) > 0 {
+			return arg.Values[g.Rand.Intn(len(arg.Values))]
+		}
+	case "boolean":
+		return strconv.FormatBool(g.Rand.Intn(2) == 0)
+	case "integer":
+		return strconv.Itoa(g.Rand.Intn(101))
+	case "number":
+		return strconv.FormatFloat(g.Rand.Float64(), 'f', 2, 64)
+	case "path":
+		if len(g.Paths) > 0 {
+			return g.Paths[g.Rand.Intn(len(g.Paths))]
+		}
+		return "input.txt"
+	}
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	b := make([]byte, 1+g.Rand.Intn(8))
+	for i := range b {
+		b[i] = letters[g.Rand.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// satisfyConditions adjusts values until no RequiredIf or ConflictsWith
+// condition is violated: a conflicting optional arg is dropped, a
+// conflicting required one drops what it conflicts with, and an arg
+// required by a condition is added.
+func (g *Generator) satisfyConditions(args []mtp.ArgDescriptor, values map[string][]string) {
+	for range args {
+		changed := false
+		for _, arg := range args {
+			if !isFlagArg(arg) {
+				continue
+			}
+			_, given := values[arg.Name]
+			for _, cond := range arg.ConflictsWith {
+				if !given || !conditionHolds(args, values, cond) {
+					continue
+				}
+				other, _, _ := strings.Cut(cond, "=")
+				if !arg.Required {
+					delete(values, arg.Name)
+					given = false
+				} else {
+					delete(values, other)
+				}
+				changed = true
+			}
+			for _, cond := range arg.RequiredIf {
+				if !given && conditionHolds(args, values, cond) {
+					values[arg.Name] = g.values(arg)
+					given, changed = true, true
+				}
+			}
+		}
+		if !changed {
+			return
+		}
+	}
+}
+
+// conditionHolds evaluates "--name" or "--name=value" against the chosen
+// values, falling back to the flag's default.
+func conditionHolds(args []mtp.ArgDescriptor, values map[string][]string, cond string) bool {
+	name, want, hasValue := strings.Cut(cond, "=")
+	vs, given := values[name]
+	if !hasValue {
+		return given
+	}
+	if given {
+		return slices.Contains(vs, want)
+	}
+	i := slices.IndexFunc(args, func(a mtp.ArgDescriptor) bool { return a.Name == name })
+	return i >= 0 && args[i].Default != nil && fmt.Sprint(args[i].Default) == want
+}
+
+func isFlagArg(arg mtp.ArgDescriptor) bool {
+	return strings.HasPrefix(arg.Name, "-")
+}
+
+// RunInvocations runs n random valid invocations (see Generator) of every
+// command in root's schema, one subtest per command, as a smoke test that
+// the schema and the tool agree. Each invocation must parse, reaching the
+// command's Run, and must not panic. If the tool declares error codes,
+// every failure must carry one (see mtp.NewError), so its exit code is
+// declared too. Path args take the names of files in opts.Fixtures.
+//
+// The invocations come from opts.Seed, or a random seed that failures
+// report, so a failing run can be reproduced.
+func RunInvocations(t *testing.T, root *cobra.Command, opts *Options, n int) {
+	t.Helper()
+	schema := mtp.Describe(root, opts.describe())
+	seed := opts.seed()
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	var paths []string
+	if fsys := opts.fixtures(); fsys != nil {
+		fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+	}
+
+	for _, cmd := range schema.Commands {
+		target, _, err := root.Find(cmd.Path)
+		if err != nil || !target.Runnable() {
+			continue
+		}
+		t.Run(cmd.Name, func(t *testing.T) {
+			g := &Generator{Rand: rand.New(rand.NewSource(seed)), Paths: paths}
+			for i := 0; i < n; i++ {
+				argv := g.Invocation(cmd)
+				if msg := runInvocation(t, root, target, opts, argv, len(schema.ErrorCodes) > 0); msg != "" {
+					t.Errorf("%s %s: %s (seed %d)", root.Name(), strings.Join(argv, " "), msg, seed)
+					return
+				}
+			}
+		})
+	}
+}
+
+// runInvocation runs argv in a fresh directory holding the fixtures and
+// returns what was wrong with the outcome, if anything.
+func runInvocation(t *testing.T, root, target *cobra.Command, opts *Options, argv []string, codesDeclared bool) (problem string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := copyFixtures(opts.fixtures(), dir); err != nil {
+		t.Fatalf("copying fixtures: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// Note whether Cobra got as far as the command's Run.
+	reached := false
+	run, runE := target.Run, target.RunE
+	defer func() { target.Run, target.RunE = run, runE }()
+	if runE != nil {
+		target.RunE = func(cmd *cobra.Command, args []string) error { reached = true; return runE(cmd, args) }
+	} else {
+		target.Run = func(cmd *cobra.Command, args []string) { reached = true; run(cmd, args) }
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			problem = fmt.Sprintf("panicked: %v", r)
+		}
+	}()
+	res := execute(root, argv, bytes.NewReader(nil))
+	var coded *mtp.Error
+	switch {
+	case res.err != nil && !reached:
+		return fmt.Sprintf("didn't parse: %v", res.err)
+	case res.err != nil && codesDeclared && !errors.As(res.err, &coded):
+		return fmt.Sprintf("failed without a declared error code (exit %d): %v", mtp.ExitCode(res.err, opts.describe()), res.err)
+	}
+	return ""
+}
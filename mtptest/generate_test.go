@@ -0,0 +1,108 @@
+package mtptest
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/spf13/cobra"
+)
+
+func TestGenerator(t *testing.T) {
+	cmd := mtp.CommandDescriptor{
+		Name: "export",
+		Path: []string{"export"},
+		Args: []mtp.ArgDescriptor{
+			{Name: "table", Type: "string", Required: true},
+			{Name: "columns", Type: "array", Variadic: true},
+			{Name: "--format", Type: "enum", Values: []string{"csv", "json"}, Required: true},
+			{Name: "--encrypt", Type: "boolean"},
+			{Name: "--key", Type: "string", RequiredIf: []string{"--encrypt=true"}},
+			{Name: "--header", Type: "boolean", ConflictsWith: []string{"--format=json"}},
+			{Name: "--limit", Type: "integer"},
+		},
+	}
+	g := &Generator{Rand: rand.New(rand.NewSource(1))}
+	seen := map[string]bool{}
+	for i := 0; i < 500; i++ {
+		argv := g.Invocation(cmd)
+		line := strings.Join(argv, " ")
+		if argv[0] != "export" {
+			t.Fatalf("%s: should start with the command path", line)
+		}
+		var positionals []string
+		flags := map[string]string{}
+		for _, w := range argv[1:] {
+			if name, v, ok := strings.Cut(w, "="); ok && strings.HasPrefix(name, "--") {
+				flags[name] = v
+				seen[name] = true
+			} else {
+				positionals = append(positionals, w)
+			}
+		}
+		switch {
+		case len(positionals) == 0:
+			t.Errorf("%s: missing the required positional", line)
+		case !slices.Contains([]string{"csv", "json"}, flags["--format"]):
+			t.Errorf("%s: --format must be a declared value", line)
+		case flags["--encrypt"] == "true" && flags["--key"] == "":
+			t.Errorf("%s: --key is required with --encrypt", line)
+		case flags["--format"] == "json" && flags["--header"] != "":
+			t.Errorf("%s: --header conflicts with --format=json", line)
+		case len(positionals) > 4:
+			t.Errorf("%s: too many positionals", line)
+		}
+	}
+	for _, name := range []string{"--encrypt", "--key", "--header", "--limit"} {
+		if !seen[name] {
+			t.Errorf("optional %s was never generated", name)
+		}
+	}
+}
+
+func TestRunInvocations(t *testing.T) {
+	RunInvocations(t, newTool(), &Options{
+		Fixtures: fstest.MapFS{"notes.txt": {Data: []byte("hi")}},
+		Seed:     1,
+	}, 20)
+}
+
+func TestRunInvocationProblems(t *testing.T) {
+	withCodes := func(err error) *cobra.Command {
+		root := &cobra.Command{Use: "tool", SilenceUsage: true, SilenceErrors: true}
+		root.AddCommand(&cobra.Command{Use: "run", Args: cobra.NoArgs, RunE: func(*cobra.Command, []string) error { return err }})
+		return root
+	}
+	tests := []struct {
+		name  string
+		err   error
+		argv  []string
+		codes bool
+		want  string
+	}{
+		{"parses", nil, []string{"run"}, true, ""},
+		{"rejected", nil, []string{"run", "extra"}, false, "didn't parse"},
+		{"uncoded", errors.New("boom"), []string{"run"}, true, "without a declared error code"},
+		{"uncoded, no codes declared", errors.New("boom"), []string{"run"}, false, ""},
+		{"coded", &mtp.Error{Code: "BOOM", Message: "boom"}, []string{"run"}, true, ""},
+	}
+	for _, tt := range tests {
+		root := withCodes(tt.err)
+		target, _, _ := root.Find([]string{"run"})
+		got := runInvocation(t, root, target, nil, tt.argv, tt.codes)
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	root := &cobra.Command{Use: "tool"}
+	root.AddCommand(&cobra.Command{Use: "run", Run: func(*cobra.Command, []string) { panic("boom") }})
+	target, _, _ := root.Find([]string{"run"})
+	if got := runInvocation(t, root, target, nil, []string{"run"}, false); !strings.Contains(got, "panicked: boom") {
+		t.Errorf("panic: got %q", got)
+	}
+}
@@ -0,0 +1,237 @@
+// Package mtptest helps tool authors test their MTP metadata against the
+// tool itself, and agent authors test against tools hermetically (see
+// Cassette).
+//
+// Tool commands run in-process: the helpers set the root command's arguments,
+// capture its output, and reset every flag to its default afterwards, so
+// one root can run many times. Output written with cmd.OutOrStdout() and
+// with fmt.Print (os.Stdout) are both captured. Because os.Stdout and the
+// working directory are process-wide, tests using this package must not
+// call t.Parallel, and the tool must not call os.Exit on the paths under
+// test.
+package mtptest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Options configures the helpers in this package.
+type Options struct {
+	// Describe is passed to mtp.Describe to obtain the schema, and so the
+	// examples and types, under test.
+	Describe *mtp.DescribeOptions
+	// Fixtures are copied into a fresh temporary directory, which becomes
+	// the working directory, before each command runs. Typically
+	// os.DirFS("testdata/examples") or an embed.FS.
+	Fixtures fs.FS
+	// Normalize rewrites output before comparison, after the built-in
+	// rules; use it to mask timestamps, IDs, and the like (see
+	// ReplaceRegexp).
+	Normalize []func(string) string
+	// Seed seeds RunInvocations. Zero picks a random seed, which failures
+	// report so they can be reproduced.
+	Seed int64
+}
+
+func (o *Options) describe() *mtp.DescribeOptions {
+	if o == nil {
+		return nil
+	}
+	return o.Describe
+}
+
+func (o *Options) seed() int64 {
+	if o == nil {
+		return 0
+	}
+	return o.Seed
+}
+
+func (o *Options) fixtures() fs.FS {
+	if o == nil {
+		return nil
+	}
+	return o.Fixtures
+}
+
+// result is the outcome of one in-process run.
+type result struct {
+	stdout string
+	stderr string
+	err    error
+}
+
+// execute runs root with args and returns what it wrote. Flags are reset
+// afterwards.
+func execute(root *cobra.Command, args []string, stdin io.Reader) result {
+	defer resetFlags(root)
+
+	var stdout, stderr bytes.Buffer
+	root.SetArgs(args)
+	root.SetIn(stdin)
+	root.SetOut(&stdout)
+	root.SetErr(&stderr)
+	defer func() {
+		root.SetArgs(nil)
+		root.SetIn(nil)
+		root.SetOut(nil)
+		root.SetErr(nil)
+	}()
+
+	printed, err := captureStdout(func() error { return root.Execute() })
+	return result{stdout: stdout.String() + printed, stderr: stderr.String(), err: err}
+}
+
+// captureStdout runs fn with os.Stdout redirected to a pipe and returns
+// what was written to it.
+func captureStdout(fn func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	saved := os.Stdout
+	os.Stdout = w
+
+	done := make(chan string)
+	go func() {
+		var b bytes.Buffer
+		io.Copy(&b, r)
+		done <- b.String()
+	}()
+
+	var runErr error
+	func() {
+		defer func() {
+			os.Stdout = saved
+			w.Close()
+		}()
+		runErr = fn()
+	}()
+	out := <-done
+	r.Close()
+	return out, runErr
+}
+
+// resetFlags restores every flag in the tree to its default, so values
+// from one run don't leak into the next.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			sv.Replace(sliceDefault(f.DefValue))
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// sliceDefault parses pflag's "[a,b]" rendering of a slice default.
+func sliceDefault(def string) []string {
+	inner := strings.TrimSuffix(strings.TrimPrefix(def, "["), "]")
+	if inner == "" {
+		return []string{}
+	}
+	return strings.Split(inner, ",")
+}
+
+// errUnsupportedShell reports a command line that needs a real shell.
+var errUnsupportedShell = errors.New("needs a shell (pipes, redirection, or substitution)")
+
+// splitCommand splits a command line into words the way a POSIX shell
+// would for a simple command: whitespace separates words, single quotes
+// are literal, and double quotes and backslashes escape. Pipes,
+// redirection, command lists, and substitutions are rejected.
+func splitCommand(line string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			cur.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				switch {
+				case line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$`", line[i+1]) >= 0:
+					i++
+				case line[i] == '$' || line[i] == '`':
+					return nil, errUnsupportedShell
+				}
+				cur.WriteByte(line[i])
+			}
+			if i == len(line) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+		case c == '\\':
+			if i+1 < len(line) {
+				i++
+				cur.WriteByte(line[i])
+			}
+			inWord = true
+		case strings.IndexByte("|&;<>()$`", c) >= 0:
+			return nil, errUnsupportedShell
+		default:
+			cur.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
+
+// copyFixtures copies every file in fsys into dir.
+func copyFixtures(fsys fs.FS, dir string) error {
+	if fsys == nil {
+		return nil
+	}
+	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(path))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+}
@@ -0,0 +1,135 @@
+package mtptest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/spf13/cobra"
+)
+
+// newTool returns a small tool whose commands print through both
+// cmd.OutOrStdout and fmt.
+func newTool() *cobra.Command {
+	root := &cobra.Command{Use: "greet", Short: "Greetings"}
+
+	hello := &cobra.Command{
+		Use:   "hello <name>",
+		Short: "Say hello",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shout, _ := cmd.Flags().GetBool("shout")
+			msg := "Hello, " + args[0] + os.Getenv("GREET_SUFFIX")
+			if shout {
+				msg = strings.ToUpper(msg)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), msg)
+			return nil
+		},
+	}
+	hello.Flags().Bool("shout", false, "Shout")
+	hello.Flags().StringSlice("tag", nil, "Tags")
+
+	show := &cobra.Command{
+		Use:   "show <file>",
+		Short: "Show a file as JSON",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			wd, _ := os.Getwd()
+			fmt.Printf(`{"path": %q, "size": %d}`+"\n", wd+"/"+args[0], len(data))
+			return nil
+		},
+	}
+
+	root.AddCommand(hello, show)
+	return root
+}
+
+// ── Example runner tests ─────────────────────────────────────────────
+
+func TestRunExamples(t *testing.T) {
+	root := newTool()
+	RunExamples(t, root, &Options{
+		Describe: &mtp.DescribeOptions{Commands: map[string]*mtp.CommandAnnotation{
+			"hello": {Examples: []mtp.Example{
+				{Command: "greet hello 'Ada Lovelace'", Output: "Hello, Ada Lovelace\n"},
+				{Command: "greet hello --shout bob", Output: "HELLO, BOB"},
+				// --shout must not leak from the previous example.
+				{Command: "GREET_SUFFIX=! greet hello amy", Output: "Hello, amy!"},
+				{Command: "greet hello amy | tr a-z A-Z", Output: "never compared"},
+				{Command: "other-tool hello", Output: "never compared"},
+			}},
+			"show": {Examples: []mtp.Example{
+				{Command: "greet show data.txt", Output: `{"size": 5, "path": "./data.txt"}`},
+			}},
+		}},
+		Fixtures: fstest.MapFS{"data.txt": {Data: []byte("hello")}},
+	})
+}
+
+func TestExecuteResetsFlags(t *testing.T) {
+	root := newTool()
+	if res := execute(root, []string{"hello", "--shout", "--tag", "a,b", "x"}, nil); res.err != nil || res.stdout != "HELLO, X\n" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	hello, _, _ := root.Find([]string{"hello"})
+	if f := hello.Flags().Lookup("shout"); f.Changed || f.Value.String() != "false" {
+		t.Errorf("--shout not reset: %v", f.Value)
+	}
+	if f := hello.Flags().Lookup("tag"); f.Changed || f.Value.String() != "[]" {
+		t.Errorf("--tag not reset: %v", f.Value)
+	}
+}
+
+func TestExecuteCapturesStdout(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(dir+"/f", []byte("abc"), 0o644)
+	res := execute(newTool(), []string{"show", dir + "/f"}, nil)
+
+	var out map[string]any
+	if err := json.Unmarshal([]byte(res.stdout), &out); err != nil || out["size"] != float64(3) {
+		t.Errorf("fmt output not captured: %q (%v)", res.stdout, err)
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+		err  bool
+	}{
+		{"tool a  b", []string{"tool", "a", "b"}, false},
+		{`tool 'a b' "c \"d\"" e\ f`, []string{"tool", "a b", `c "d"`, "e f"}, false},
+		{`tool --name=""`, []string{"tool", "--name="}, false},
+		{"tool a | grep b", nil, true},
+		{"tool > out", nil, true},
+		{"tool $(date)", nil, true},
+		{`tool "$HOME"`, nil, true},
+		{"tool 'open", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := splitCommand(tt.in)
+		if (err != nil) != tt.err || strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("splitCommand(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	opts := &Options{Normalize: []func(string) string{ReplaceRegexp(`id=\d+`, "id=<n>")}}
+	got := normalize("\r\n/tmp/x/out.txt id=42  \r\n\n", "/tmp/x", opts)
+	if got != "./out.txt id=<n>" {
+		t.Errorf("normalize = %q", got)
+	}
+	if !jsonEqual(`{"a":1,"b":[1,2]}`, "{\n  \"b\": [1, 2],\n  \"a\": 1\n}") || jsonEqual(`{"a":1}`, `{"a":2}`) {
+		t.Error("jsonEqual should compare values")
+	}
+}
@@ -0,0 +1,96 @@
+package mtp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ToNushell returns Nushell extern definitions for every command in
+// schema, giving Nushell typed completion and argument checking for the
+// tool. Enum arguments complete from their values. Save the result as a
+// module and `use` it, or source it from config.nu.
+func ToNushell(schema *ToolSchema) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated from the MTP schema for %s %s.\n", schema.Name, schema.Version)
+
+	for _, cmd := range schema.Commands {
+		path := strings.Join(commandArgv(schema.Name, cmd), " ")
+		b.WriteString("\n")
+
+		// Completers must be defined before the extern that uses them.
+		completers := map[string]string{}
+		for _, arg := range cmd.Args {
+			if len(arg.Values) == 0 {
+				continue
+			}
+			name := "nu-complete " + path + " " + argKey(arg)
+			completers[arg.Name] = name
+			quoted := make([]string, len(arg.Values))
+			for i, v := range arg.Values {
+				quoted[i] = mustJSON(v)
+			}
+			fmt.Fprintf(&b, "def %s [] { [%s] }\n", mustJSON(name), strings.Join(quoted, " "))
+		}
+
+		desc := cmd.Description
+		if desc == "" {
+			desc = schema.Description
+		}
+		if desc != "" {
+			fmt.Fprintf(&b, "# %s\n", nuComment(desc))
+		}
+		fmt.Fprintf(&b, "export extern %s [\n", mustJSON(path))
+		for _, arg := range cmd.Args {
+			fmt.Fprintf(&b, "  %s", nuParam(arg, completers[arg.Name]))
+			if d := argDescription(arg); d != "" {
+				fmt.Fprintf(&b, "  # %s", nuComment(d))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("]\n")
+	}
+	return b.String()
+}
+
+// nuParam renders one extern parameter.
+func nuParam(arg ArgDescriptor, completer string) string {
+	typ := nuType(arg.Type)
+	if completer != "" {
+		typ += "@" + mustJSON(completer)
+	}
+
+	if isFlag(arg) {
+		if arg.Type == "boolean" {
+			return arg.Name // a switch
+		}
+		return arg.Name + ": " + typ
+	}
+
+	name := strings.ReplaceAll(argKey(arg), "-", "_")
+	switch {
+	case arg.Variadic:
+		return "..." + name + ": " + typ
+	case !arg.Required:
+		return name + "?: " + typ
+	}
+	return name + ": " + typ
+}
+
+// nuType maps an MTP type to a Nushell type. Externs receive arrays as
+// repeated flags or separate positionals, so each element is a string.
+func nuType(typ string) string {
+	switch typ {
+	case "integer":
+		return "int"
+	case "number":
+		return "number"
+	case "boolean":
+		return "bool"
+	}
+	return "string"
+}
+
+// nuComment flattens text onto a single comment line.
+func nuComment(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,126 @@
+package mtp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// OCI label keys. A small schema is stored as plain JSON in
+// OCILabelSchema. A larger one is gzipped and base64-encoded
+// (OCILabelEncoding is "gzip+base64"), and if that is still too big it is
+// split across OCILabelSchema+".0", ".1", ... with the count in
+// OCILabelChunks.
+const (
+	OCILabelSchema   = "org.mtp.schema"
+	OCILabelEncoding = "org.mtp.schema.encoding"
+	OCILabelChunks   = "org.mtp.schema.chunks"
+)
+
+// ociEncodingGzip is the only OCILabelEncoding value.
+const ociEncodingGzip = "gzip+base64"
+
+// ociMaxLabelValue bounds each label value. Registries and runtimes accept
+// larger labels, but image configs are fetched eagerly, and several tools
+// truncate long values in listings.
+const ociMaxLabelValue = 16 * 1024
+
+// ociMaxSchema bounds a decompressed schema label. Labels come from
+// whoever built the image, and a few kilobytes of gzip can expand to
+// gigabytes, so SchemaFromOCILabels rejects anything larger.
+const ociMaxSchema = 8 << 20
+
+// ErrNoSchemaLabel is returned by SchemaFromOCILabels when the labels carry
+// no MTP schema.
+var ErrNoSchemaLabel = errors.New("mtp: no " + OCILabelSchema + " label")
+
+// ToOCILabels encodes schema as image labels, so a registry or host can
+// discover a containerized tool from its image config without running it.
+// Pass the result to docker build --label or a buildkit/ko/jib config.
+func ToOCILabels(schema *ToolSchema) (map[string]string, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) <= ociMaxLabelValue {
+		return map[string]string{OCILabelSchema: string(data)}, nil
+	}
+
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	labels := map[string]string{OCILabelEncoding: ociEncodingGzip}
+	if len(encoded) <= ociMaxLabelValue {
+		labels[OCILabelSchema] = encoded
+		return labels, nil
+	}
+
+	n := 0
+	for ; len(encoded) > 0; n++ {
+		size := min(len(encoded), ociMaxLabelValue)
+		labels[OCILabelSchema+"."+strconv.Itoa(n)] = encoded[:size]
+		encoded = encoded[size:]
+	}
+	labels[OCILabelChunks] = strconv.Itoa(n)
+	return labels, nil
+}
+
+// SchemaFromOCILabels reassembles a schema written by ToOCILabels from an
+// image's labels (e.g. the Config.Labels of an image config or the output
+// of docker inspect). Unknown schema fields are preserved. A compressed
+// schema that expands past 8 MiB is rejected.
+func SchemaFromOCILabels(labels map[string]string) (*ToolSchema, error) {
+	payload, ok := labels[OCILabelSchema]
+	if countStr, chunked := labels[OCILabelChunks]; chunked {
+		count, err := strconv.Atoi(countStr)
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("invalid %s label %q", OCILabelChunks, countStr)
+		}
+		var b bytes.Buffer
+		for i := 0; i < count; i++ {
+			chunk, ok := labels[OCILabelSchema+"."+strconv.Itoa(i)]
+			if !ok {
+				return nil, fmt.Errorf("missing schema label chunk %d of %d", i, count)
+			}
+			b.WriteString(chunk)
+		}
+		payload, ok = b.String(), true
+	}
+	if !ok {
+		return nil, ErrNoSchemaLabel
+	}
+
+	data := []byte(payload)
+	switch enc := labels[OCILabelEncoding]; enc {
+	case "":
+	case ociEncodingGzip:
+		raw, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, fmt.Errorf("decoding schema label: %w", err)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("decompressing schema label: %w", err)
+		}
+		if data, err = io.ReadAll(io.LimitReader(zr, ociMaxSchema+1)); err != nil {
+			return nil, fmt.Errorf("decompressing schema label: %w", err)
+		}
+		if len(data) > ociMaxSchema {
+			return nil, fmt.Errorf("decompressing schema label: schema is larger than %d bytes", ociMaxSchema)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported %s %q", OCILabelEncoding, enc)
+	}
+
+	return ParseSchema(data, ParseOptions{PreserveUnknown: true})
+}
@@ -0,0 +1,81 @@
+package mtp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// ── OCI label tests ──────────────────────────────────────────────────
+
+func TestOCILabelsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		schema     *ToolSchema
+		encoding   string
+		wantChunks bool
+	}{
+		{"plain", Describe(newLargeTree(1, 1, 2, 1), nil), "", false},
+		{"compressed", Describe(newLargeTree(5, 5, 4, 5), nil), ociEncodingGzip, false},
+		{"chunked", Describe(newLargeTree(40, 10, 8, 10), nil), ociEncodingGzip, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, err := ToOCILabels(tt.schema)
+			if err != nil {
+				t.Fatalf("ToOCILabels failed: %v", err)
+			}
+			if labels[OCILabelEncoding] != tt.encoding {
+				t.Errorf("encoding = %q, want %q", labels[OCILabelEncoding], tt.encoding)
+			}
+			if _, chunked := labels[OCILabelChunks]; chunked != tt.wantChunks {
+				t.Errorf("chunked = %v, want %v", chunked, tt.wantChunks)
+			}
+			for k, v := range labels {
+				if len(v) > ociMaxLabelValue {
+					t.Errorf("label %s is %d bytes", k, len(v))
+				}
+			}
+
+			got, err := SchemaFromOCILabels(labels)
+			if err != nil {
+				t.Fatalf("SchemaFromOCILabels failed: %v", err)
+			}
+			want, _ := json.Marshal(tt.schema)
+			have, _ := json.Marshal(got)
+			if string(want) != string(have) {
+				t.Error("schema changed in round trip")
+			}
+		})
+	}
+}
+
+func TestSchemaFromOCILabelsErrors(t *testing.T) {
+	if _, err := SchemaFromOCILabels(map[string]string{"other": "x"}); !errors.Is(err, ErrNoSchemaLabel) {
+		t.Errorf("expected ErrNoSchemaLabel, got %v", err)
+	}
+	missing := map[string]string{OCILabelChunks: "2", OCILabelSchema + ".0": "abc", OCILabelEncoding: ociEncodingGzip}
+	if _, err := SchemaFromOCILabels(missing); err == nil {
+		t.Error("expected error for missing chunk")
+	}
+	unknown := map[string]string{OCILabelSchema: "{}", OCILabelEncoding: "zstd"}
+	if _, err := SchemaFromOCILabels(unknown); err == nil {
+		t.Error("expected error for unknown encoding")
+	}
+
+	var bomb bytes.Buffer
+	zw := gzip.NewWriter(&bomb)
+	zw.Write([]byte(`{"name":"` + strings.Repeat("a", ociMaxSchema) + `"}`))
+	zw.Close()
+	huge := map[string]string{
+		OCILabelSchema:   base64.StdEncoding.EncodeToString(bomb.Bytes()),
+		OCILabelEncoding: ociEncodingGzip,
+	}
+	if _, err := SchemaFromOCILabels(huge); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("expected size error for oversized label, got %v", err)
+	}
+}
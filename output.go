@@ -0,0 +1,49 @@
+package mtp
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// The machine-output convention WithJSONOutput installs.
+const (
+	OutputFlag = "output"
+	OutputJSON = "json"
+	OutputText = "text"
+)
+
+// WithJSONOutput adds a persistent --output flag to root, taking "text"
+// (the default) or "json", so every command offers machine-readable output
+// the same way. It appears in the schema as an enum on every command.
+// Commands read it with WantsJSON.
+//
+// A command that defines its own --output flag shadows this one.
+func WithJSONOutput(root *cobra.Command) {
+	v := outputValue(OutputText)
+	root.PersistentFlags().Var(&v, OutputFlag, "Output format: text or json")
+	f := root.PersistentFlags().Lookup(OutputFlag)
+	f.Annotations = map[string][]string{"values": {OutputText, OutputJSON}}
+}
+
+// WantsJSON reports whether cmd was run with --output json.
+func WantsJSON(cmd *cobra.Command) bool {
+	f := cmd.Flags().Lookup(OutputFlag)
+	return f != nil && f.Value.String() == OutputJSON
+}
+
+// outputValue is the --output flag's value, rejecting unknown formats at
+// parse time.
+type outputValue string
+
+func (v *outputValue) String() string { return string(*v) }
+
+func (v *outputValue) Set(s string) error {
+	if s != OutputText && s != OutputJSON {
+		return fmt.Errorf("must be %s or %s", OutputText, OutputJSON)
+	}
+	*v = outputValue(s)
+	return nil
+}
+
+func (v *outputValue) Type() string { return "string" }
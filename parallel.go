@@ -0,0 +1,67 @@
+package mtp
+
+import (
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// walkParallel extracts the subtrees rooted at subs on a pool of workers
+// and concatenates their results in the order of subs, so the output is
+// identical to a sequential walk.
+//
+// Each subtree is walked by a single worker, so the only state workers
+// share is the parent chain above subs, which they must only read. pflag
+// sorts and caches a flag set's flags on first visit; doing that here,
+// before the workers start, leaves the shared flag sets read-only for the
+// rest of the walk, as long as nothing merges them. Cobra's InheritedFlags,
+// LocalFlags, NonInheritedFlags, and LocalNonPersistentFlags all do: they
+// add the ancestors' persistent flags to the command's own set, and pflag
+// rewrites each added flag's Name as it goes. So the walk must never call
+// them; read a command's flags with collectFlags instead.
+// TestDescribeDoesNotMergeFlags checks this.
+func walkParallel(parent *cobra.Command, subs []*cobra.Command, prefix string, opts *DescribeOptions) []CommandDescriptor {
+	for c := parent; c != nil; c = c.Parent() {
+		c.PersistentFlags().VisitAll(func(*pflag.Flag) {})
+	}
+
+	results := make([][]CommandDescriptor, len(subs))
+	jobs := make(chan int)
+
+	workers := opts.Parallelism
+	if workers > len(subs) {
+		workers = len(subs)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = walkCommands(subs[i], childName(prefix, subs[i]), opts)
+			}
+		}()
+	}
+	for i := range subs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var commands []CommandDescriptor
+	for _, r := range results {
+		commands = append(commands, r...)
+	}
+	return commands
+}
+
+// childName returns the schema name of sub, a child of the command named
+// prefix ("" for the root).
+func childName(prefix string, sub *cobra.Command) string {
+	if prefix == "" {
+		return sub.Name()
+	}
+	return prefix + " " + sub.Name()
+}
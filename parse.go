@@ -0,0 +1,304 @@
+package mtp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ParseOptions controls ParseSchema.
+type ParseOptions struct {
+	// Strict rejects fields the schema types don't define.
+	Strict bool
+	// PreserveUnknown keeps unknown fields of every object in the schema,
+	// from ToolSchema down to a Download or an ErrorCode, in its type's
+	// Extensions map, so that re-encoding the schema emits them again.
+	// Ignored when Strict is set.
+	PreserveUnknown bool
+}
+
+// ParseSchema decodes a --mtp-describe document.
+//
+// By default unknown fields are ignored, as with json.Unmarshal. Registries
+// and proxies that re-publish schemas should set PreserveUnknown so vendor
+// extensions survive a round trip; validators should set Strict.
+func ParseSchema(data []byte, opts ParseOptions) (*ToolSchema, error) {
+	var schema ToolSchema
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if opts.Strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("parsing schema: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("parsing schema: unexpected data after schema")
+	}
+
+	if opts.PreserveUnknown && !opts.Strict {
+		if err := collectExtensions(data, reflect.ValueOf(&schema).Elem()); err != nil {
+			return nil, fmt.Errorf("parsing schema: %w", err)
+		}
+	}
+	for i := range schema.Commands {
+		schema.Commands[i].Path = commandPath(schema.Name, schema.Commands[i])
+	}
+	return &schema, nil
+}
+
+// collectExtensions stores the keys of the JSON object data that v's type
+// doesn't define in v's Extensions field, and recurses into struct-valued
+// fields so nested objects get the same treatment.
+func collectExtensions(data []byte, v reflect.Value) error {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	known := jsonFields(v.Type())
+	var ext map[string]json.RawMessage
+	for key, raw := range obj {
+		idx, ok := fieldIndex(known, key)
+		if !ok {
+			if ext == nil {
+				ext = map[string]json.RawMessage{}
+			}
+			ext[key] = raw
+			continue
+		}
+		if err := collectNested(raw, v.Field(idx)); err != nil {
+			return err
+		}
+	}
+
+	if f := v.FieldByName("Extensions"); f.IsValid() && ext != nil {
+		f.Set(reflect.ValueOf(ext))
+	}
+	return nil
+}
+
+// collectNested descends into a struct, pointer-to-struct, or slice-of-struct
+// field. Other kinds hold no objects that can carry extensions.
+func collectNested(raw json.RawMessage, f reflect.Value) error {
+	switch f.Kind() {
+	case reflect.Struct:
+		return collectExtensions(raw, f)
+	case reflect.Pointer:
+		if f.IsNil() || f.Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		return collectExtensions(raw, f.Elem())
+	case reflect.Slice:
+		if f.Type().Elem().Kind() != reflect.Struct {
+			return nil
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		for i := 0; i < len(items) && i < f.Len(); i++ {
+			if err := collectExtensions(items[i], f.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonFields maps each JSON key of struct type t to its field index.
+func jsonFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" || !t.Field(i).IsExported() {
+			continue
+		}
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+// fieldIndex returns the index of the field that decodes key, from a
+// jsonFields map. Like encoding/json, it prefers an exact match and falls
+// back to a case-insensitive one, so "Description" isn't taken for an
+// extension.
+func fieldIndex(known map[string]int, key string) (int, bool) {
+	if i, ok := known[key]; ok {
+		return i, true
+	}
+	for name, i := range known {
+		if strings.EqualFold(name, key) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// marshalWithExtensions appends ext's keys, sorted, to the JSON object
+// encoding of v. Keys that v already encodes win over extensions.
+func marshalWithExtensions(v any, ext map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(ext) == 0 {
+		return data, err
+	}
+
+	known := jsonFields(reflect.TypeOf(v))
+	keys := make([]string, 0, len(ext))
+	for k := range ext {
+		if _, ok := fieldIndex(known, k); !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for _, k := range keys {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(k)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(ext[k])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalJSON encodes the schema along with any preserved extensions.
+func (s ToolSchema) MarshalJSON() ([]byte, error) {
+	type plain ToolSchema
+	return marshalWithExtensions(plain(s), s.Extensions)
+}
+
+// MarshalJSON encodes the command along with any preserved extensions. A
+// nil Path is derived from the name, so hand-built descriptors still
+// encode a path. Examples whose CommandFile couldn't be loaded are left
+// out rather than encoded with an empty command; ValidateSchema reports
+// them.
+func (c CommandDescriptor) MarshalJSON() ([]byte, error) {
+	type plain CommandDescriptor
+	if c.Path == nil {
+		c.Path = commandPath("", c)
+	}
+	c.Examples = slices.DeleteFunc(slices.Clone(c.Examples), func(ex Example) bool {
+		return ex.Command == "" && ex.CommandFile != ""
+	})
+	return marshalWithExtensions(plain(c), c.Extensions)
+}
+
+// MarshalJSON encodes the argument along with any preserved extensions.
+func (a ArgDescriptor) MarshalJSON() ([]byte, error) {
+	type plain ArgDescriptor
+	return marshalWithExtensions(plain(a), a.Extensions)
+}
+
+// MarshalJSON encodes the stream descriptor along with any preserved
+// extensions.
+func (d IODescriptor) MarshalJSON() ([]byte, error) {
+	type plain IODescriptor
+	return marshalWithExtensions(plain(d), d.Extensions)
+}
+
+// MarshalJSON encodes the example along with any preserved extensions.
+func (e Example) MarshalJSON() ([]byte, error) {
+	type plain Example
+	return marshalWithExtensions(plain(e), e.Extensions)
+}
+
+// MarshalJSON encodes the auth config along with any preserved extensions.
+func (a AuthConfig) MarshalJSON() ([]byte, error) {
+	type plain AuthConfig
+	return marshalWithExtensions(plain(a), a.Extensions)
+}
+
+// MarshalJSON encodes the provider along with any preserved extensions.
+func (p AuthProvider) MarshalJSON() ([]byte, error) {
+	type plain AuthProvider
+	return marshalWithExtensions(plain(p), p.Extensions)
+}
+
+// MarshalJSON encodes the deprecation along with any preserved extensions.
+func (d Deprecation) MarshalJSON() ([]byte, error) {
+	type plain Deprecation
+	return marshalWithExtensions(plain(d), d.Extensions)
+}
+
+// MarshalJSON encodes the command auth along with any preserved extensions.
+func (a CommandAuth) MarshalJSON() ([]byte, error) {
+	type plain CommandAuth
+	return marshalWithExtensions(plain(a), a.Extensions)
+}
+
+// MarshalJSON encodes the requirements along with any preserved extensions.
+func (r Requirements) MarshalJSON() ([]byte, error) {
+	type plain Requirements
+	return marshalWithExtensions(plain(r), r.Extensions)
+}
+
+// MarshalJSON encodes the dependency along with any preserved extensions.
+func (d Dependency) MarshalJSON() ([]byte, error) {
+	type plain Dependency
+	return marshalWithExtensions(plain(d), d.Extensions)
+}
+
+// MarshalJSON encodes the filesystem access along with any preserved
+// extensions.
+func (f FilesystemAccess) MarshalJSON() ([]byte, error) {
+	type plain FilesystemAccess
+	return marshalWithExtensions(plain(f), f.Extensions)
+}
+
+// MarshalJSON encodes the cost along with any preserved extensions.
+func (c Cost) MarshalJSON() ([]byte, error) {
+	type plain Cost
+	return marshalWithExtensions(plain(c), c.Extensions)
+}
+
+// MarshalJSON encodes the credential source along with any preserved
+// extensions.
+func (s CredentialSource) MarshalJSON() ([]byte, error) {
+	type plain CredentialSource
+	return marshalWithExtensions(plain(s), s.Extensions)
+}
+
+// MarshalJSON encodes the environment along with any preserved extensions.
+func (e AuthEnvironment) MarshalJSON() ([]byte, error) {
+	type plain AuthEnvironment
+	return marshalWithExtensions(plain(e), e.Extensions)
+}
+
+// MarshalJSON encodes the install info along with any preserved extensions.
+func (i InstallInfo) MarshalJSON() ([]byte, error) {
+	type plain InstallInfo
+	return marshalWithExtensions(plain(i), i.Extensions)
+}
+
+// MarshalJSON encodes the download along with any preserved extensions.
+func (d Download) MarshalJSON() ([]byte, error) {
+	type plain Download
+	return marshalWithExtensions(plain(d), d.Extensions)
+}
+
+// MarshalJSON encodes the changelog entry along with any preserved
+// extensions.
+func (e ChangeEntry) MarshalJSON() ([]byte, error) {
+	type plain ChangeEntry
+	return marshalWithExtensions(plain(e), e.Extensions)
+}
+
+// MarshalJSON encodes the error code along with any preserved extensions.
+func (c ErrorCode) MarshalJSON() ([]byte, error) {
+	type plain ErrorCode
+	return marshalWithExtensions(plain(c), c.Extensions)
+}
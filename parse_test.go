@@ -0,0 +1,238 @@
+package mtp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const extendedSchema = `{
+  "specVersion": "2026-02-07",
+  "name": "tool",
+  "version": "1.0.0",
+  "description": "A tool",
+  "x-vendor": {"team": "infra"},
+  "commands": [
+    {
+      "name": "run",
+      "description": "Run it",
+      "x-cost": 3,
+      "args": [{"name": "--fast", "type": "boolean", "x-hint": "prefer"}],
+      "stdout": {"contentType": "application/json", "x-stream": true},
+      "examples": [{"command": "tool run", "x-verified": "2026-01-01"}]
+    }
+  ],
+  "auth": {
+    "envVar": "TOOL_TOKEN",
+    "x-vendor": "acme",
+    "providers": [{"id": "env", "type": "api-key", "x-portal": "https://acme.example"}]
+  }
+}`
+
+// ── ParseSchema tests ────────────────────────────────────────────────
+
+func TestParseSchemaIgnoresUnknownByDefault(t *testing.T) {
+	schema, err := ParseSchema([]byte(extendedSchema), ParseOptions{})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if schema.Name != "tool" || len(schema.Commands) != 1 {
+		t.Errorf("unexpected schema: %+v", schema)
+	}
+	if schema.Extensions != nil {
+		t.Errorf("extensions should only be kept when asked: %v", schema.Extensions)
+	}
+}
+
+func TestParseSchemaStrictRejectsUnknown(t *testing.T) {
+	_, err := ParseSchema([]byte(extendedSchema), ParseOptions{Strict: true})
+	if err == nil || !strings.Contains(err.Error(), "x-vendor") {
+		t.Errorf("expected unknown field error, got %v", err)
+	}
+
+	valid, _ := json.Marshal(Describe(newBulkyTool(), nil))
+	if _, err := ParseSchema(valid, ParseOptions{Strict: true}); err != nil {
+		t.Errorf("strict parse of a generated schema failed: %v", err)
+	}
+}
+
+func TestParseSchemaRejectsTrailingData(t *testing.T) {
+	if _, err := ParseSchema([]byte(`{"name":"a"} {"name":"b"}`), ParseOptions{}); err == nil {
+		t.Error("expected error for trailing data")
+	}
+}
+
+func TestParseSchemaPreservesUnknown(t *testing.T) {
+	schema, err := ParseSchema([]byte(extendedSchema), ParseOptions{PreserveUnknown: true})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if string(schema.Extensions["x-vendor"]) != `{"team": "infra"}` {
+		t.Errorf("tool extension = %s", schema.Extensions["x-vendor"])
+	}
+	if string(schema.Commands[0].Extensions["x-cost"]) != "3" {
+		t.Errorf("command extension = %s", schema.Commands[0].Extensions["x-cost"])
+	}
+	if string(schema.Commands[0].Args[0].Extensions["x-hint"]) != `"prefer"` {
+		t.Errorf("arg extension = %s", schema.Commands[0].Args[0].Extensions["x-hint"])
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	again, err := ParseSchema(data, ParseOptions{PreserveUnknown: true})
+	if err != nil {
+		t.Fatalf("re-parse failed: %v", err)
+	}
+	if string(again.Commands[0].Args[0].Extensions["x-hint"]) != `"prefer"` {
+		t.Errorf("extension lost in round trip: %s", data)
+	}
+	nested := map[string]json.RawMessage{
+		"stdout":   again.Commands[0].Stdout.Extensions["x-stream"],
+		"example":  again.Commands[0].Examples[0].Extensions["x-verified"],
+		"auth":     again.Auth.Extensions["x-vendor"],
+		"provider": again.Auth.Providers[0].Extensions["x-portal"],
+	}
+	want := map[string]string{
+		"stdout":   "true",
+		"example":  `"2026-01-01"`,
+		"auth":     `"acme"`,
+		"provider": `"https://acme.example"`,
+	}
+	for where, raw := range nested {
+		if string(raw) != want[where] {
+			t.Errorf("%s extension lost in round trip: got %s in %s", where, raw, data)
+		}
+	}
+	if diags := ValidateAgainstSpec(data); len(diags) != 0 {
+		t.Errorf("round-tripped schema fails the spec: %v", diags)
+	}
+}
+
+// nestedExtensionsSchema puts an x- key on every kind of object a schema
+// can contain.
+const nestedExtensionsSchema = `{
+  "specVersion": "2026-02-07",
+  "name": "tool",
+  "version": "1.0.0",
+  "description": "A tool",
+  "requires": {"os": ["linux"], "x-a": 1, "dependencies": [{"name": "git", "x-b": 2}]},
+  "install": {"brew": "tool", "x-c": 3, "downloads": [{"os": "linux", "arch": "amd64", "url": "https://example.com/t", "sha256": "abababababababababababababababababababababababababababababababab", "x-d": 4}]},
+  "changelog": [{"version": "1.0.0", "changes": ["first"], "x-e": 5}],
+  "errorCodes": [{"code": "NOT_FOUND", "x-f": 6}],
+  "auth": {
+    "envVar": "TOOL_TOKEN",
+    "providers": [{"id": "env", "type": "api-key"}],
+    "environments": [{"name": "prod", "x-g": 7}],
+    "credentialSources": [{"type": "env", "envVar": "TOOL_TOKEN", "x-h": 8}]
+  },
+  "commands": [{
+    "name": "run",
+    "description": "Run it",
+    "auth": {"required": true, "x-i": 9},
+    "cost": {"billable": true, "x-j": 10},
+    "deprecated": {"message": "use go", "x-k": 11},
+    "filesystem": {"reads": ["./"], "x-l": 12}
+  }]
+}`
+
+func TestParseSchemaPreservesNestedExtensions(t *testing.T) {
+	schema, err := ParseSchema([]byte(nestedExtensionsSchema), ParseOptions{PreserveUnknown: true})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	want, got := map[string]string{}, map[string]string{}
+	var in, out any
+	json.Unmarshal([]byte(nestedExtensionsSchema), &in)
+	json.Unmarshal(data, &out)
+	extensionPaths(in, "", want)
+	extensionPaths(out, "", got)
+	if len(want) != 12 || !reflect.DeepEqual(got, want) {
+		t.Errorf("extensions changed in round trip:\nwant %v\ngot  %v", want, got)
+	}
+	if diags := ValidateAgainstSpec(data); len(diags) != 0 {
+		t.Errorf("round-tripped schema fails the spec: %v", diags)
+	}
+}
+
+// extensionPaths records the value of every x- key in the decoded JSON v by
+// its path.
+func extensionPaths(v any, path string, out map[string]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if strings.HasPrefix(k, "x-") {
+				out[path+"."+k] = fmt.Sprint(child)
+				continue
+			}
+			extensionPaths(child, path+"."+k, out)
+		}
+	case []any:
+		for i, child := range v {
+			extensionPaths(child, fmt.Sprintf("%s[%d]", path, i), out)
+		}
+	}
+}
+
+func TestEverySchemaObjectHasExtensions(t *testing.T) {
+	seen := map[reflect.Type]bool{}
+	var visit func(reflect.Type)
+	visit = func(typ reflect.Type) {
+		for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct || seen[typ] {
+			return
+		}
+		seen[typ] = true
+		if _, ok := typ.FieldByName("Extensions"); !ok {
+			t.Errorf("%s has no Extensions field, so its unknown fields are dropped", typ.Name())
+		}
+		if _, ok := typ.MethodByName("MarshalJSON"); !ok {
+			t.Errorf("%s has no MarshalJSON, so its Extensions aren't encoded", typ.Name())
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			if typ.Field(i).Tag.Get("json") != "-" {
+				visit(typ.Field(i).Type)
+			}
+		}
+	}
+	visit(reflect.TypeOf(ToolSchema{}))
+}
+
+func TestParseSchemaMatchesKeysCaseInsensitively(t *testing.T) {
+	doc := `{"name": "tool", "Description": "A tool", "commands": [{"NAME": "run", "x-cost": 1}]}`
+	schema, err := ParseSchema([]byte(doc), ParseOptions{PreserveUnknown: true})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if schema.Description != "A tool" || len(schema.Extensions) != 0 {
+		t.Errorf("Description decoded as %q with extensions %v", schema.Description, schema.Extensions)
+	}
+	if cmd := schema.Commands[0]; cmd.Name != "run" || len(cmd.Extensions) != 1 {
+		t.Errorf("command = %q with extensions %v", cmd.Name, cmd.Extensions)
+	}
+	data, _ := json.Marshal(schema)
+	if strings.Contains(string(data), `"Description"`) || strings.Contains(string(data), `"NAME"`) {
+		t.Errorf("a known field was re-emitted as an extension: %s", data)
+	}
+}
+
+func TestExtensionsCannotOverrideKnownFields(t *testing.T) {
+	cmd := CommandDescriptor{
+		Name:       "run",
+		Extensions: map[string]json.RawMessage{"name": json.RawMessage(`"evil"`)},
+	}
+	data, _ := json.Marshal(cmd)
+	if strings.Contains(string(data), "evil") {
+		t.Errorf("extension overrode a known field: %s", data)
+	}
+}
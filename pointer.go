@@ -0,0 +1,115 @@
+package mtp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pointerEscapes unescapes a JSON Pointer reference token.
+var pointerEscapes = strings.NewReplacer("~1", "/", "~0", "~")
+
+// splitPointer splits a JSON Pointer (RFC 6901) into its unescaped
+// reference tokens. The empty pointer, the whole document, has none.
+func splitPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("mtp: JSON Pointer %q doesn't start with \"/\"", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, tok := range tokens {
+		for j := 0; j < len(tok); j++ {
+			if tok[j] != '~' {
+				continue
+			}
+			if j+1 == len(tok) || (tok[j+1] != '0' && tok[j+1] != '1') {
+				return nil, fmt.Errorf("mtp: JSON Pointer %q has an invalid escape", pointer)
+			}
+			j++
+		}
+		tokens[i] = pointerEscapes.Replace(tok)
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses a reference token as an array index: digits without a
+// leading zero.
+func arrayIndex(tok string) (int, bool) {
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') || strings.TrimLeft(tok, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(tok)
+	return n, err == nil
+}
+
+// ResolvePointer returns the value at pointer in doc, a decoded JSON
+// document. Agents chaining commands use it with an IODescriptor's
+// OutputPointers to pick one command's output value for the next's args:
+//
+//	var out any
+//	json.Unmarshal(stdout, &out)
+//	id, err := mtp.ResolvePointer(out, cmd.Stdout.OutputPointers["id"])
+func ResolvePointer(doc any, pointer string) (any, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	v := doc
+	for i, tok := range tokens {
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[tok]; !ok {
+				return nil, fmt.Errorf("mtp: %s: no field %q", pointer, tok)
+			}
+		case []any:
+			n, ok := arrayIndex(tok)
+			if !ok || n >= len(node) {
+				return nil, fmt.Errorf("mtp: %s: no element %q in an array of %d", pointer, tok, len(node))
+			}
+			v = node[n]
+		default:
+			return nil, fmt.Errorf("mtp: %s: %q is not an object or array", pointer, "/"+strings.Join(tokens[:i], "/"))
+		}
+	}
+	return v, nil
+}
+
+// schemaAt follows tokens through a JSON Schema's properties, items, and
+// additionalProperties, and $refs into defs. It reports the first token the
+// schema declares no place for, and stops without error where the schema
+// doesn't say what's below (no properties or items), since such values may
+// hold anything.
+func schemaAt(schema map[string]any, tokens []string, defs map[string]map[string]any) error {
+	for _, tok := range tokens {
+		// Bounded, since definitions may refer to each other in a loop.
+		for hops := 0; hops <= len(defs); hops++ {
+			name, ok := definitionRef(schema)
+			if !ok || defs[name] == nil {
+				break
+			}
+			schema = defs[name]
+		}
+		props, hasProps := schema["properties"].(map[string]any)
+		if sub, ok := props[tok].(map[string]any); ok {
+			schema = sub
+			continue
+		}
+		items, hasItems := schema["items"].(map[string]any)
+		if _, isIndex := arrayIndex(tok); hasItems && isIndex {
+			schema = items
+			continue
+		}
+		if add, ok := schema["additionalProperties"].(map[string]any); ok {
+			schema = add
+			continue
+		}
+		if hasProps || hasItems {
+			return fmt.Errorf("the schema declares no %q", tok)
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package mtp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestResolvePointer(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(`{"result": {"id": "j-1", "items": [{"a/b": 1}, {"~x": 2}]}}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for pointer, want := range map[string]any{
+		"/result/id":            "j-1",
+		"/result/items/0/a~1b":  1.0,
+		"/result/items/1/~0x":   2.0,
+		"/result/items/1/~0x/z": nil,
+		"/result/items/01":      nil,
+		"/result/items/2":       nil,
+		"/result/missing":       nil,
+		"result":                nil,
+		"/result/id~2":          nil,
+	} {
+		got, err := ResolvePointer(doc, pointer)
+		if want == nil {
+			if err == nil {
+				t.Errorf("%s: got %v, want an error", pointer, got)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("%s: got %v, %v, want %v", pointer, got, err, want)
+		}
+	}
+	if got, err := ResolvePointer(doc, ""); err != nil || got == nil {
+		t.Errorf("empty pointer: got %v, %v, want the document", got, err)
+	}
+}
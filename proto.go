@@ -0,0 +1,184 @@
+package mtp
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ToProto returns a proto3 file describing schema as an RPC service: one
+// rpc per command, taking a request message with a field per argument and
+// returning the command's exit code and output. Enum arguments become
+// nested proto enums whose zero value means "not set", repeated for array
+// and variadic arguments. Optional scalar
+// arguments use proto3 `optional` so a server can tell an omitted flag
+// from its zero value.
+func ToProto(schema *ToolSchema) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Generated from the MTP schema for %s %s.\n", schema.Name, schema.Version)
+	b.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&b, "package %s;\n", protoSnake(schema.Name))
+
+	service := protoPascal(schema.Name)
+	messages := map[string]int{}
+	rpcs := make([]string, 0, len(schema.Commands))
+
+	for _, cmd := range schema.Commands {
+		name := service
+		if !isRootCommand(schema.Name, cmd) {
+			name = protoPascal(cmd.Name)
+		}
+		name = uniqueIdent(messages, name)
+
+		desc := cmd.Description
+		if desc == "" {
+			desc = schema.Description
+		}
+
+		b.WriteString("\n")
+		writeProtoComment(&b, "", desc)
+		fmt.Fprintf(&b, "message %sRequest {\n", name)
+		fields := map[string]int{}
+		for i, arg := range cmd.Args {
+			field := uniqueIdent(fields, protoSnake(argKey(arg)))
+			typ, label := protoType(arg)
+			if len(arg.Values) > 0 {
+				typ = protoPascal(field)
+				writeProtoEnum(&b, typ, arg.Values)
+				if label == "optional " {
+					label = "" // The zero value already means "not set"
+				}
+			}
+			writeProtoComment(&b, "  ", protoArgComment(arg))
+			fmt.Fprintf(&b, "  %s%s %s = %d;\n", label, typ, field, i+1)
+		}
+		b.WriteString("}\n\n")
+
+		fmt.Fprintf(&b, "message %sResponse {\n", name)
+		b.WriteString("  int32 exit_code = 1;\n")
+		if cmd.Stdout != nil && cmd.Stdout.ContentType != "" {
+			fmt.Fprintf(&b, "  // %s\n", cmd.Stdout.ContentType)
+		}
+		b.WriteString("  bytes stdout = 2;\n")
+		b.WriteString("  bytes stderr = 3;\n")
+		b.WriteString("}\n")
+
+		var rpc strings.Builder
+		writeProtoComment(&rpc, "  ", desc)
+		fmt.Fprintf(&rpc, "  rpc %s(%sRequest) returns (%sResponse);\n", name, name, name)
+		rpcs = append(rpcs, rpc.String())
+	}
+
+	b.WriteString("\n")
+	writeProtoComment(&b, "", schema.Description)
+	fmt.Fprintf(&b, "service %s {\n", service)
+	for _, rpc := range rpcs {
+		b.WriteString(rpc)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// protoType returns the proto3 type and field label for an argument.
+// Arrays and variadic positionals are repeated strings; objects are
+// carried as JSON text.
+func protoType(arg ArgDescriptor) (typ, label string) {
+	if arg.Type == "array" || arg.Variadic {
+		return "string", "repeated "
+	}
+	switch arg.Type {
+	case "integer":
+		typ = "int64"
+	case "number":
+		typ = "double"
+	case "boolean":
+		typ = "bool"
+	default:
+		typ = "string"
+	}
+	if !arg.Required {
+		label = "optional "
+	}
+	return typ, label
+}
+
+// writeProtoEnum writes a nested enum for an argument's allowed values.
+// Values are prefixed with the enum name, as proto3 enum values share
+// their parent's scope.
+func writeProtoEnum(b *strings.Builder, name string, values []string) {
+	prefix := strings.ToUpper(protoSnake(name))
+	fmt.Fprintf(b, "  enum %s {\n", name)
+	fmt.Fprintf(b, "    %s_UNSPECIFIED = 0;\n", prefix)
+	seen := map[string]int{prefix + "_UNSPECIFIED": 1}
+	for i, v := range values {
+		suffix := strings.ToUpper(strings.Trim(nonIdentChars.ReplaceAllString(v, "_"), "_"))
+		if suffix == "" {
+			suffix = "EMPTY"
+		}
+		ident := uniqueIdent(seen, prefix+"_"+suffix)
+		fmt.Fprintf(b, "    %s = %d; // %s\n", ident, i+1, mustJSON(v))
+	}
+	b.WriteString("  }\n")
+}
+
+// protoArgComment describes an argument, noting the flag it maps to and
+// its default.
+func protoArgComment(arg ArgDescriptor) string {
+	parts := []string{}
+	if d := argDescription(arg); d != "" {
+		parts = append(parts, d)
+	}
+	if isFlag(arg) {
+		parts = append(parts, "("+arg.Name+")")
+	}
+	if def := typedDefault(arg); def != nil {
+		parts = append(parts, "Default: "+mustJSON(def)+".")
+	}
+	if arg.Type == "object" {
+		parts = append(parts, "JSON object.")
+	}
+	return strings.Join(parts, " ")
+}
+
+// writeProtoComment writes s as a // comment, one line per input line.
+func writeProtoComment(b *strings.Builder, indent, s string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return
+	}
+	for _, line := range strings.Split(s, "\n") {
+		fmt.Fprintf(b, "%s// %s\n", indent, strings.TrimRightFunc(line, unicode.IsSpace))
+	}
+}
+
+// protoSnake converts a name to a lower snake_case identifier.
+func protoSnake(s string) string {
+	s = strings.ToLower(strings.Trim(nonIdentChars.ReplaceAllString(s, "_"), "_"))
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "x_" + s
+	}
+	return s
+}
+
+// protoPascal converts a name to a PascalCase identifier.
+func protoPascal(s string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(protoSnake(s), "_") {
+		if word == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// uniqueIdent returns name, or name with a numeric suffix if it's already
+// in seen, and records the result.
+func uniqueIdent(seen map[string]int, name string) string {
+	seen[name]++
+	if n := seen[name]; n > 1 {
+		name = fmt.Sprintf("%s%d", name, n)
+		seen[name]++
+	}
+	return name
+}
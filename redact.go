@@ -0,0 +1,263 @@
+package mtp
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// redacted replaces secret values in schema output.
+const redacted = "***"
+
+// sensitiveWords mark a flag or variable name as holding a secret when its
+// last dash-separated segments spell one of them.
+var sensitiveWords = []string{
+	"token", "password", "passwd", "secret", "secret-key", "apikey", "api-key",
+	"credential", "credentials", "private-key", "access-key",
+}
+
+// isSensitiveName reports whether a flag or env var name looks like it holds
+// a secret (e.g. "api-key", "GITHUB_TOKEN", "db-password", "apiToken").
+// Names are compared by whole segments at the end, so "--max-tokens",
+// "--token-count", and "--token-file" are not secrets.
+func isSensitiveName(name string) bool {
+	name = "-" + strings.ToLower(camelBoundary.ReplaceAllString(name, "$1-$2"))
+	name = strings.ReplaceAll(name, "_", "-")
+	for _, w := range sensitiveWords {
+		if strings.HasSuffix(name, "-"+w) {
+			return true
+		}
+	}
+	return false
+}
+
+// camelBoundary matches where a camelCase name starts a new word.
+var camelBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// isSensitiveFlag reports whether a flag's default must not appear in the
+// schema: it was marked with Sensitive or its name looks like a secret's.
+func isSensitiveFlag(f *pflag.Flag) bool {
+	_, marked := f.Annotations[sensitiveAnnotation]
+	return marked || isSensitiveName(f.Name)
+}
+
+// commandRedaction describes the command an example runs, so values can
+// be masked beyond what flag names and token formats reveal: those of flags
+// marked Sensitive, by name or shorthand, and of Sensitive positionals.
+type commandRedaction struct {
+	path       []string          // Words after the tool name that select the command
+	args       []ArgDescriptor   // The command's args, flags and positionals
+	shorthands map[string]string // Flag names by shorthand, such as "p" for "pass"
+}
+
+// newCommandRedaction describes the command whose descriptor is cd, and
+// whose flags collectFlags returned, for redaction.
+func newCommandRedaction(flags []*pflag.Flag, cd *CommandDescriptor) *commandRedaction {
+	cr := &commandRedaction{path: cd.Path, args: cd.Args, shorthands: map[string]string{}}
+	for _, f := range flags {
+		if f.Shorthand != "" {
+			cr.shorthands[f.Shorthand] = f.Name
+		}
+	}
+	return cr
+}
+
+// flag returns the flag called name, or with name as its shorthand.
+func (cr *commandRedaction) flag(name string) *ArgDescriptor {
+	if cr == nil {
+		return nil
+	}
+	if long, ok := cr.shorthands[name]; ok {
+		name = long
+	}
+	for i, arg := range cr.args {
+		if isFlag(arg) && argKey(arg) == name {
+			return &cr.args[i]
+		}
+	}
+	return nil
+}
+
+// sensitiveFlag reports whether the flag called name holds a secret, by
+// its name or by a Sensitive mark.
+func (cr *commandRedaction) sensitiveFlag(name string) bool {
+	if isSensitiveName(name) {
+		return true
+	}
+	f := cr.flag(name)
+	return f != nil && (f.Sensitive || isSensitiveName(argKey(*f)))
+}
+
+// takesValue reports whether the flag called name is followed by its value.
+func (cr *commandRedaction) takesValue(name string) bool {
+	f := cr.flag(name)
+	return f != nil && f.Type != "boolean"
+}
+
+// positional returns the descriptor of the i'th positional value, or nil.
+func (cr *commandRedaction) positional(i int) *ArgDescriptor {
+	if cr == nil {
+		return nil
+	}
+	var last *ArgDescriptor
+	for j, arg := range cr.args {
+		if isFlag(arg) {
+			continue
+		}
+		if i == 0 {
+			return &cr.args[j]
+		}
+		i--
+		last = &cr.args[j]
+	}
+	if last != nil && last.Variadic {
+		return last
+	}
+	return nil
+}
+
+var (
+	// envNamePattern matches the name in a "NAME=value" shell assignment.
+	envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// tokenPattern matches well-known credential formats regardless of context.
+	tokenPattern = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|sk-[A-Za-z0-9_-]{16,}|xox[abpr]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16}|eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})\b`)
+	// bearerPattern matches "Bearer <token>" in header arguments.
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+)
+
+// redactCommand masks obvious secrets in an example command line: values
+// of sensitive flags and env assignments, bearer tokens, and well-known
+// token formats. With cr, the values of flags and positionals it marks
+// Sensitive are masked too.
+func redactCommand(cmdline string, cr *commandRedaction) string {
+	words := shellWords(cmdline)
+	var b strings.Builder
+	last := 0
+	mask := func(start, end int) {
+		b.WriteString(cmdline[last:start])
+		b.WriteString(redacted)
+		last = end
+	}
+	const (
+		atTool   = iota // Before the tool's name, past any env assignments
+		inArgs          // In the command's path and arguments
+		inOthers        // Past a shell operator, in another command
+	)
+	stage := atTool
+	pathWords, positionals, afterDashes := 0, 0, false
+	for i := 0; i < len(words); i++ {
+		start, end := words[i][0], words[i][1]
+		word := cmdline[start:end]
+		name, _, hasValue := strings.Cut(word, "=")
+		isAssignment := hasValue && envNamePattern.MatchString(name)
+		switch {
+		case isShellOperator(word):
+			stage, afterDashes = inOthers, false
+		case isAssignment && isSensitiveName(name): // NAME=value
+			mask(start+len(name)+1, end)
+			if stage == inArgs {
+				positionals++
+			}
+		case word == "--" && !afterDashes:
+			afterDashes = true
+		case strings.HasPrefix(word, "-") && len(word) > 1 && !afterDashes:
+			key := strings.TrimLeft(name, "-")
+			next := i + 1
+			switch {
+			case hasValue: // --name=value
+				if cr.sensitiveFlag(key) {
+					mask(start+len(name)+1, end)
+				}
+			case next == len(words) || isShellOperator(cmdline[words[next][0]:words[next][1]]):
+			case cr.sensitiveFlag(key): // --name value
+				i++
+				mask(words[i][0], words[i][1])
+			case stage == inArgs && cr.takesValue(key):
+				i++
+			}
+		case stage == atTool:
+			if !isAssignment {
+				stage = inArgs
+			}
+		case stage == inArgs:
+			if positionals == 0 && cr != nil && pathWords < len(cr.path) && word == cr.path[pathWords] {
+				pathWords++
+				continue
+			}
+			if arg := cr.positional(positionals); arg != nil && arg.Sensitive {
+				mask(start, end)
+			}
+			positionals++
+		}
+	}
+	b.WriteString(cmdline[last:])
+	cmdline = bearerPattern.ReplaceAllString(b.String(), "${1}"+redacted)
+	return tokenPattern.ReplaceAllString(cmdline, redacted)
+}
+
+// shellOperators end a word in a command line, and form words of their own.
+const shellOperators = "|&;<>()"
+
+// isShellOperator reports whether a word from shellWords is an operator
+// such as "|" or "&&" rather than an argument.
+func isShellOperator(word string) bool {
+	return strings.ContainsRune(shellOperators, rune(word[0]))
+}
+
+// shellWords splits a command line into words the way a shell would, and
+// returns each word's start and end offset. Quotes and backslashes keep
+// spaces and operators inside a word, and are kept in it.
+func shellWords(s string) [][2]int {
+	var words [][2]int
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+			continue
+		case strings.IndexByte(shellOperators, c) >= 0:
+			start := i
+			for i < len(s) && strings.IndexByte(shellOperators, s[i]) >= 0 {
+				i++
+			}
+			words = append(words, [2]int{start, i})
+			continue
+		}
+		start := i
+		var quote byte
+	word:
+		for ; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				} else if c == '\\' && quote == '"' && i+1 < len(s) {
+					i++
+				}
+			case c == '"' || c == '\'':
+				quote = c
+			case c == '\\' && i+1 < len(s):
+				i++
+			case c == ' ' || c == '\t' || c == '\n' || strings.IndexByte(shellOperators, c) >= 0:
+				break word
+			}
+		}
+		words = append(words, [2]int{start, i})
+	}
+	return words
+}
+
+// redactExamples returns a copy of examples with secrets masked in each
+// command.
+func redactExamples(examples []Example, cr *commandRedaction) []Example {
+	if examples == nil {
+		return nil
+	}
+	out := make([]Example, len(examples))
+	for i, ex := range examples {
+		ex.Command = redactCommand(ex.Command, cr)
+		out[i] = ex
+	}
+	return out
+}
@@ -0,0 +1,287 @@
+// Package registry implements a small MTP tool catalog: tools publish their
+// schemas to it, and agent hosts search it and fetch schemas by version.
+//
+// # HTTP API
+//
+//	POST /v1/tools                      publish a schema (body), signed via Mtp-Signature[-Time]
+//	GET  /v1/tools?q=text               search names and descriptions
+//	GET  /v1/tools/<name>               list versions, newest first
+//	GET  /v1/tools/<name>/<version>     fetch a schema; "latest" for the newest
+//
+// Schemas are served as client.MediaType with an ETag and their signature,
+// so client.FetchSchema can retrieve and verify them. Published versions
+// are immutable. Errors are JSON objects with an "error" message.
+package registry
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/modeltoolsprotocol/go-sdk/client"
+)
+
+// Server serves the registry API over HTTP.
+type Server struct {
+	Storage Storage
+	// TrustedKeys are the Ed25519 keys whose signatures are accepted on
+	// publish. When empty, any schema may be published, signed or not;
+	// only do that on a trusted network.
+	TrustedKeys []ed25519.PublicKey
+	// MaxBytes limits a published schema; client.DefaultMaxBytes when 0.
+	MaxBytes int64
+}
+
+// SearchResult is one tool in a search response, at its newest version.
+type SearchResult struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+// VersionList is the response for GET /v1/tools/<name>.
+type VersionList struct {
+	Name     string   `json:"name"`
+	Versions []string `json:"versions"` // Newest first
+}
+
+var (
+	toolName    = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	toolVersion = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.+_-]*$`)
+)
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/v1/tools")
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	if parts[0] == "" {
+		parts = nil
+	}
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		s.publish(w, r)
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		s.search(w, r)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.versions(w, r, parts[0])
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.fetch(w, r, parts[0], parts[1])
+	case len(parts) <= 2:
+		writeError(w, http.StatusMethodNotAllowed, r.Method+" not allowed")
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) publish(w http.ResponseWriter, r *http.Request) {
+	limit := s.MaxBytes
+	if limit <= 0 {
+		limit = client.DefaultMaxBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if int64(len(body)) > limit {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("schema is larger than %d bytes", limit))
+		return
+	}
+
+	var sig []byte
+	if h := r.Header.Get(client.SignatureHeader); h != "" {
+		if sig, err = base64.StdEncoding.DecodeString(h); err != nil {
+			writeError(w, http.StatusBadRequest, "malformed "+client.SignatureHeader+" header")
+			return
+		}
+	}
+	var signedAt time.Time
+	if h := r.Header.Get(client.SignatureTimeHeader); h != "" {
+		if signedAt, err = time.Parse(time.RFC3339, h); err != nil || len(sig) == 0 {
+			writeError(w, http.StatusBadRequest, "malformed "+client.SignatureTimeHeader+" header")
+			return
+		}
+	}
+	if len(s.TrustedKeys) > 0 {
+		if len(sig) == 0 {
+			writeError(w, http.StatusUnauthorized, "schema must be signed")
+			return
+		}
+		if err := client.Ed25519Verifier(s.TrustedKeys...)(client.SignedMessage(body, signedAt), sig); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+	}
+
+	schema, err := mtp.ParseSchema(body, mtp.ParseOptions{PreserveUnknown: true})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	switch {
+	case !toolName.MatchString(schema.Name):
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("invalid tool name %q", schema.Name))
+		return
+	case !toolVersion.MatchString(schema.Version) || schema.Version == "latest":
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("invalid version %q", schema.Version))
+		return
+	}
+	if diags := mtp.ValidateSchema(schema); mtp.HasErrors(diags) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(struct {
+			Error       string           `json:"error"`
+			Diagnostics []mtp.Diagnostic `json:"diagnostics"`
+		}{"schema has errors", diags})
+		return
+	}
+
+	e := &Entry{Name: schema.Name, Version: schema.Version, Schema: body, Signature: sig, SignedAt: signedAt, Published: time.Now().UTC()}
+	if err := s.Storage.Put(r.Context(), e); err != nil {
+		writeStorageError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/v1/tools/"+e.Name+"/"+e.Version)
+	writeJSON(w, http.StatusCreated, e)
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	q := strings.ToLower(r.URL.Query().Get("q"))
+	names, err := s.Storage.Names(r.Context())
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+	sort.Strings(names)
+	results := []SearchResult{}
+	for _, name := range names {
+		e, err := s.latest(r.Context(), name)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			writeStorageError(w, err)
+			return
+		}
+		var meta struct {
+			Description string `json:"description"`
+		}
+		json.Unmarshal(e.Schema, &meta)
+		if q == "" || strings.Contains(strings.ToLower(name), q) || strings.Contains(strings.ToLower(meta.Description), q) {
+			results = append(results, SearchResult{Name: name, Version: e.Version, Description: meta.Description})
+		}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Tools []SearchResult `json:"tools"`
+	}{results})
+}
+
+func (s *Server) versions(w http.ResponseWriter, r *http.Request, name string) {
+	versions, err := s.sortedVersions(r.Context(), name)
+	if err != nil {
+		writeStorageError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, VersionList{Name: name, Versions: versions})
+}
+
+func (s *Server) fetch(w http.ResponseWriter, r *http.Request, name, version string) {
+	if !toolName.MatchString(name) || !toolVersion.MatchString(version) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	var e *Entry
+	var err error
+	if version == "latest" {
+		e, err = s.latest(r.Context(), name)
+		w.Header().Set("Cache-Control", "public, max-age=60")
+	} else {
+		e, err = s.Storage.Get(r.Context(), name, version)
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	if err != nil {
+		w.Header().Del("Cache-Control")
+		writeStorageError(w, err)
+		return
+	}
+
+	sum := sha256.Sum256(e.Schema)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	if len(e.Signature) > 0 {
+		w.Header().Set(client.SignatureHeader, base64.StdEncoding.EncodeToString(e.Signature))
+		if !e.SignedAt.IsZero() {
+			w.Header().Set(client.SignatureTimeHeader, e.SignedAt.UTC().Format(time.RFC3339))
+		}
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", client.MediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(e.Schema)))
+	w.Write(e.Schema)
+}
+
+// latest returns the newest version of a tool.
+func (s *Server) latest(ctx context.Context, name string) (*Entry, error) {
+	versions, err := s.sortedVersions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.Storage.Get(ctx, name, versions[0])
+}
+
+// sortedVersions lists a tool's versions, newest first.
+func (s *Server) sortedVersions(ctx context.Context, name string) ([]string, error) {
+	if !toolName.MatchString(name) {
+		return nil, ErrNotFound
+	}
+	versions, err := s.Storage.Versions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(versions, func(i, j int) bool { return client.CompareVersions(versions[i], versions[j]) > 0 })
+	return versions, nil
+}
+
+func writeStorageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, "not found")
+	case errors.Is(err, ErrExists):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
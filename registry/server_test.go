@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	mtp "github.com/modeltoolsprotocol/go-sdk"
+	"github.com/modeltoolsprotocol/go-sdk/client"
+)
+
+func schemaJSON(name, version, description string) []byte {
+	return []byte(fmt.Sprintf(`{"specVersion":%q,"name":%q,"version":%q,"description":%q,"commands":[]}`,
+		mtp.MTPSpecVersion, name, version, description))
+}
+
+func publish(t *testing.T, url string, body []byte, key ed25519.PrivateKey) int {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url+"/v1/tools", bytes.NewReader(body))
+	if key != nil {
+		req.Header.Set(client.SignatureHeader, base64.StdEncoding.EncodeToString(ed25519.Sign(key, body)))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func getJSON(t *testing.T, url string, v any) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServer(t *testing.T) {
+	storages := map[string]Storage{
+		"memory": NewMemoryStorage(),
+		"dir":    NewDirStorage(t.TempDir()),
+	}
+	for name, storage := range storages {
+		t.Run(name, func(t *testing.T) {
+			pub, priv, _ := ed25519.GenerateKey(nil)
+			_, stranger, _ := ed25519.GenerateKey(nil)
+			srv := httptest.NewServer(&Server{Storage: storage, TrustedKeys: []ed25519.PublicKey{pub}})
+			defer srv.Close()
+
+			for _, v := range []string{"1.9.0", "1.10.0", "2.0.0-rc.1"} {
+				if code := publish(t, srv.URL, schemaJSON("deploy", v, "Ship services"), priv); code != http.StatusCreated {
+					t.Fatalf("publish %s: %d", v, code)
+				}
+			}
+			publish(t, srv.URL, schemaJSON("gh", "2.40.0", "GitHub on the command line"), priv)
+
+			if code := publish(t, srv.URL, schemaJSON("deploy", "1.9.0", "again"), priv); code != http.StatusConflict {
+				t.Errorf("republishing a version should conflict, got %d", code)
+			}
+			if code := publish(t, srv.URL, schemaJSON("evil", "1.0.0", ""), nil); code != http.StatusUnauthorized {
+				t.Errorf("unsigned publish should be refused, got %d", code)
+			}
+			if code := publish(t, srv.URL, schemaJSON("evil", "1.0.0", ""), stranger); code != http.StatusForbidden {
+				t.Errorf("publish by an untrusted key should be refused, got %d", code)
+			}
+
+			var list VersionList
+			getJSON(t, srv.URL+"/v1/tools/deploy", &list)
+			if want := []string{"2.0.0-rc.1", "1.10.0", "1.9.0"}; !slices.Equal(list.Versions, want) {
+				t.Errorf("versions %v, want %v", list.Versions, want)
+			}
+			if code := getJSON(t, srv.URL+"/v1/tools/evil", &list); code != http.StatusNotFound {
+				t.Errorf("unknown tool: %d", code)
+			}
+
+			var found struct{ Tools []SearchResult }
+			getJSON(t, srv.URL+"/v1/tools?q=github", &found)
+			if len(found.Tools) != 1 || found.Tools[0].Name != "gh" {
+				t.Errorf("search found %+v", found.Tools)
+			}
+			getJSON(t, srv.URL+"/v1/tools", &found)
+			if len(found.Tools) != 2 || found.Tools[0].Version != "2.0.0-rc.1" {
+				t.Errorf("listing found %+v", found.Tools)
+			}
+
+			opts := client.FetchOptions{VerifySignature: client.Ed25519Verifier(pub)}
+			res, err := client.FetchSchema(context.Background(), srv.URL+"/v1/tools/deploy/1.10.0", opts)
+			if err != nil || res.Schema.Version != "1.10.0" || res.ETag == "" {
+				t.Fatalf("fetch: %+v, %v", res, err)
+			}
+			opts.ETag = res.ETag
+			if res, err := client.FetchSchema(context.Background(), srv.URL+"/v1/tools/deploy/1.10.0", opts); err != nil || !res.NotModified {
+				t.Errorf("revalidation: %+v, %v", res, err)
+			}
+			res, err = client.FetchSchema(context.Background(), srv.URL+"/v1/tools/deploy/latest", client.FetchOptions{})
+			if err != nil || res.Schema.Version != "2.0.0-rc.1" {
+				t.Errorf("latest: %+v, %v", res, err)
+			}
+			if _, err := client.FetchSchema(context.Background(), srv.URL+"/v1/tools/../secrets/1.0.0", client.FetchOptions{}); err == nil {
+				t.Error("path traversal should not resolve")
+			}
+
+			signedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+			body := schemaJSON("deploy", "3.0.0", "Ship services")
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/tools", bytes.NewReader(body))
+			req.Header.Set(client.SignatureHeader, base64.StdEncoding.EncodeToString(client.Sign(priv, body, signedAt)))
+			req.Header.Set(client.SignatureTimeHeader, signedAt.Format(time.RFC3339))
+			if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusCreated {
+				t.Fatalf("publishing with a signature time: %v, %v", resp, err)
+			}
+			policy := &client.TrustPolicy{Publishers: []client.Publisher{{Name: "acme", Keys: []ed25519.PublicKey{pub}}}, MinSignatureAge: time.Minute}
+			if res, err := client.FetchSchema(context.Background(), srv.URL+"/v1/tools/deploy/3.0.0", client.FetchOptions{Trust: policy}); err != nil || !res.SignedAt.Equal(signedAt) {
+				t.Errorf("fetch with a trust policy: %+v, %v", res, err)
+			}
+		})
+	}
+}
+
+func TestPublishInvalidSchema(t *testing.T) {
+	srv := httptest.NewServer(&Server{Storage: NewMemoryStorage()})
+	defer srv.Close()
+	tests := map[string][]byte{
+		"not json":     []byte("{"),
+		"bad name":     schemaJSON("../x", "1.0.0", ""),
+		"latest":       schemaJSON("x", "latest", ""),
+		"schema error": []byte(`{"specVersion":"2026-02-07","name":"x","version":"1","description":"","commands":[],"changelog":[{"changes":[]}]}`),
+	}
+	for name, body := range tests {
+		if code := publish(t, srv.URL, body, nil); code < 400 || code >= 500 {
+			t.Errorf("%s: expected a client error, got %d", name, code)
+		}
+	}
+	if code := publish(t, srv.URL, schemaJSON("x", "1.0.0", ""), nil); code != http.StatusCreated {
+		t.Errorf("without trusted keys, unsigned schemas should be accepted, got %d", code)
+	}
+}
@@ -0,0 +1,207 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by Storage when a tool or version doesn't exist.
+var ErrNotFound = errors.New("registry: not found")
+
+// ErrExists is returned by Storage.Put when the version was already
+// published. Published versions are immutable.
+var ErrExists = errors.New("registry: version already published")
+
+// Entry is one published version of a tool.
+type Entry struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	Schema    []byte    `json:"-"`                   // The schema exactly as published and signed
+	Signature []byte    `json:"signature,omitempty"` // Ed25519 signature of Schema, if it was signed
+	SignedAt  time.Time `json:"signedAt,omitempty"`  // When it was signed, if the signature covers a time
+	Published time.Time `json:"published"`
+}
+
+// Storage persists published entries. Implementations must be safe for
+// concurrent use.
+type Storage interface {
+	// Put stores a new entry, or returns ErrExists.
+	Put(ctx context.Context, e *Entry) error
+	// Get returns one version of a tool, or ErrNotFound.
+	Get(ctx context.Context, name, version string) (*Entry, error)
+	// Versions lists the published versions of a tool, in any order, or
+	// returns ErrNotFound.
+	Versions(ctx context.Context, name string) ([]string, error)
+	// Names lists every tool with at least one version, in any order.
+	Names(ctx context.Context) ([]string, error)
+}
+
+// MemoryStorage keeps entries in memory, for tests and throwaway
+// registries.
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	entries map[string]map[string]*Entry // name -> version -> entry
+}
+
+// NewMemoryStorage returns an empty MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{entries: map[string]map[string]*Entry{}}
+}
+
+// Put implements Storage.
+func (m *MemoryStorage) Put(_ context.Context, e *Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries[e.Name] == nil {
+		m.entries[e.Name] = map[string]*Entry{}
+	}
+	if _, ok := m.entries[e.Name][e.Version]; ok {
+		return ErrExists
+	}
+	c := *e
+	m.entries[e.Name][e.Version] = &c
+	return nil
+}
+
+// Get implements Storage.
+func (m *MemoryStorage) Get(_ context.Context, name, version string) (*Entry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.entries[name][version]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *e
+	return &c, nil
+}
+
+// Versions implements Storage.
+func (m *MemoryStorage) Versions(_ context.Context, name string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.entries[name]) == 0 {
+		return nil, ErrNotFound
+	}
+	var versions []string
+	for v := range m.entries[name] {
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// Names implements Storage.
+func (m *MemoryStorage) Names(context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var names []string
+	for n := range m.entries {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DirStorage keeps entries in a directory tree: <dir>/<name>/<version>.json
+// holds the schema, and <version>.meta the rest of the entry.
+type DirStorage struct {
+	Dir string
+	mu  sync.Mutex // Serializes Put's existence check and write
+}
+
+// NewDirStorage returns a DirStorage rooted at dir, which is created on the
+// first Put.
+func NewDirStorage(dir string) *DirStorage {
+	return &DirStorage{Dir: dir}
+}
+
+// Put implements Storage.
+func (d *DirStorage) Put(_ context.Context, e *Entry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dir := filepath.Join(d.Dir, e.Name)
+	schemaPath := filepath.Join(dir, e.Version+".json")
+	if _, err := os.Stat(schemaPath); err == nil {
+		return ErrExists
+	}
+	meta, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Metadata first: a schema file without it would be an unreadable entry.
+	if err := os.WriteFile(filepath.Join(dir, e.Version+".meta"), meta, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(schemaPath, e.Schema, 0o644)
+}
+
+// Get implements Storage.
+func (d *DirStorage) Get(_ context.Context, name, version string) (*Entry, error) {
+	dir := filepath.Join(d.Dir, name)
+	schema, err := os.ReadFile(filepath.Join(dir, version+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	meta, err := os.ReadFile(filepath.Join(dir, version+".meta"))
+	if err != nil {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(meta, &e); err != nil {
+		return nil, err
+	}
+	e.Schema = schema
+	return &e, nil
+}
+
+// Versions implements Storage.
+func (d *DirStorage) Versions(_ context.Context, name string) ([]string, error) {
+	files, err := os.ReadDir(filepath.Join(d.Dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, f := range files {
+		if v, ok := strings.CutSuffix(f.Name(), ".json"); ok {
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	return versions, nil
+}
+
+// Names implements Storage.
+func (d *DirStorage) Names(context.Context) ([]string, error) {
+	dirs, err := os.ReadDir(d.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range dirs {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,111 @@
+package mtp
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ansiEscape matches terminal escape sequences: CSI (colors, cursor
+// movement), OSC (titles, hyperlinks) terminated by BEL or ST, and the
+// remaining two-byte escapes.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// sanitizeText makes help text safe to embed in a schema. It strips ANSI
+// escapes, normalizes CRLF and CR to LF, drops control and invisible
+// formatting characters (zero-width spaces, bidi overrides) that can hide
+// content from reviewers, and trims surrounding whitespace. If maxLen is
+// positive, the result is cut to at most maxLen runes, ending in "…".
+func sanitizeText(s string, maxLen int) string {
+	if maxLen <= 0 && isCleanText(s) {
+		return s
+	}
+
+	s = ansiEscape.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case r == utf8.RuneError, unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.TrimSpace(s)
+
+	if maxLen > 0 && utf8.RuneCountInString(s) > maxLen {
+		runes := []rune(s)
+		s = strings.TrimRightFunc(string(runes[:maxLen-1]), unicode.IsSpace) + "…"
+	}
+	return s
+}
+
+// isCleanText reports whether sanitizeText would return s unchanged, so the
+// common case costs one scan and no allocation.
+func isCleanText(s string) bool {
+	if s == "" {
+		return true
+	}
+	if unicode.IsSpace(rune(s[0])) || unicode.IsSpace(rune(s[len(s)-1])) {
+		return false
+	}
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			if r < 0x20 && r != '\n' && r != '\t' || r == 0x7f {
+				return false
+			}
+			continue
+		}
+		if r == utf8.RuneError || unicode.IsControl(r) || unicode.Is(unicode.Cf, r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizeCommand applies sanitizeText to the descriptions extracted into
+// cmd. Stdin/Stdout descriptors are shared with the caller's annotations
+// and are left untouched.
+func sanitizeCommand(cmd *CommandDescriptor, maxLen int) {
+	cmd.Description = sanitizeText(cmd.Description, maxLen)
+	cmd.Deprecated = sanitizeDeprecation(cmd.Deprecated, maxLen)
+	for j := range cmd.Args {
+		cmd.Args[j].Description = sanitizeText(cmd.Args[j].Description, maxLen)
+		cmd.Args[j].Deprecated = sanitizeDeprecation(cmd.Args[j].Deprecated, maxLen)
+	}
+	for j := range cmd.Examples {
+		cmd.Examples[j].Description = sanitizeText(cmd.Examples[j].Description, maxLen)
+	}
+}
+
+// sanitizeDeprecation returns d with its message sanitized. It copies d
+// rather than modifying it, since d may belong to an annotation.
+func sanitizeDeprecation(d *Deprecation, maxLen int) *Deprecation {
+	if d == nil {
+		return nil
+	}
+	msg := sanitizeText(d.Message, maxLen)
+	if msg == d.Message {
+		return d
+	}
+	c := *d
+	c.Message = msg
+	return &c
+}
+
+// sanitizeChangelog returns a copy of entries with each change sanitized.
+func sanitizeChangelog(entries []ChangeEntry, maxLen int) []ChangeEntry {
+	out := make([]ChangeEntry, len(entries))
+	for i, e := range entries {
+		changes := make([]string, len(e.Changes))
+		for j, c := range e.Changes {
+			changes[j] = sanitizeText(c, maxLen)
+		}
+		e.Changes = changes
+		out[i] = e
+	}
+	return out
+}
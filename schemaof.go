@@ -0,0 +1,449 @@
+package mtp
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaEnum is implemented by types whose values form a fixed set, such
+// as a string type with declared constants. SchemaOf lists SchemaEnum()
+// as the type's "enum". It's called on the zero value.
+type SchemaEnum interface {
+	SchemaEnum() []any
+}
+
+// SchemaOf reflects T into a JSON Schema for IODescriptor.Schema, so the
+// type a command's RunE unmarshals is the only statement of its input or
+// output:
+//
+//	type Result struct {
+//		ID     string   `json:"id" jsonschema:"description=Job ID"`
+//		State  State    `json:"state"` // State implements SchemaEnum
+//		Labels []string `json:"labels,omitempty" jsonschema:"maxItems=10"`
+//	}
+//
+//	mtp.Annotate("job get").StdoutJSON(mtp.SchemaOf[Result]())
+//
+// Fields are named and skipped as encoding/json does, including the fields
+// promoted from embedded structs. A field is required unless it's tagged
+// omitempty; a pointer field may also be null. Structs don't allow other
+// properties. time.Time is a date-time string, []byte a base64 string,
+// other encoding.TextMarshalers strings, and other json.Marshalers any
+// value. A type that refers to itself is emitted once under "$defs".
+//
+// The jsonschema tag holds comma-separated keywords: title, description,
+// format, pattern, default, enum (repeated for each value), minimum,
+// maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength,
+// minItems, maxItems, and uniqueItems, each as keyword=value, and the bare
+// words required and optional, which override omitempty. Write "\," for a
+// comma inside a value. On a slice, enum applies to the elements.
+//
+// SchemaOf panics on a malformed tag, or on a type encoding/json can't
+// encode, such as a channel or function.
+func SchemaOf[T any]() map[string]any {
+	return schemaOfType(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// schemaOfType implements SchemaOf.
+func schemaOfType(t reflect.Type) map[string]any {
+	r := &schemaReflector{root: t, building: map[reflect.Type]bool{}, recursive: map[reflect.Type]bool{}}
+	schema := r.schema(t)
+	if len(r.defs) > 0 {
+		schema["$defs"] = r.defs
+	}
+	return schema
+}
+
+var (
+	schemaEnumType    = reflect.TypeOf((*SchemaEnum)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// schemaReflector builds schemas for one SchemaOf call, tracking the
+// struct types being built to catch recursion.
+type schemaReflector struct {
+	root      reflect.Type
+	building  map[reflect.Type]bool
+	recursive map[reflect.Type]bool
+	defs      map[string]any
+}
+
+func (r *schemaReflector) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if values, ok := enumValues(t); ok {
+		schema := r.kindSchema(t)
+		schema["enum"] = values
+		return schema
+	}
+	if t.Kind() != reflect.Struct || t == timeType || implements(t, jsonMarshalerType) || implements(t, textMarshalerType) {
+		return r.kindSchema(t)
+	}
+
+	ref := "#/$defs/" + t.Name()
+	if t == r.root {
+		ref = "#"
+	}
+	if r.building[t] {
+		r.recursive[t] = true
+		return map[string]any{"$ref": ref}
+	}
+	r.building[t] = true
+	schema := r.structSchema(t)
+	r.building[t] = false
+	if r.recursive[t] && t != r.root {
+		if r.defs == nil {
+			r.defs = map[string]any{}
+		}
+		r.defs[t.Name()] = schema
+		return map[string]any{"$ref": ref}
+	}
+	return schema
+}
+
+// kindSchema returns the schema for a type that isn't a plain struct.
+func (r *schemaReflector) kindSchema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]any{}
+	case implements(t, jsonMarshalerType):
+		return map[string]any{}
+	case implements(t, textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 && !implements(t.Elem(), textMarshalerType) {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		schema := map[string]any{"type": "array", "items": r.schema(t.Elem())}
+		if t.Kind() == reflect.Array {
+			schema["minItems"], schema["maxItems"] = t.Len(), t.Len()
+		}
+		return schema
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			if !implements(t.Key(), textMarshalerType) {
+				panic(fmt.Sprintf("mtp: SchemaOf: map key type %s can't be encoded as JSON", t.Key()))
+			}
+		}
+		return map[string]any{"type": "object", "additionalProperties": r.schema(t.Elem())}
+	}
+	panic(fmt.Sprintf("mtp: SchemaOf: type %s can't be encoded as JSON", t))
+}
+
+func (r *schemaReflector) structSchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	for _, f := range structFields(t) {
+		schema := r.schema(f.Type)
+		if f.AsString {
+			schema = map[string]any{"type": "string"}
+		}
+		opts, err := parseSchemaTag(f.Tag.Get("jsonschema"))
+		if err != nil {
+			panic(fmt.Sprintf("mtp: SchemaOf: %s.%s: %v", t.Name(), f.Name, err))
+		}
+		isRequired := !f.OmitEmpty
+		if err := applySchemaTag(schema, opts, &isRequired); err != nil {
+			panic(fmt.Sprintf("mtp: SchemaOf: %s.%s: %v", t.Name(), f.Name, err))
+		}
+		if f.Type.Kind() == reflect.Pointer && !f.OmitEmpty {
+			schema = nullable(schema)
+		}
+		props[f.JSONName] = schema
+		if isRequired {
+			required = append(required, f.JSONName)
+		}
+	}
+	schema := map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// nullable returns schema extended to accept null.
+func nullable(schema map[string]any) map[string]any {
+	switch typ := schema["type"].(type) {
+	case string:
+		schema["type"] = []string{typ, "null"}
+		if enum, ok := schema["enum"].([]any); ok {
+			schema["enum"] = append(enum, nil)
+		}
+		return schema
+	case nil:
+		if _, isRef := schema["$ref"]; !isRef {
+			return schema // Already accepts anything
+		}
+	}
+	return map[string]any{"anyOf": []any{schema, map[string]any{"type": "null"}}}
+}
+
+// structField is a field as encoding/json sees it.
+type structField struct {
+	reflect.StructField
+	JSONName  string
+	OmitEmpty bool // Tagged omitempty or omitzero
+	AsString  bool // Tagged string: a number or boolean encoded as a string
+	Index     []int
+}
+
+// structFields returns t's fields as encoding/json encodes them, with the
+// fields of embedded structs promoted. Where names collide, the shallowest
+// field wins, and a tie goes to a tagged field, or else drops the name.
+func structFields(t reflect.Type) []structField {
+	type candidate struct {
+		structField
+		depth  int
+		tagged bool
+	}
+	var all []candidate
+	visited := map[reflect.Type]bool{}
+	var walk func(t reflect.Type, index []int, depth int)
+	walk = func(t reflect.Type, index []int, depth int) {
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+		defer delete(visited, t)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				if !f.IsExported() && f.Type.Kind() == reflect.Pointer {
+					continue // encoding/json can't set through an unexported pointer
+				}
+				walk(ft, append(slices.Clone(index), i), depth+1)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			c := candidate{depth: depth, tagged: name != ""}
+			c.StructField = f
+			c.JSONName = name
+			if name == "" {
+				c.JSONName = f.Name
+			}
+			for _, opt := range strings.Split(options, ",") {
+				switch opt {
+				case "omitempty", "omitzero":
+					c.OmitEmpty = true
+				case "string":
+					switch ft.Kind() {
+					case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64:
+						c.AsString = true
+					}
+				}
+			}
+			c.Index = append(slices.Clone(index), i)
+			all = append(all, c)
+		}
+	}
+	walk(t, nil, 0)
+
+	var fields []structField
+	for i, c := range all {
+		dominant := true
+		for j, o := range all {
+			if i == j || o.JSONName != c.JSONName {
+				continue
+			}
+			if o.depth < c.depth || (o.depth == c.depth && (o.tagged && !c.tagged || o.tagged == c.tagged)) {
+				dominant = false
+				break
+			}
+		}
+		if dominant {
+			fields = append(fields, c.structField)
+		}
+	}
+	return fields
+}
+
+// enumValues returns the values of a type implementing SchemaEnum, with a
+// value or pointer receiver.
+func enumValues(t reflect.Type) ([]any, bool) {
+	switch {
+	case t.Implements(schemaEnumType):
+		return reflect.Zero(t).Interface().(SchemaEnum).SchemaEnum(), true
+	case reflect.PointerTo(t).Implements(schemaEnumType):
+		return reflect.New(t).Interface().(SchemaEnum).SchemaEnum(), true
+	}
+	return nil, false
+}
+
+// implements reports whether t or *t implements iface.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// schemaTagOption is one keyword of a jsonschema tag.
+type schemaTagOption struct {
+	key, value string
+	hasValue   bool
+}
+
+// parseSchemaTag splits a jsonschema tag at unescaped commas.
+func parseSchemaTag(tag string) ([]schemaTagOption, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	var opts []schemaTagOption
+	var b strings.Builder
+	flush := func() error {
+		part := strings.TrimSpace(b.String())
+		b.Reset()
+		if part == "" {
+			return fmt.Errorf("empty keyword in jsonschema tag")
+		}
+		key, value, hasValue := strings.Cut(part, "=")
+		opts = append(opts, schemaTagOption{strings.TrimSpace(key), value, hasValue})
+		return nil
+	}
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		default:
+			b.WriteByte(tag[i])
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// applySchemaTag sets the keywords of a jsonschema tag on schema, and
+// updates required for the required and optional words.
+func applySchemaTag(schema map[string]any, opts []schemaTagOption, required *bool) error {
+	target := schema
+	if items, ok := schema["items"].(map[string]any); ok && schema["type"] == "array" {
+		target = items // Values of enum describe the elements
+	}
+	for _, o := range opts {
+		switch o.key {
+		case "required", "optional":
+			if o.hasValue {
+				return fmt.Errorf("%s takes no value", o.key)
+			}
+			*required = o.key == "required"
+			continue
+		}
+		if !o.hasValue {
+			return fmt.Errorf("%s needs a value", o.key)
+		}
+		switch o.key {
+		case "title", "description", "format", "pattern":
+			schema[o.key] = o.value
+		case "default":
+			v, err := tagValue(schema, o.value)
+			if err != nil {
+				return fmt.Errorf("default: %w", err)
+			}
+			schema[o.key] = v
+		case "enum":
+			v, err := tagValue(target, o.value)
+			if err != nil {
+				return fmt.Errorf("enum: %w", err)
+			}
+			enum, _ := target["enum"].([]any)
+			target["enum"] = append(enum, v)
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+			n, err := strconv.ParseFloat(o.value, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a number", o.key, o.value)
+			}
+			schema[o.key] = n
+		case "minLength", "maxLength", "minItems", "maxItems":
+			n, err := strconv.Atoi(o.value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("%s: %q is not a non-negative integer", o.key, o.value)
+			}
+			schema[o.key] = n
+		case "uniqueItems":
+			b, err := strconv.ParseBool(o.value)
+			if err != nil {
+				return fmt.Errorf("uniqueItems: %q is not a boolean", o.value)
+			}
+			schema[o.key] = b
+		default:
+			return fmt.Errorf("unknown jsonschema keyword %q", o.key)
+		}
+	}
+	return nil
+}
+
+// tagValue parses a tag's default or enum value as schema's type.
+func tagValue(schema map[string]any, s string) (any, error) {
+	switch schema["type"] {
+	case "integer":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", s)
+		}
+		return n, nil
+	case "number":
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", s)
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", s)
+		}
+		return b, nil
+	case "string":
+		return s, nil
+	}
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return s, nil
+	}
+	return v, nil
+}
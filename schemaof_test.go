@@ -0,0 +1,127 @@
+package mtp
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type jobState string
+
+func (jobState) SchemaEnum() []any { return []any{"queued", "running", "done"} }
+
+type jobMeta struct {
+	Created time.Time `json:"created"`
+	Owner   string    `json:"owner,omitempty"`
+}
+
+type jobResult struct {
+	jobMeta
+	ID       string            `json:"id" jsonschema:"description=Job ID\\, as returned by create,pattern=^j-"`
+	State    jobState          `json:"state"`
+	Priority int               `json:"priority,omitempty" jsonschema:"minimum=0,maximum=9,default=5"`
+	Labels   []string          `json:"labels,omitempty" jsonschema:"enum=urgent,enum=batch,uniqueItems=true"`
+	Parent   *jobResult        `json:"parent"`
+	Attrs    map[string]int    `json:"attrs,omitempty" jsonschema:"required"`
+	Payload  json.RawMessage   `json:"payload,omitempty"`
+	Count    int64             `json:"count,string"`
+	Data     []byte            `json:"data,omitempty"`
+	Extra    map[string]string `json:"-"`
+	internal string
+}
+
+func TestSchemaOf(t *testing.T) {
+	got := SchemaOf[jobResult]()
+	want := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"created", "id", "state", "parent", "attrs", "count"},
+		"properties": map[string]any{
+			"created": map[string]any{"type": "string", "format": "date-time"},
+			"owner":   map[string]any{"type": "string"},
+			"id":      map[string]any{"type": "string", "description": "Job ID, as returned by create", "pattern": "^j-"},
+			"state":   map[string]any{"type": "string", "enum": []any{"queued", "running", "done"}},
+			"priority": map[string]any{
+				"type": "integer", "minimum": 0.0, "maximum": 9.0, "default": int64(5),
+			},
+			"labels": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string", "enum": []any{"urgent", "batch"}},
+				"uniqueItems": true,
+			},
+			"parent":  map[string]any{"anyOf": []any{map[string]any{"$ref": "#"}, map[string]any{"type": "null"}}},
+			"attrs":   map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "integer"}},
+			"payload": map[string]any{},
+			"count":   map[string]any{"type": "string"},
+			"data":    map[string]any{"type": "string", "contentEncoding": "base64"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.MarshalIndent(got, "", "  ")
+		wantJSON, _ := json.MarshalIndent(want, "", "  ")
+		t.Errorf("got\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+
+	tool := &ToolSchema{Commands: []CommandDescriptor{{Name: "job get", Stdout: &IODescriptor{Schema: got}}}}
+	if diags := ValidateSchema(tool); len(diags) > 0 {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+}
+
+type treeNode struct {
+	Name     string      `json:"name"`
+	Children []*treeNode `json:"children,omitempty"`
+}
+
+type forest struct {
+	Trees []treeNode `json:"trees"`
+}
+
+func TestSchemaOfRecursive(t *testing.T) {
+	got := SchemaOf[forest]()
+	defs, _ := got["$defs"].(map[string]any)
+	node, _ := defs["treeNode"].(map[string]any)
+	if node == nil {
+		t.Fatalf("no $defs entry for treeNode: %v", got)
+	}
+	items := node["properties"].(map[string]any)["children"].(map[string]any)["items"]
+	if !reflect.DeepEqual(items, map[string]any{"$ref": "#/$defs/treeNode"}) {
+		t.Errorf("children items %v", items)
+	}
+	trees := got["properties"].(map[string]any)["trees"].(map[string]any)["items"]
+	if !reflect.DeepEqual(trees, map[string]any{"$ref": "#/$defs/treeNode"}) {
+		t.Errorf("trees items %v", trees)
+	}
+
+	if got := SchemaOf[[]string](); !reflect.DeepEqual(got, map[string]any{"type": "array", "items": map[string]any{"type": "string"}}) {
+		t.Errorf("[]string: %v", got)
+	}
+}
+
+func TestSchemaOfPanics(t *testing.T) {
+	type badTag struct {
+		N int `json:"n" jsonschema:"minimum=low"`
+	}
+	type unknownKeyword struct {
+		S string `json:"s" jsonschema:"color=red"`
+	}
+	type badType struct {
+		C chan int `json:"c"`
+	}
+	for name, build := range map[string]func(){
+		"minimum":  func() { SchemaOf[badTag]() },
+		"color":    func() { SchemaOf[unknownKeyword]() },
+		"chan int": func() { SchemaOf[badType]() },
+	} {
+		func() {
+			defer func() {
+				if msg, _ := recover().(string); !strings.Contains(msg, name) {
+					t.Errorf("panic %q, want it to mention %q", msg, name)
+				}
+			}()
+			build()
+		}()
+	}
+}
@@ -0,0 +1,89 @@
+package mtp
+
+// SemanticKernelPlugin is a Semantic Kernel plugin manifest: one native
+// function per command, with metadata in the shape of SK's
+// KernelFunctionMetadata.
+type SemanticKernelPlugin struct {
+	Name        string                   `json:"name"`
+	Description string                   `json:"description"`
+	Functions   []SemanticKernelFunction `json:"functions"`
+}
+
+// SemanticKernelFunction describes one command as a kernel function.
+type SemanticKernelFunction struct {
+	Name            string                    `json:"name"`
+	Description     string                    `json:"description"`
+	Parameters      []SemanticKernelParameter `json:"parameters"`
+	ReturnParameter SemanticKernelReturn      `json:"returnParameter"`
+	// Command is the argv prefix the host's function should execute,
+	// followed by positionals and --flags built from the parameters.
+	Command []string `json:"command"`
+}
+
+// SemanticKernelParameter describes one function parameter.
+type SemanticKernelParameter struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description,omitempty"`
+	IsRequired   bool           `json:"isRequired"`
+	DefaultValue any            `json:"defaultValue,omitempty"`
+	Schema       map[string]any `json:"schema"`
+}
+
+// SemanticKernelReturn describes a function's return value.
+type SemanticKernelReturn struct {
+	Description string         `json:"description,omitempty"`
+	Schema      map[string]any `json:"schema,omitempty"`
+}
+
+// ToSemanticKernelPlugin converts schema to a Semantic Kernel plugin
+// manifest. Function names are the command path with underscores
+// ("db_migrate"), numbered where two collide; SK scopes them by the plugin
+// name.
+func ToSemanticKernelPlugin(schema *ToolSchema) *SemanticKernelPlugin {
+	plugin := &SemanticKernelPlugin{
+		Name:        exportName(schema.Name, CommandDescriptor{Name: "_root"}),
+		Description: schema.Description,
+		Functions:   make([]SemanticKernelFunction, 0, len(schema.Commands)),
+	}
+
+	names := make([]string, len(schema.Commands))
+	for i, cmd := range schema.Commands {
+		names[i] = plugin.Name
+		if !isRootCommand(schema.Name, cmd) {
+			names[i] = identifier(cmd.Name)
+		}
+	}
+	names = uniqueNames(names)
+
+	for i, cmd := range schema.Commands {
+		fn := SemanticKernelFunction{
+			Name:        names[i],
+			Description: commandDescription(cmd.Description, cmd),
+			Parameters:  make([]SemanticKernelParameter, 0, len(cmd.Args)),
+			Command:     commandArgv(schema.Name, cmd),
+			ReturnParameter: SemanticKernelReturn{
+				Schema: map[string]any{"type": "string"},
+			},
+		}
+		for _, arg := range cmd.Args {
+			fn.Parameters = append(fn.Parameters, SemanticKernelParameter{
+				Name:         argKey(arg),
+				Description:  argDescription(arg),
+				IsRequired:   arg.Required,
+				DefaultValue: typedDefault(arg),
+				Schema:       argJSONSchema(arg),
+			})
+		}
+		if cmd.Stdout != nil {
+			fn.ReturnParameter.Description = cmd.Stdout.Description
+			if cmd.Stdout.Schema != nil {
+				fn.ReturnParameter.Schema = cmd.Stdout.Schema
+				if resolved, err := schema.ResolveRefs(cmd.Stdout.Schema); err == nil {
+					fn.ReturnParameter.Schema = resolved
+				}
+			}
+		}
+		plugin.Functions = append(plugin.Functions, fn)
+	}
+	return plugin
+}
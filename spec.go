@@ -0,0 +1,310 @@
+package mtp
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+//go:embed spec/mtp.schema.json
+var specSchema []byte
+
+// SpecJSONSchema returns the JSON Schema (draft 2020-12) for --mtp-describe
+// documents at MTPSpecVersion. The returned slice is a copy.
+func SpecJSONSchema() []byte {
+	return bytes.Clone(specSchema)
+}
+
+// specDocument is the decoded spec schema, parsed once on first use.
+var specDocument = sync.OnceValue(func() map[string]any {
+	doc, err := decodeJSON(specSchema)
+	if err != nil {
+		panic("mtp: embedded spec schema: " + err.Error())
+	}
+	return doc.(map[string]any)
+})
+
+// ValidateAgainstSpec checks a raw --mtp-describe document against
+// SpecJSONSchema. It's meant for schemas written by hand or produced by
+// other SDKs; ValidateSchema covers the semantic checks that JSON Schema
+// can't express, so run both.
+//
+// Every diagnostic has error severity. Paths follow ValidateSchema, with
+// array elements addressed by index (e.g. "commands[2].args[0].type").
+func ValidateAgainstSpec(raw []byte) []Diagnostic {
+	doc, err := decodeJSON(raw)
+	if err != nil {
+		return []Diagnostic{{Severity: SeverityError, Message: err.Error()}}
+	}
+	v := specValidator{root: specDocument(), patterns: map[string]*regexp.Regexp{}}
+	v.check(doc, v.root, "")
+	return v.diags
+}
+
+// decodeJSON decodes a single JSON value, keeping numbers as json.Number.
+func decodeJSON(raw []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("invalid JSON: unexpected data after document")
+	}
+	return doc, nil
+}
+
+// specValidator implements the subset of JSON Schema the spec uses: $ref
+// into $defs, type, enum, minLength, pattern, minimum, required,
+// properties, patternProperties, additionalProperties, and items. It also
+// covers what SchemaOf emits beyond that, for Bind: a $ref to the root,
+// a list of types, anyOf, maximum, exclusiveMinimum, exclusiveMaximum,
+// maxLength, minItems, maxItems, and uniqueItems.
+type specValidator struct {
+	root     map[string]any
+	patterns map[string]*regexp.Regexp
+	diags    []Diagnostic
+}
+
+func (v *specValidator) errorf(path, format string, args ...any) {
+	v.diags = append(v.diags, Diagnostic{
+		Severity: SeverityError,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *specValidator) check(value any, schema map[string]any, path string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		v.check(value, v.resolve(ref), path)
+	}
+
+	if typ, ok := schema["type"].(string); ok {
+		if got := jsonType(value); got != typ && !(typ == "number" && got == "integer") {
+			v.errorf(path, "expected %s, got %s", typ, got)
+			return
+		}
+	}
+	if types, ok := schema["type"].([]any); ok {
+		got := jsonType(value)
+		if !slices.ContainsFunc(types, func(typ any) bool { return typ == got || (typ == "number" && got == "integer") }) {
+			names := make([]string, len(types))
+			for i, typ := range types {
+				names[i] = fmt.Sprint(typ)
+			}
+			v.errorf(path, "expected %s, got %s", strings.Join(names, " or "), got)
+			return
+		}
+	}
+	if options, ok := schema["anyOf"].([]any); ok {
+		matched := slices.ContainsFunc(options, func(o any) bool {
+			sub := specValidator{root: v.root, patterns: v.patterns}
+			sub.check(value, o.(map[string]any), path)
+			return len(sub.diags) == 0
+		})
+		if !matched {
+			v.errorf(path, "value %s matches none of the allowed schemas", mustJSON(value))
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(value, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.errorf(path, "value %s is not one of %s", mustJSON(value), mustJSON(enum))
+		}
+	}
+
+	switch val := value.(type) {
+	case string:
+		v.checkString(val, schema, path)
+	case json.Number:
+		v.checkNumber(val, schema, path)
+	case map[string]any:
+		v.checkObject(val, schema, path)
+	case []any:
+		v.checkArray(val, schema, path)
+	}
+}
+
+func (v *specValidator) checkNumber(n json.Number, schema map[string]any, path string) {
+	f, _ := n.Float64()
+	bound := func(keyword string) (json.Number, float64, bool) {
+		b, ok := schema[keyword].(json.Number)
+		limit, _ := b.Float64()
+		return b, limit, ok
+	}
+	if min, limit, ok := bound("minimum"); ok && f < limit {
+		v.errorf(path, "%s is less than the minimum %s", n, min)
+	}
+	if max, limit, ok := bound("maximum"); ok && f > limit {
+		v.errorf(path, "%s is greater than the maximum %s", n, max)
+	}
+	if min, limit, ok := bound("exclusiveMinimum"); ok && f <= limit {
+		v.errorf(path, "%s must be greater than %s", n, min)
+	}
+	if max, limit, ok := bound("exclusiveMaximum"); ok && f >= limit {
+		v.errorf(path, "%s must be less than %s", n, max)
+	}
+}
+
+func (v *specValidator) checkArray(items []any, schema map[string]any, path string) {
+	if min, ok := schema["minItems"].(json.Number); ok {
+		if n, _ := min.Int64(); int64(len(items)) < n {
+			v.errorf(path, "must have at least %d items", n)
+		}
+	}
+	if max, ok := schema["maxItems"].(json.Number); ok {
+		if n, _ := max.Int64(); int64(len(items)) > n {
+			v.errorf(path, "must have at most %d items", n)
+		}
+	}
+	if schema["uniqueItems"] == true {
+		for i := range items {
+			if j := slices.IndexFunc(items[:i], func(item any) bool { return reflect.DeepEqual(item, items[i]) }); j >= 0 {
+				v.errorf(path+"["+strconv.Itoa(i)+"]", "duplicates item %d", j)
+			}
+		}
+	}
+	if sub, ok := schema["items"].(map[string]any); ok {
+		for i, item := range items {
+			v.check(item, sub, path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+}
+
+func (v *specValidator) checkString(s string, schema map[string]any, path string) {
+	if min, ok := schema["minLength"].(json.Number); ok {
+		if n, _ := min.Int64(); int64(utf8.RuneCountInString(s)) < n {
+			v.errorf(path, "must be at least %d characters", n)
+		}
+	}
+	if max, ok := schema["maxLength"].(json.Number); ok {
+		if n, _ := max.Int64(); int64(utf8.RuneCountInString(s)) > n {
+			v.errorf(path, "must be at most %d characters", n)
+		}
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, ok := v.patterns[pattern]
+		if !ok {
+			re = regexp.MustCompile(pattern)
+			v.patterns[pattern] = re
+		}
+		if !re.MatchString(s) {
+			v.errorf(path, "%q does not match %s", s, pattern)
+		}
+	}
+}
+
+func (v *specValidator) checkObject(obj map[string]any, schema map[string]any, path string) {
+	if required, ok := schema["required"].([]any); ok {
+		for _, r := range required {
+			name := r.(string)
+			if _, ok := obj[name]; !ok {
+				v.errorf(joinPath(path, name), "required field is missing")
+			}
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]any)
+	patternProps, _ := schema["patternProperties"].(map[string]any)
+	additional, hasAdditional := schema["additionalProperties"]
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := joinPath(path, k)
+		matched := false
+		if sub, ok := props[k].(map[string]any); ok {
+			v.check(obj[k], sub, p)
+			matched = true
+		}
+		for pattern, sub := range patternProps {
+			re, ok := v.patterns[pattern]
+			if !ok {
+				re = regexp.MustCompile(pattern)
+				v.patterns[pattern] = re
+			}
+			if re.MatchString(k) {
+				v.check(obj[k], sub.(map[string]any), p)
+				matched = true
+			}
+		}
+		if matched || !hasAdditional {
+			continue
+		}
+		switch a := additional.(type) {
+		case bool:
+			if !a {
+				v.errorf(p, "unknown field")
+			}
+		case map[string]any:
+			v.check(obj[k], a, p)
+		}
+	}
+}
+
+// resolve looks up a local "#/$defs/name" reference, or "#", the root.
+func (v *specValidator) resolve(ref string) map[string]any {
+	if ref == "#" {
+		return v.root
+	}
+	defs, _ := v.root["$defs"].(map[string]any)
+	if name, ok := strings.CutPrefix(ref, "#/$defs/"); ok {
+		if def, ok := defs[name].(map[string]any); ok {
+			return def
+		}
+	}
+	panic("mtp: unresolvable $ref in spec schema: " + ref)
+}
+
+// jsonType returns the JSON Schema type name of a decoded value.
+func jsonType(value any) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if f, err := val.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// joinPath appends a field name to a diagnostic path.
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
@@ -0,0 +1,150 @@
+package mtp
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// ── Spec JSON Schema tests ───────────────────────────────────────────
+
+func TestSpecJSONSchemaIsCopy(t *testing.T) {
+	a := SpecJSONSchema()
+	a[0] = 'X'
+	if b := SpecJSONSchema(); b[0] != '{' {
+		t.Error("SpecJSONSchema should return a copy")
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(SpecJSONSchema(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["$schema"] == nil || doc["$defs"] == nil {
+		t.Errorf("unexpected spec schema: %v", doc)
+	}
+}
+
+// TestSpecMatchesTypes keeps the spec and the Go types in sync: every JSON
+// field of a schema type must be declared in its definition, and vice versa.
+func TestSpecMatchesTypes(t *testing.T) {
+	defs := specDocument()["$defs"].(map[string]any)
+	types := map[string]reflect.Type{
+		"toolSchema":       reflect.TypeOf(ToolSchema{}),
+		"command":          reflect.TypeOf(CommandDescriptor{}),
+		"arg":              reflect.TypeOf(ArgDescriptor{}),
+		"io":               reflect.TypeOf(IODescriptor{}),
+		"deprecation":      reflect.TypeOf(Deprecation{}),
+		"cost":             reflect.TypeOf(Cost{}),
+		"changeEntry":      reflect.TypeOf(ChangeEntry{}),
+		"example":          reflect.TypeOf(Example{}),
+		"commandAuth":      reflect.TypeOf(CommandAuth{}),
+		"requirements":     reflect.TypeOf(Requirements{}),
+		"dependency":       reflect.TypeOf(Dependency{}),
+		"errorCode":        reflect.TypeOf(ErrorCode{}),
+		"filesystemAccess": reflect.TypeOf(FilesystemAccess{}),
+		"install":          reflect.TypeOf(InstallInfo{}),
+		"download":         reflect.TypeOf(Download{}),
+		"authConfig":       reflect.TypeOf(AuthConfig{}),
+		"authEnvironment":  reflect.TypeOf(AuthEnvironment{}),
+		"credentialSource": reflect.TypeOf(CredentialSource{}),
+		"authProvider":     reflect.TypeOf(AuthProvider{}),
+	}
+	for def, typ := range types {
+		d, ok := defs[def].(map[string]any)
+		if !ok {
+			t.Errorf("spec has no definition %q", def)
+			continue
+		}
+		props := d["properties"].(map[string]any)
+		fields := jsonFields(typ)
+		for name := range fields {
+			if _, ok := props[name]; !ok {
+				t.Errorf("%s.%s is missing from $defs/%s", typ.Name(), name, def)
+			}
+		}
+		for name := range props {
+			if _, ok := fields[name]; !ok {
+				t.Errorf("$defs/%s.%s has no field in %s", def, name, typ.Name())
+			}
+		}
+	}
+}
+
+func TestValidateAgainstSpecDescribeOutput(t *testing.T) {
+	root := &cobra.Command{Use: "tool", Short: "A tool", Version: "1.0.0"}
+	fetch := &cobra.Command{Use: "fetch <url>", Short: "Fetch a URL", Run: func(*cobra.Command, []string) {}}
+	fetch.Flags().Int("retries", 3, "Retries")
+	fetch.Flags().String("format", "json", "Format")
+	EnumValues(fetch, "format", []string{"json", "text"})
+	root.AddCommand(fetch)
+
+	schema := Describe(root, &DescribeOptions{
+		Auth: &AuthConfig{
+			Required: true,
+			EnvVar:   "TOOL_TOKEN",
+			Providers: []AuthProvider{
+				{ID: "gh", Type: "oauth2", Scopes: []string{"repo"}, AccessTokenLifetime: 3600},
+			},
+		},
+		Install: &InstallInfo{
+			Brew:      "tool",
+			Downloads: []Download{{OS: "linux", Arch: "amd64", URL: "https://example.com/t", SHA256: strings.Repeat("ab", 32)}},
+		},
+		Commands: map[string]*CommandAnnotation{
+			"fetch": {
+				Stdout:   &IODescriptor{ContentType: "application/json", Schema: map[string]any{"type": "object"}},
+				Examples: []Example{{Description: "Fetch", Command: "tool fetch https://example.com"}},
+				Auth:     &CommandAuth{Required: true, Scopes: []string{"repo"}},
+			},
+		},
+	})
+	schema.Extensions = map[string]json.RawMessage{"x-vendor": json.RawMessage(`{"a":1}`)}
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := ValidateAgainstSpec(raw); len(diags) != 0 {
+		t.Errorf("Describe output should satisfy the spec, got %v", diags)
+	}
+}
+
+func TestValidateAgainstSpecErrors(t *testing.T) {
+	const base = `"specVersion":"2026-02-07","name":"t","version":"1","description":"d"`
+	tests := []struct {
+		name, doc, path, msg string
+	}{
+		{"invalid JSON", `{`, "", "invalid JSON"},
+		{"trailing data", `{` + base + `,"commands":[]} {}`, "", "unexpected data"},
+		{"not an object", `[]`, "", "expected object, got array"},
+		{"missing field", `{"specVersion":"x","name":"t","version":"1","commands":[]}`, "description", "required field is missing"},
+		{"unknown field", `{` + base + `,"commands":[],"colour":"red"}`, "colour", "unknown field"},
+		{"wrong type", `{` + base + `,"commands":{}}`, "commands", "expected array, got object"},
+		{"empty name", `{` + base + `,"commands":[{"name":"","description":"x"}]}`, "commands[0].name", "at least 1 characters"},
+		{"arg missing type", `{` + base + `,"commands":[{"name":"a","description":"x","args":[{"name":"--v"}]}]}`, "commands[0].args[0].type", "required field is missing"},
+		{"bad sha", `{` + base + `,"commands":[],"install":{"downloads":[{"os":"linux","arch":"amd64","url":"u","sha256":"nope"}]}}`, "install.downloads[0].sha256", "does not match"},
+		{"bad enum", `{` + base + `,"commands":[],"auth":{"envVar":"T","providers":[],"credentialSources":[{"type":"vault"}]}}`, "auth.credentialSources[0].type", "not one of"},
+		{"negative ttl", `{` + base + `,"commands":[],"auth":{"envVar":"T","providers":[],"tokenTtlSeconds":-1}}`, "auth.tokenTtlSeconds", "less than the minimum"},
+		{"fractional integer", `{` + base + `,"commands":[],"auth":{"envVar":"T","providers":[],"tokenTtlSeconds":1.5}}`, "auth.tokenTtlSeconds", "expected integer, got number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := ValidateAgainstSpec([]byte(tt.doc))
+			if len(diags) != 1 {
+				t.Fatalf("expected 1 diagnostic, got %v", diags)
+			}
+			if d := diags[0]; d.Severity != SeverityError || d.Path != tt.path || !strings.Contains(d.Message, tt.msg) {
+				t.Errorf("got %v, want %s: %s", d, tt.path, tt.msg)
+			}
+		})
+	}
+}
+
+func TestValidateAgainstSpecAllowsExtensions(t *testing.T) {
+	doc := `{"specVersion":"x","name":"t","version":"1","description":"d","x-top":1,
+		"commands":[{"name":"a","description":"x","x-cmd":true,"args":[{"name":"--v","type":"string","x-arg":[]}]}]}`
+	if diags := ValidateAgainstSpec([]byte(doc)); len(diags) != 0 {
+		t.Errorf("x- fields should be allowed, got %v", diags)
+	}
+}
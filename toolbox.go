@@ -0,0 +1,217 @@
+package mtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// ToolboxSchema is a catalog of several tools, as produced by Merge. Each
+// tool keeps its own schema and is namespaced by its name: the command
+// "pr list" of the tool "gh" is "gh pr list" in the toolbox.
+type ToolboxSchema struct {
+	SpecVersion string       `json:"specVersion"`
+	Tools       []ToolSchema `json:"tools"`
+}
+
+// Merge combines tool schemas into one toolbox, so a platform team can
+// publish a single catalog of its CLIs. Tools appear in the order given.
+// It reports every conflict it finds: a nil schema, a tool name that
+// isn't a single word, or two tools with the same name. The schemas are
+// copied, not shared, at the top level.
+func Merge(schemas ...*ToolSchema) (*ToolboxSchema, error) {
+	box := &ToolboxSchema{SpecVersion: MTPSpecVersion, Tools: make([]ToolSchema, 0, len(schemas))}
+	var errs []error
+	first := map[string]int{}
+	for i, s := range schemas {
+		switch {
+		case s == nil:
+			errs = append(errs, fmt.Errorf("mtp: schema %d is nil", i))
+			continue
+		case s.Name == "" || len(strings.Fields(s.Name)) != 1 || strings.TrimSpace(s.Name) != s.Name:
+			errs = append(errs, fmt.Errorf("mtp: schema %d: tool name %q is not a single word", i, s.Name))
+			continue
+		}
+		if j, ok := first[s.Name]; ok {
+			errs = append(errs, fmt.Errorf("mtp: schemas %d and %d are both named %q", j, i, s.Name))
+			continue
+		}
+		first[s.Name] = i
+		box.Tools = append(box.Tools, *s)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return box, nil
+}
+
+// Tool returns the tool with the given name, or nil.
+func (b *ToolboxSchema) Tool(name string) *ToolSchema {
+	i := slices.IndexFunc(b.Tools, func(t ToolSchema) bool { return t.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return &b.Tools[i]
+}
+
+// Command resolves a namespaced command name such as "gh pr list" to its
+// tool and descriptor. The tool name alone refers to the tool's root
+// command.
+func (b *ToolboxSchema) Command(name string) (*ToolSchema, *CommandDescriptor, bool) {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return nil, nil, false
+	}
+	tool := b.Tool(words[0])
+	if tool == nil {
+		return nil, nil, false
+	}
+	for i, cmd := range tool.Commands {
+		if slices.Equal(commandPath(tool.Name, cmd), words[1:]) {
+			return tool, &tool.Commands[i], true
+		}
+	}
+	return tool, nil, false
+}
+
+// Toolbox routes invocations to a directory of child MTP tools, busybox
+// style: "toolbox gh pr list" runs the child named gh with "pr list". The
+// children are found and named by their schemas, not their file names.
+type Toolbox struct {
+	Schema *ToolboxSchema
+	bins   map[string]string // Tool name -> executable
+}
+
+// describeTimeout bounds a tool's --mtp-describe run in DescribeExecutable.
+const describeTimeout = 10 * time.Second
+
+// LoadToolbox describes every executable in dir by running it with
+// --mtp-describe, and merges the schemas. Subdirectories, hidden files, and
+// files that aren't executable are skipped. Every executable must be an MTP
+// tool; a child that fails to describe itself, or two children with the
+// same tool name, fail the load.
+func LoadToolbox(ctx context.Context, dir string) (*Toolbox, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var schemas []*ToolSchema
+	var errs []error
+	bins := map[string]string{}
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if info, err := e.Info(); err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		s, err := DescribeExecutable(ctx, path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, ok := bins[s.Name]; !ok {
+			bins[s.Name] = path
+		}
+		schemas = append(schemas, s)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	box, err := Merge(schemas...)
+	if err != nil {
+		return nil, err
+	}
+	return &Toolbox{Schema: box, bins: bins}, nil
+}
+
+// DescribeExecutable runs the tool at path with --mtp-describe and parses
+// the schema it prints. The run is limited to ten seconds.
+func DescribeExecutable(ctx context.Context, path string) (*ToolSchema, error) {
+	ctx, cancel := context.WithTimeout(ctx, describeTimeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	c := exec.CommandContext(ctx, path, "--mtp-describe")
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running %s --mtp-describe: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	s, err := ParseSchema(out, ParseOptions{PreserveUnknown: true})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Exec runs the named tool with args, connected to the given streams, and
+// returns its exit code. The error is non-nil only when the tool is
+// unknown or couldn't be started.
+func (t *Toolbox) Exec(ctx context.Context, tool string, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	bin, ok := t.bins[tool]
+	if !ok {
+		return 0, fmt.Errorf("mtp: unknown tool %q", tool)
+	}
+	c := exec.CommandContext(ctx, bin, args...)
+	c.Stdin, c.Stdout, c.Stderr = stdin, stdout, stderr
+	err := c.Run()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return exit.ExitCode(), nil
+	}
+	return 0, err
+}
+
+// Command returns a root command for a toolbox binary called name. It has a
+// subcommand per tool that passes every argument, flags included, to the
+// child and exits with its exit code. --mtp-describe prints the merged
+// ToolboxSchema.
+func (t *Toolbox) Command(name string) *cobra.Command {
+	var describe bool
+	root := &cobra.Command{
+		Use:          name + " <tool> [args...]",
+		Short:        "Run a tool from the toolbox",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !describe {
+				return cmd.Help()
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			return enc.Encode(t.Schema)
+		},
+	}
+	root.Flags().BoolVar(&describe, "mtp-describe", false, "Output the merged JSON schema of every tool")
+
+	for _, tool := range t.Schema.Tools {
+		tool := tool.Name
+		root.AddCommand(&cobra.Command{
+			Use:                tool,
+			Short:              t.Schema.Tool(tool).Description,
+			DisableFlagParsing: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				code, err := t.Exec(cmd.Context(), tool, args, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+				if err != nil {
+					return err
+				}
+				if code != 0 {
+					os.Exit(code)
+				}
+				return nil
+			},
+		})
+	}
+	return root
+}
@@ -0,0 +1,145 @@
+package mtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	gh := &ToolSchema{Name: "gh", Version: "2.40.0", Commands: []CommandDescriptor{
+		{Name: "pr list", Path: []string{"pr", "list"}},
+	}}
+	kubectl := &ToolSchema{Name: "kubectl", Version: "1.29.0", Commands: []CommandDescriptor{
+		{Name: "kubectl", Path: []string{}},
+		{Name: "get"},
+	}}
+
+	box, err := Merge(gh, kubectl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if box.SpecVersion != MTPSpecVersion || len(box.Tools) != 2 || box.Tools[0].Name != "gh" {
+		t.Fatalf("unexpected toolbox %+v", box)
+	}
+	gh.Name = "changed"
+	if box.Tool("gh") == nil {
+		t.Error("Merge should copy the schemas")
+	}
+
+	if tool, cmd, ok := box.Command("gh pr list"); !ok || tool.Name != "gh" || cmd.Name != "pr list" {
+		t.Errorf("gh pr list not resolved: %v %v %v", tool, cmd, ok)
+	}
+	if _, cmd, ok := box.Command("kubectl"); !ok || cmd.Name != "kubectl" {
+		t.Errorf("the tool name should resolve to its root command, got %v", cmd)
+	}
+	if _, cmd, ok := box.Command("kubectl get"); !ok || cmd.Name != "get" {
+		t.Errorf("a command without a path should resolve by name, got %v", cmd)
+	}
+	if tool, _, ok := box.Command("gh issue list"); ok || tool == nil {
+		t.Error("an unknown command of a known tool should return the tool only")
+	}
+	if _, _, ok := box.Command("terraform plan"); ok {
+		t.Error("unknown tools should not resolve")
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	_, err := Merge(
+		&ToolSchema{Name: "gh"},
+		nil,
+		&ToolSchema{Name: "my tool"},
+		&ToolSchema{Name: "gh"},
+	)
+	if err == nil {
+		t.Fatal("expected conflicts")
+	}
+	for _, want := range []string{"schema 1 is nil", `"my tool" is not a single word`, `schemas 0 and 3 are both named "gh"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+}
+
+// writeChildTool writes a shell script that describes itself as the tool
+// name and otherwise echoes its arguments and exits with code.
+func writeChildTool(t *testing.T, dir, file, name string, code int) {
+	t.Helper()
+	schema := fmt.Sprintf(`{"specVersion":%q,"name":%q,"version":"1.0.0","description":"The %s tool","commands":[]}`, MTPSpecVersion, name, name)
+	script := fmt.Sprintf("#!/bin/sh\nif [ \"$1\" = --mtp-describe ]; then echo '%s'; exit 0; fi\necho %s \"$@\"\nexit %d\n", schema, name, code)
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestToolbox(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as child tools")
+	}
+	dir := t.TempDir()
+	writeChildTool(t, dir, "gh-2.40", "gh", 0)
+	writeChildTool(t, dir, "deploy", "deploy", 3)
+	os.WriteFile(filepath.Join(dir, "README"), []byte("not a tool"), 0o644)
+
+	box, err := LoadToolbox(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(box.Schema.Tools) != 2 || box.Schema.Tool("gh") == nil || box.Schema.Tool("deploy") == nil {
+		t.Fatalf("unexpected tools %+v", box.Schema.Tools)
+	}
+
+	var out bytes.Buffer
+	code, err := box.Exec(context.Background(), "gh", []string{"pr", "list", "--json"}, nil, &out, io.Discard)
+	if err != nil || code != 0 || out.String() != "gh pr list --json\n" {
+		t.Errorf("gh: code %d, err %v, output %q", code, err, out.String())
+	}
+	if code, err := box.Exec(context.Background(), "deploy", nil, nil, io.Discard, io.Discard); err != nil || code != 3 {
+		t.Errorf("deploy should exit 3, got %d, %v", code, err)
+	}
+	if _, err := box.Exec(context.Background(), "terraform", nil, nil, io.Discard, io.Discard); err == nil {
+		t.Error("unknown tools should be an error")
+	}
+
+	out.Reset()
+	root := box.Command("toolbox")
+	root.SetOut(&out)
+	root.SetArgs([]string{"--mtp-describe"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	var merged ToolboxSchema
+	if err := json.Unmarshal(out.Bytes(), &merged); err != nil || len(merged.Tools) != 2 {
+		t.Errorf("unexpected merged schema %s: %v", out.String(), err)
+	}
+
+	out.Reset()
+	root.SetArgs([]string{"gh", "--help"})
+	if err := root.Execute(); err != nil || out.String() != "gh --help\n" {
+		t.Errorf("flags should pass through to the child, got %q, %v", out.String(), err)
+	}
+}
+
+func TestLoadToolboxErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as child tools")
+	}
+	dir := t.TempDir()
+	writeChildTool(t, dir, "a", "gh", 0)
+	writeChildTool(t, dir, "b", "gh", 0)
+	if _, err := LoadToolbox(context.Background(), dir); err == nil || !strings.Contains(err.Error(), `both named "gh"`) {
+		t.Errorf("expected a name conflict, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "b"), []byte("#!/bin/sh\nexit 1\n"), 0o755)
+	if _, err := LoadToolbox(context.Background(), dir); err == nil || !strings.Contains(err.Error(), "--mtp-describe") {
+		t.Errorf("expected a describe failure, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package mtp
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// truncatedMarker is the JSON added to a schema when trimSchema sets
+// Truncated.
+const truncatedMarker = `,"truncated":true`
+
+// trimSchema shrinks schema until its JSON encoding fits in maxBytes.
+// It first drops descriptions and WhenToUse guidance, longest first, then
+// drops examples beyond the first for each command, last examples first.
+// The savings of each step are computed exactly from the field's encoded
+// size, so the schema is only marshaled once. Ties break by position in
+// the schema, so the same input always yields the same output. If
+// trimming can't get under the budget, the schema is returned as small as
+// it can be made.
+func trimSchema(schema *ToolSchema, maxBytes int) {
+	data, err := json.Marshal(schema)
+	if err != nil || len(data) <= maxBytes {
+		return
+	}
+	schema.Truncated = true
+	excess := len(data) + len(truncatedMarker) - maxBytes
+
+	// Pass 1: descriptions, longest first.
+	type descRef struct {
+		ptr     *string
+		size    int
+		savings int
+	}
+	var descs []descRef
+	for i := range schema.Commands {
+		cmd := &schema.Commands[i]
+		if cmd.Description != "" {
+			// Command descriptions aren't omitempty; "" stays behind.
+			n := encodedLen(cmd.Description)
+			descs = append(descs, descRef{&cmd.Description, n, n - 2})
+		}
+		if cmd.WhenToUse != "" {
+			n := encodedLen(cmd.WhenToUse)
+			descs = append(descs, descRef{&cmd.WhenToUse, n, n + len(`,"whenToUse":`)})
+		}
+		for j := range cmd.Args {
+			arg := &cmd.Args[j]
+			if arg.Description != "" {
+				n := encodedLen(arg.Description)
+				descs = append(descs, descRef{&arg.Description, n, n + len(`,"description":`)})
+			}
+		}
+	}
+	sort.SliceStable(descs, func(i, j int) bool { return descs[i].size > descs[j].size })
+	for _, d := range descs {
+		if excess <= 0 {
+			return
+		}
+		*d.ptr = ""
+		excess -= d.savings
+	}
+
+	// Pass 2: extra examples, highest index first across all commands.
+	maxExamples := 0
+	for _, cmd := range schema.Commands {
+		if len(cmd.Examples) > maxExamples {
+			maxExamples = len(cmd.Examples)
+		}
+	}
+	for idx := maxExamples - 1; idx >= 1 && excess > 0; idx-- {
+		for i := range schema.Commands {
+			cmd := &schema.Commands[i]
+			if len(cmd.Examples) != idx+1 {
+				continue
+			}
+			excess -= encodedLen(cmd.Examples[idx]) + 1 // plus the separating comma
+			cmd.Examples = cmd.Examples[:idx]
+			if excess <= 0 {
+				return
+			}
+		}
+	}
+}
+
+// encodedLen returns the length of v's JSON encoding.
+func encodedLen(v any) int {
+	data, _ := json.Marshal(v)
+	return len(data)
+}
@@ -1,5 +1,10 @@
 package mtp
 
+import (
+	"encoding/json"
+	"io/fs"
+)
+
 // ToolSchema is the top-level --describe output for a CLI tool.
 type ToolSchema struct {
 	SpecVersion string              `json:"specVersion"`
@@ -8,27 +13,123 @@ type ToolSchema struct {
 	Description string              `json:"description"`
 	Commands    []CommandDescriptor `json:"commands"`
 	Auth        *AuthConfig         `json:"auth,omitempty"`
+	Requires    *Requirements       `json:"requires,omitempty"`
+	Install     *InstallInfo        `json:"install,omitempty"`
+	Changelog   []ChangeEntry       `json:"changelog,omitempty"`
+	ErrorCodes  []ErrorCode         `json:"errorCodes,omitempty"` // See DescribeOptions.ErrorCodes
+	Truncated   bool                `json:"truncated,omitempty"`  // Content was dropped to fit DescribeOptions.MaxBytes
+
+	// Definitions holds JSON Schemas shared by commands' IO schemas, which
+	// refer to them as {"$ref": "#/definitions/<name>"}; see Ref and
+	// ResolveRefs.
+	Definitions map[string]map[string]any `json:"definitions,omitempty"`
+
+	// Extensions holds fields this SDK doesn't define, kept by ParseSchema
+	// with PreserveUnknown and re-emitted when the schema is encoded.
+	Extensions map[string]json.RawMessage `json:"-"`
+}
+
+// ChangeEntry summarizes one release of the tool, so a client comparing a
+// cached schema with a new one can show what changed in between.
+type ChangeEntry struct {
+	Version string   `json:"version"`
+	Date    string   `json:"date,omitempty"` // YYYY-MM-DD
+	Changes []string `json:"changes"`
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
 }
 
 // CommandDescriptor describes a single command within a tool.
 type CommandDescriptor struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	Args        []ArgDescriptor `json:"args,omitempty"`
-	Stdin       *IODescriptor   `json:"stdin,omitempty"`
-	Stdout      *IODescriptor   `json:"stdout,omitempty"`
-	Examples    []Example       `json:"examples,omitempty"`
-	Auth        *CommandAuth    `json:"auth,omitempty"`
+	Name           string            `json:"name"`
+	Path           []string          `json:"path"`                // Name split into argv words; empty for the root
+	Version        string            `json:"version,omitempty"`   // Set when the command is versioned apart from the tool
+	Stability      string            `json:"stability,omitempty"` // See Stability* constants; empty when undeclared
+	Description    string            `json:"description"`
+	WhenToUse      string            `json:"whenToUse,omitempty"`      // Routing guidance for agents choosing among commands
+	CommonMistakes []string          `json:"commonMistakes,omitempty"` // Known ways agents misuse the command
+	SeeAlso        []string          `json:"seeAlso,omitempty"`        // Names of related commands, such as natural follow-ups
+	Args           []ArgDescriptor   `json:"args,omitempty"`
+	Stdin          *IODescriptor     `json:"stdin,omitempty"`
+	Stdout         *IODescriptor     `json:"stdout,omitempty"`
+	Examples       []Example         `json:"examples,omitempty"`
+	Auth           *CommandAuth      `json:"auth,omitempty"`
+	MayElicit      bool              `json:"mayElicit,omitempty"`
+	Requires       *Requirements     `json:"requires,omitempty"`
+	Endpoints      []string          `json:"endpoints,omitempty"`    // Network destinations the command contacts
+	Filesystem     *FilesystemAccess `json:"filesystem,omitempty"`   // Paths the command reads and writes
+	Capabilities   []string          `json:"capabilities,omitempty"` // See Cap* constants
+	Cost           *Cost             `json:"cost,omitempty"`
+	Locks          []string          `json:"locks,omitempty"` // Named shared resources the command holds exclusively
+	Deprecated     *Deprecation      `json:"deprecated,omitempty"`
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
 }
 
+// Command stability levels for CommandDescriptor.Stability. Conservative
+// clients can restrict themselves to stable commands.
+const (
+	StabilityAlpha  = "alpha"  // May change or disappear without notice
+	StabilityBeta   = "beta"   // Feature-complete, but its interface may still change
+	StabilityStable = "stable" // Covered by the tool's compatibility promise
+)
+
 // ArgDescriptor describes a single argument (flag or positional) for a command.
 type ArgDescriptor struct {
-	Name        string   `json:"name"`
-	Type        string   `json:"type"`
-	Description string   `json:"description,omitempty"`
-	Required    bool     `json:"required,omitempty"`
-	Default     any      `json:"default,omitempty"`
-	Values      []string `json:"values,omitempty"`
+	Name        string       `json:"name"`
+	Type        string       `json:"type"`
+	Description string       `json:"description,omitempty"`
+	Required    bool         `json:"required,omitempty"`
+	Default     any          `json:"default,omitempty"`
+	Values      []string     `json:"values,omitempty"`
+	DefaultFrom string       `json:"defaultFrom,omitempty"` // Where the default comes from, e.g. "$TOOL_HOME"; see DefaultFrom
+	Sensitive   bool         `json:"sensitive,omitempty"`   // Holds a secret; clients must not log or display its value
+	Unit        string       `json:"unit,omitempty"`        // Unit of a numeric value, e.g. "seconds", "bytes", "MiB", "percent"
+	ContentHint string       `json:"contentHint,omitempty"` // What free text holds, e.g. "sql", "regex", "markdown"; see Hint* constants
+	Style       string       `json:"style,omitempty"`       // How an array or boolean flag is written on the command line; see Style* constants
+	Variadic    bool         `json:"variadic,omitempty"`    // Positional that accepts one or more values
+	Group       string       `json:"group,omitempty"`       // Display group, e.g. "Output" (see ArgGroup)
+	Advanced    bool         `json:"advanced,omitempty"`    // Rarely needed; clients should deprioritize it
+	Deprecated  *Deprecation `json:"deprecated,omitempty"`
+
+	// RequiredIf and ConflictsWith relate the arg to other flags. Each entry
+	// is a condition: "--name" holds when that flag is given, "--name=value"
+	// when its value is value. The arg is required, or forbidden, when any
+	// condition holds. See RequiredIf and ConflictsWith.
+	RequiredIf    []string `json:"requiredIf,omitempty"`
+	ConflictsWith []string `json:"conflictsWith,omitempty"`
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
+}
+
+// Content hints for ArgDescriptor.ContentHint. For source code, use the
+// language's Markdown code fence name instead (e.g. "python", "jq").
+const (
+	HintSQL      = "sql"
+	HintRegex    = "regex"
+	HintMarkdown = "markdown"
+	HintJSON     = "json"
+	HintCode     = "code" // Source code in an unspecified language
+)
+
+// Encodings for ArgDescriptor.Style. An array flag without a style is
+// repeated, and a boolean flag without one is given by presence.
+const (
+	StyleRepeated = "repeated" // Array flag: --tag a --tag b
+	StyleComma    = "comma"    // Array flag: --tag a,b
+	StylePresence = "presence" // Boolean flag: --verbose when true, omitted when false
+	StyleExplicit = "explicit" // Boolean flag: --verbose=true or --verbose=false
+)
+
+// Deprecation marks a command or argument as deprecated, with enough of a
+// timeline for automated consumers to plan a migration.
+type Deprecation struct {
+	Message          string `json:"message,omitempty"`          // What to use instead, or why
+	SunsetDate       string `json:"sunsetDate,omitempty"`       // YYYY-MM-DD after which it may stop working
+	RemovedInVersion string `json:"removedInVersion,omitempty"` // First tool version without it
+	ReplacedBy       string `json:"replacedBy,omitempty"`       // Successor command name (e.g. "database migrate") or flag
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
 }
 
 // IODescriptor describes stdin or stdout for a command.
@@ -36,6 +137,19 @@ type IODescriptor struct {
 	ContentType string         `json:"contentType,omitempty"`
 	Description string         `json:"description,omitempty"`
 	Schema      map[string]any `json:"schema,omitempty"`
+	FileRefs    bool           `json:"fileRefs,omitempty"` // Values may be {"$file": path} references
+
+	// OutputPointers maps semantic names to JSON Pointers (RFC 6901) into
+	// the data, e.g. "id": "/result/id", so agents chaining commands know
+	// which field of one command's output feeds the next one's argument.
+	// See ResolvePointer.
+	OutputPointers map[string]string `json:"outputPointers,omitempty"`
+
+	// SchemaFile names a JSON file holding Schema, read when the schema is
+	// described (see DescribeOptions.FS) instead of compiled into the binary.
+	SchemaFile string `json:"-"`
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
 }
 
 // Example is a usage example for a command.
@@ -43,6 +157,97 @@ type Example struct {
 	Description string `json:"description,omitempty"`
 	Command     string `json:"command"`
 	Output      string `json:"output,omitempty"`
+
+	// CommandFile and OutputFile name files holding Command and Output,
+	// read when the schema is described (see DescribeOptions.FS).
+	CommandFile string `json:"-"`
+	OutputFile  string `json:"-"`
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
+}
+
+// Requirements lists what must be present in the environment before a tool
+// or command can run. Hosts use it to pre-flight invocations (see --mtp-check).
+type Requirements struct {
+	EnvVars  []string `json:"envVars,omitempty"`  // Environment variables that must be set
+	Binaries []string `json:"binaries,omitempty"` // Executables that must be on PATH (e.g. "git", "docker")
+	OS       []string `json:"os,omitempty"`       // Supported operating systems as GOOS values (e.g. "linux", "darwin")
+
+	// MinOSVersion maps a GOOS value to the oldest supported release of that
+	// system, e.g. {"darwin": "13.0", "linux": "5.10"}. Linux versions are
+	// kernel releases.
+	MinOSVersion map[string]string `json:"minOsVersion,omitempty"`
+
+	// Dependencies are executables and services the tool shells out to or
+	// talks to, with minimum versions. Unlike Binaries, Check runs them to
+	// verify the version, or dials the service.
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
+}
+
+// Dependency is an external executable or local service a command needs.
+type Dependency struct {
+	Name       string `json:"name"`                 // Executable name (e.g. "docker"), or a label for a service
+	MinVersion string `json:"minVersion,omitempty"` // Oldest supported version (e.g. "24.0")
+
+	// VersionArgs make the executable print its version; the first dotted
+	// number in the output is compared with MinVersion. Defaults to
+	// ["--version"].
+	VersionArgs []string `json:"versionArgs,omitempty"`
+
+	// Endpoint makes this a service dependency, checked by connecting to
+	// it: "unix:///var/run/docker.sock" or "tcp://localhost:5432".
+	Endpoint string `json:"endpoint,omitempty"`
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
+}
+
+// FilesystemAccess declares, as glob patterns, the paths a command reads
+// and writes, so hosts can mount only those (read-only or read-write) and
+// auditors can see which commands touch user data. Patterns use path.Match
+// syntax plus "**" for any depth, and may start with "~/" (the user's home
+// directory), "$VAR/" (an environment variable), or "./" (the working
+// directory).
+type FilesystemAccess struct {
+	Reads  []string `json:"reads,omitempty"`
+	Writes []string `json:"writes,omitempty"` // Writing implies reading
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
+}
+
+// Cost declares that running a command costs money, so budget-aware hosts
+// can ask for approval first. Estimate, Currency, and Unit are optional and
+// approximate: "about 0.10 USD per hour".
+type Cost struct {
+	Billable bool    `json:"billable"`
+	Estimate float64 `json:"estimate,omitempty"` // Approximate cost per Unit
+	Currency string  `json:"currency,omitempty"` // ISO 4217 code, e.g. "USD"
+	Unit     string  `json:"unit,omitempty"`     // What is charged for: "call", "hour", "GB", ...
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
+}
+
+// InstallInfo tells a host that found the tool in a registry, but not on
+// PATH, how to obtain it. Set whichever channels the tool is published on.
+type InstallInfo struct {
+	Brew      string     `json:"brew,omitempty"`      // Homebrew formula (e.g. "acme/tap/imgtool")
+	Apt       string     `json:"apt,omitempty"`       // Debian/Ubuntu package name
+	GoInstall string     `json:"goInstall,omitempty"` // go install path (e.g. "github.com/acme/imgtool/cmd/imgtool@latest")
+	Image     string     `json:"image,omitempty"`     // Container image reference, ideally pinned by digest
+	Downloads []Download `json:"downloads,omitempty"` // Prebuilt binaries
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
+}
+
+// Download is a prebuilt binary for one platform.
+type Download struct {
+	OS     string `json:"os"`   // GOOS value
+	Arch   string `json:"arch"` // GOARCH value
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"` // Hex-encoded checksum of the file at URL
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
 }
 
 // AuthConfig describes the authentication requirements for a tool.
@@ -50,39 +255,257 @@ type AuthConfig struct {
 	Required  bool           `json:"required,omitempty"`
 	EnvVar    string         `json:"envVar"`
 	Providers []AuthProvider `json:"providers"`
+	// CredentialSources lists where the tool looks for a credential, in
+	// precedence order (first match wins).
+	CredentialSources []CredentialSource `json:"credentialSources,omitempty"`
+	// CredentialCommand prints a fresh credential to stdout when run, in the
+	// style of git/docker credential helpers (e.g. "tool auth print-token").
+	CredentialCommand string `json:"credentialCommand,omitempty"`
+	// TokenTTLSeconds is how long a token stays valid once issued.
+	TokenTTLSeconds int `json:"tokenTtlSeconds,omitempty"`
+	// CacheTokens tells hosts a token may be reused across invocations until
+	// it expires. When false, hosts should obtain a fresh token per call.
+	CacheTokens bool `json:"cacheTokens,omitempty"`
+	// Environments are named deployment targets (e.g. "prod", "staging") with
+	// their own endpoints and env vars. Select one with --mtp-env.
+	Environments       []AuthEnvironment `json:"environments,omitempty"`
+	DefaultEnvironment string            `json:"defaultEnvironment,omitempty"`
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
+}
+
+// AuthEnvironment overrides auth settings for one deployment target.
+// Empty fields inherit from the enclosing AuthConfig.
+type AuthEnvironment struct {
+	Name      string         `json:"name"`
+	EnvVar    string         `json:"envVar,omitempty"`
+	Providers []AuthProvider `json:"providers,omitempty"` // Replaces AuthConfig.Providers when set
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
 }
 
+// CredentialSource is one place a tool reads its credential from.
+type CredentialSource struct {
+	Type    string `json:"type"`              // See Source* constants
+	EnvVar  string `json:"envVar,omitempty"`  // For "env"
+	Path    string `json:"path,omitempty"`    // For "file"; a leading ~ is the user's home directory
+	Service string `json:"service,omitempty"` // For "keychain"
+	Account string `json:"account,omitempty"` // For "keychain"
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
+}
+
+// Credential source types for CredentialSource.Type.
+const (
+	SourceEnv      = "env"
+	SourceFile     = "file"
+	SourceKeychain = "keychain"
+)
+
 // AuthProvider describes a single authentication provider.
 type AuthProvider struct {
-	ID               string   `json:"id"`
-	Type             string   `json:"type"`
-	DisplayName      string   `json:"displayName,omitempty"`
-	AuthorizationURL string   `json:"authorizationUrl,omitempty"`
-	TokenURL         string   `json:"tokenUrl,omitempty"`
-	Scopes           []string `json:"scopes,omitempty"`
-	ClientID         string   `json:"clientId,omitempty"`
-	RegistrationURL  string   `json:"registrationUrl,omitempty"`
-	Instructions     string   `json:"instructions,omitempty"`
+	ID                     string   `json:"id"`
+	Type                   string   `json:"type"`
+	DisplayName            string   `json:"displayName,omitempty"`
+	IssuerURL              string   `json:"issuerUrl,omitempty"` // OIDC issuer; see DiscoverOIDC
+	AuthorizationURL       string   `json:"authorizationUrl,omitempty"`
+	TokenURL               string   `json:"tokenUrl,omitempty"`
+	DeviceAuthorizationURL string   `json:"deviceAuthorizationUrl,omitempty"` // RFC 8628 device authorization endpoint
+	GrantTypes             []string `json:"grantTypes,omitempty"`             // Supported OAuth grants (see Grant* constants)
+	UsesPKCE               bool     `json:"usesPkce,omitempty"`               // Clients must send an RFC 7636 code challenge
+	CodeChallengeMethods   []string `json:"codeChallengeMethods,omitempty"`   // e.g. "S256", "plain"
+	SupportsRefresh        bool     `json:"supportsRefresh,omitempty"`
+	RefreshURL             string   `json:"refreshUrl,omitempty"`           // Defaults to TokenURL
+	AccessTokenLifetime    int      `json:"accessTokenLifetime,omitempty"`  // Typical access token lifetime in seconds
+	RefreshTokenLifetime   int      `json:"refreshTokenLifetime,omitempty"` // Typical refresh token lifetime in seconds
+	Scopes                 []string `json:"scopes,omitempty"`
+	ClientID               string   `json:"clientId,omitempty"`
+	RegistrationURL        string   `json:"registrationUrl,omitempty"` // Where to obtain credentials (e.g. an API key page)
+	Instructions           string   `json:"instructions,omitempty"`
+
+	// API-key delivery. A key may be accepted through any combination of these.
+	EnvVar     string `json:"envVar,omitempty"`     // Env var holding the key; defaults to AuthConfig.EnvVar
+	HeaderName string `json:"headerName,omitempty"` // HTTP header the tool sends the key in (informational)
+	FlagName   string `json:"flagName,omitempty"`   // CLI flag accepting the key (e.g. "--api-key")
+	KeyPattern string `json:"keyPattern,omitempty"` // Regular expression the key is expected to match
+
+	// Basic auth credentials, for legacy services.
+	UsernameEnvVar string `json:"usernameEnvVar,omitempty"`
+	PasswordEnvVar string `json:"passwordEnvVar,omitempty"`
+
+	// Ambient cloud credentials (aws-sigv4, gcp-adc, azure-msi). The tool uses
+	// whatever credentials the environment already provides.
+	Region   string `json:"region,omitempty"`   // AWS region to sign for
+	Service  string `json:"service,omitempty"`  // AWS service name to sign for (e.g. "execute-api")
+	Audience string `json:"audience,omitempty"` // Token audience; also sent as the OAuth "audience" parameter
+	// Resource is the RFC 8707 resource indicator clients send when requesting
+	// OAuth tokens for multi-tenant APIs.
+	Resource string `json:"resource,omitempty"`
+
+	// mTLS client certificates. Each may be given as a path or an env var
+	// holding a path.
+	CertPath   string `json:"certPath,omitempty"`
+	CertEnvVar string `json:"certEnvVar,omitempty"`
+	KeyPath    string `json:"keyPath,omitempty"`
+	KeyEnvVar  string `json:"keyEnvVar,omitempty"`
+	CAPath     string `json:"caPath,omitempty"` // CA bundle used to verify the server
+	CAEnvVar   string `json:"caEnvVar,omitempty"`
+	CARequired bool   `json:"caRequired,omitempty"` // Server uses a private CA; a bundle must be supplied
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
 }
 
+// Provider types for AuthProvider.Type.
+const (
+	ProviderOAuth2 = "oauth2"
+	ProviderAPIKey = "api-key"
+	ProviderMTLS   = "mtls"
+	ProviderBasic  = "basic"
+
+	// Ambient cloud credentials.
+	ProviderAWSSigV4 = "aws-sigv4"
+	ProviderGCPADC   = "gcp-adc"
+	ProviderAzureMSI = "azure-msi"
+)
+
+// OAuth 2.0 grant types for AuthProvider.GrantTypes.
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+	GrantClientCredentials = "client_credentials"
+	GrantRefreshToken      = "refresh_token"
+)
+
 // CommandAuth describes per-command authentication requirements.
 type CommandAuth struct {
 	Required bool     `json:"required,omitempty"`
 	Scopes   []string `json:"scopes,omitempty"`
+
+	Extensions map[string]json.RawMessage `json:"-"` // See ToolSchema.Extensions
 }
 
 // DescribeOptions provides metadata that Cobra doesn't natively expose.
 type DescribeOptions struct {
 	Commands map[string]*CommandAnnotation
+	// Paths annotates commands by path segments instead of a space-joined
+	// name; see PathAnnotation. A Paths entry beats a Commands entry for the
+	// same command.
+	Paths []PathAnnotation
+	// Match annotates whole command families, such as every command
+	// below "db", with a predicate on the command path. Commands keys can
+	// do the same with glob patterns ("db *"). A command's own annotation
+	// wins field by field over its families'.
+	Match []MatchAnnotation
+	// Defaults is merged into every command's annotation, below all the
+	// others, for declarations shared by the whole tool such as a common
+	// auth requirement.
+	Defaults *CommandAnnotation
+	// RootName is the schema name of the root command when it runs on its
+	// own. It defaults to "_root"; single-command tools usually set it to
+	// the tool's name. The root's Path is empty whatever its name.
+	RootName string
 	Auth     *AuthConfig
+	Requires *Requirements // Tool-level requirements
+	Install  *InstallInfo  // How to obtain the tool
+	// Changelog lists recent releases, newest first.
+	Changelog []ChangeEntry
+	// ErrorCodes lists the codes the tool reports with NewError, and their
+	// exit statuses for ExitCode.
+	ErrorCodes []ErrorCode
+	// Definitions declares JSON Schemas shared by several commands' stdin
+	// and stdout, emitted once as the tool's "definitions". Refer to one
+	// with Ref, or register it with Define.
+	Definitions map[string]map[string]any
+	// Strict makes WithDescribe refuse to print a schema when validation
+	// finds errors; diagnostics go to stderr and the process exits 1.
+	Strict bool
+	// SortCommands orders sibling commands in the schema. The zero value
+	// keeps Cobra's order, which depends on registration order when
+	// cobra.EnableCommandSorting is off.
+	SortCommands CommandOrder
+	// MaxDescriptionLength caps every description at this many characters,
+	// ending truncated text with "…". Zero means no limit.
+	MaxDescriptionLength int
+	// MaxBytes is a budget for the encoded schema. Larger schemas are
+	// trimmed (descriptions first, then examples beyond the first per
+	// command) and marked Truncated. Zero means no limit.
+	MaxBytes int
+	// Parallelism is the number of workers that extract top-level subtrees
+	// concurrently. Output is identical to a sequential walk. Values of 0
+	// or 1 walk sequentially; runtime.GOMAXPROCS(0) is a good choice for
+	// trees with thousands of commands.
+	Parallelism int
+	// FS resolves Example.CommandFile, Example.OutputFile, and
+	// IODescriptor.SchemaFile, typically an embed.FS. If nil, paths are
+	// read from the OS filesystem.
+	FS fs.FS
 }
 
+// CommandOrder selects how DescribeOptions.SortCommands orders commands.
+type CommandOrder string
+
+const (
+	// SortAlphabetical orders sibling commands by name.
+	SortAlphabetical CommandOrder = "alpha"
+	// SortByGroup orders sibling commands by Cobra group, in the order the
+	// groups were added to the parent, then by name. Ungrouped commands
+	// come last.
+	SortByGroup CommandOrder = "group"
+)
+
 // CommandAnnotation supplements a command with MTP metadata.
 type CommandAnnotation struct {
-	Args     []ArgDescriptor   // Positional args (Cobra has no typed positional args)
-	ArgTypes map[string]string // Flag name -> MTP type override (e.g. "port" -> "integer")
-	Stdin    *IODescriptor
-	Stdout   *IODescriptor
-	Examples []Example
-	Auth     *CommandAuth
+	Args      []ArgDescriptor   // Positional args (Cobra has no typed positional args)
+	ArgTypes  map[string]string // Flag name -> MTP type override (e.g. "port" -> "integer")
+	Stdin     *IODescriptor
+	Stdout    *IODescriptor
+	Examples  []Example
+	Auth      *CommandAuth
+	MayElicit bool // Command may call Ask for mid-execution input
+	Requires  *Requirements
+	// WhenToUse tells an agent when to pick this command over its
+	// neighbors, beyond what the one-line description conveys (e.g. "Use
+	// validate before convert for untrusted input").
+	WhenToUse string
+	// CommonMistakes lists known failure modes, each a short instruction
+	// ("Do not pass a directory to <input_file>"), so agents avoid them.
+	CommonMistakes []string
+	// SeeAlso names related commands (e.g. "validate" on "convert"), so
+	// clients can suggest natural follow-up actions. Each must be the name
+	// of another command in the schema.
+	SeeAlso []string
+	// Endpoints lists the hosts or URL patterns the command contacts, so
+	// network-restricted sandboxes can allow exactly that egress: a
+	// hostname ("api.github.com"), a host wildcard ("*.s3.amazonaws.com"),
+	// a host and port ("db.internal:5432"), or a URL prefix
+	// ("https://api.github.com/repos/*").
+	Endpoints []string
+	// Filesystem declares the paths the command reads and writes.
+	Filesystem *FilesystemAccess
+	// Capabilities lists what the command needs from its host (see the
+	// Cap* constants) beyond those implied by Endpoints, Filesystem, and
+	// Requires, which are added automatically.
+	Capabilities []string
+	// Cost declares that the command incurs monetary cost, such as
+	// provisioning cloud resources.
+	Cost *Cost
+	// Locks names shared resources the command needs exclusive use of
+	// while it runs (e.g. "git-index", "statefile"). A host running several
+	// commands at once won't run two that share a lock concurrently.
+	Locks []string
+	// Version is the command's own version, for subcommands released
+	// independently of the tool (e.g. plugins). Clients caching per-command
+	// capabilities key them by it.
+	Version string
+	// Stability is the command's maturity (see the Stability* constants).
+	// When empty, it comes from the Cobra group the command belongs to, if
+	// that group's ID or title contains "alpha", "beta", or "stable".
+	Stability string
+	// Deprecated adds a timeline to the command's deprecation. Message
+	// defaults to cobra.Command.Deprecated; setting Deprecated here marks
+	// the command deprecated even if Cobra doesn't.
+	Deprecated *Deprecation
+	// DeprecatedArgs does the same for flags, keyed by flag name, on top of
+	// pflag's MarkDeprecated.
+	DeprecatedArgs map[string]*Deprecation
 }
@@ -0,0 +1,176 @@
+package mtp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// parseUseArgs extracts positional arg descriptors from a Cobra Use string.
+// It understands the common use-line conventions:
+//
+//	<required>  [optional]  NAME (uppercase placeholder, required)
+//	<src>...  [file...]  [ARG]...          (variadic)
+//	[(-o|--output)=TYPE]  [-f FILE]  [--]  (flag placeholders, skipped)
+//	(TYPE NAME | TYPE/NAME)                (alternatives; first one names the arg)
+//	[flags]  [options]  [command]          (Cobra boilerplate, skipped)
+//
+// Lowercase bare words are literals (subcommand keywords) and are skipped.
+func parseUseArgs(use string) []ArgDescriptor {
+	tokens := splitUseTokens(use)
+	if len(tokens) <= 1 {
+		return nil
+	}
+
+	var args []ArgDescriptor
+	for _, tok := range tokens[1:] {
+		if arg, ok := parseUseToken(tok); ok {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// useBoilerplate are bracketed words Cobra and common CLIs put in Use
+// strings that don't name a positional argument.
+var useBoilerplate = map[string]bool{
+	"flags":   true,
+	"FLAGS":   true,
+	"options": true,
+	"OPTIONS": true,
+	"command": true,
+}
+
+// useIdent matches the identifier that names an argument.
+var useIdent = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_-]*`)
+
+// splitUseTokens splits a use line on whitespace outside of <>, [], and ().
+func splitUseTokens(use string) []string {
+	var tokens []string
+	var cur strings.Builder
+	depth := 0
+
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for _, r := range use {
+		switch r {
+		case '<', '[', '(':
+			depth++
+		case '>', ']', ')':
+			if depth > 0 {
+				depth--
+			}
+		case ' ', '\t', '\n':
+			if depth == 0 {
+				flush()
+				continue
+			}
+		}
+		cur.WriteRune(r)
+	}
+	flush()
+	return tokens
+}
+
+// parseUseToken turns one top-level use-line token into an argument.
+func parseUseToken(tok string) (ArgDescriptor, bool) {
+	variadic := false
+	if strings.HasSuffix(tok, "...") {
+		variadic = true
+		tok = strings.TrimSuffix(tok, "...")
+	}
+	if tok == "" || tok == "--" {
+		return ArgDescriptor{}, false
+	}
+
+	var inner string
+	required := true
+	switch tok[0] {
+	case '<':
+		inner = trimEnclosing(tok, '>')
+	case '(':
+		inner = trimEnclosing(tok, ')')
+	case '[':
+		inner = trimEnclosing(tok, ']')
+		required = false
+	default:
+		if !isUsePlaceholder(tok) {
+			return ArgDescriptor{}, false
+		}
+		inner = tok
+	}
+
+	inner = strings.TrimSpace(inner)
+	if strings.HasSuffix(inner, "...") {
+		variadic = true
+		inner = strings.TrimSpace(strings.TrimSuffix(inner, "..."))
+	}
+	if inner == "" || useBoilerplate[inner] {
+		return ArgDescriptor{}, false
+	}
+	if strings.HasPrefix(inner, "-") || strings.HasPrefix(inner, "(-") {
+		return ArgDescriptor{}, false
+	}
+
+	// Alternatives: the first one names the argument.
+	inner = strings.TrimSpace(splitTopLevel(inner, '|')[0])
+	name := useIdent.FindString(inner)
+	if name == "" {
+		return ArgDescriptor{}, false
+	}
+	if name == strings.ToUpper(name) {
+		name = strings.ToLower(name)
+	}
+
+	arg := ArgDescriptor{Name: name, Type: "string", Required: required}
+	if variadic {
+		arg.Type = "array"
+		arg.Variadic = true
+	}
+	return arg, true
+}
+
+// isUsePlaceholder reports whether a bare word is an uppercase placeholder
+// such as NAME or TYPE[.VERSION], rather than a literal keyword.
+func isUsePlaceholder(word string) bool {
+	ident := useIdent.FindString(word)
+	if ident == "" || !strings.HasPrefix(word, ident) {
+		return false
+	}
+	return ident == strings.ToUpper(ident) && strings.ToLower(ident) != ident
+}
+
+// trimEnclosing strips the opening bracket and, if present, the closing one.
+func trimEnclosing(tok string, close byte) string {
+	tok = tok[1:]
+	if strings.HasSuffix(tok, string(close)) {
+		tok = tok[:len(tok)-1]
+	}
+	return tok
+}
+
+// splitTopLevel splits s on sep where sep is not nested in brackets.
+func splitTopLevel(s string, sep rune) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '<', '[', '(':
+			depth++
+		case '>', ']', ')':
+			if depth > 0 {
+				depth--
+			}
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
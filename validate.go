@@ -0,0 +1,926 @@
+package mtp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	pathpkg "path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Severity levels for Diagnostic.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a single problem found while validating a schema.
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Path     string `json:"path"` // e.g. "commands[fetch].auth.scopes"
+	Message  string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Severity, d.Path, d.Message)
+}
+
+// HasErrors reports whether any diagnostic has error severity.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateSchema checks a schema for problems that would break clients.
+// It returns nil if the schema is valid.
+//
+// It also screens descriptions for content an agent host shouldn't feed to
+// a model unreviewed: excessive length, embedded instructions, and HTML or
+// script. These findings are warnings, since they are heuristics.
+func ValidateSchema(schema *ToolSchema) []Diagnostic {
+	var diags []Diagnostic
+	diags = append(diags, lintDescription("description", schema.Description)...)
+	diags = append(diags, validateInstall(schema.Install)...)
+	diags = append(diags, validateChangelog(schema.Changelog)...)
+	diags = append(diags, validateErrorCodes(schema.ErrorCodes)...)
+	diags = append(diags, validateDefinitions(schema)...)
+	for _, cmd := range schema.Commands {
+		prefix := "commands[" + cmd.Name + "]"
+		diags = append(diags, lintDescription(prefix+".description", cmd.Description)...)
+		diags = append(diags, lintDescription(prefix+".whenToUse", cmd.WhenToUse)...)
+		for i, m := range cmd.CommonMistakes {
+			path := fmt.Sprintf("%s.commonMistakes[%d]", prefix, i)
+			if strings.TrimSpace(m) == "" {
+				diags = append(diags, Diagnostic{Severity: SeverityWarning, Path: path, Message: "empty common mistake"})
+				continue
+			}
+			diags = append(diags, lintDescription(path, m)...)
+		}
+		diags = append(diags, validateDeprecation(prefix+".deprecated", cmd.Deprecated)...)
+		diags = append(diags, validateReplacements(schema, cmd)...)
+		diags = append(diags, validateSeeAlso(schema, cmd)...)
+		diags = append(diags, validatePath(prefix+".path", cmd)...)
+		diags = append(diags, validateEndpoints(prefix+".endpoints", cmd.Endpoints)...)
+		for i, c := range cmd.Capabilities {
+			if !slices.Contains(capabilityOrder, c) {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityWarning,
+					Path:     fmt.Sprintf("%s.capabilities[%d]", prefix, i),
+					Message:  fmt.Sprintf("unknown capability %q; hosts may not recognize it", c),
+				})
+			}
+		}
+		if cmd.Stability != "" && !slices.Contains(stabilityLevels, cmd.Stability) {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Path:     prefix + ".stability",
+				Message:  fmt.Sprintf("unknown stability %q; use alpha, beta, or stable", cmd.Stability),
+			})
+		}
+		diags = append(diags, validateCost(prefix+".cost", cmd.Cost)...)
+		diags = append(diags, validateLocks(prefix+".locks", cmd.Locks)...)
+		if fs := cmd.Filesystem; fs != nil {
+			diags = append(diags, validateGlobs(prefix+".filesystem.reads", fs.Reads)...)
+			diags = append(diags, validateGlobs(prefix+".filesystem.writes", fs.Writes)...)
+		}
+		for _, arg := range cmd.Args {
+			diags = append(diags, lintDescription(prefix+".args["+arg.Name+"].description", arg.Description)...)
+			diags = append(diags, validateDeprecation(prefix+".args["+arg.Name+"].deprecated", arg.Deprecated)...)
+		}
+		diags = append(diags, validateArgs(cmd)...)
+		diags = append(diags, validateConditions(cmd)...)
+		diags = append(diags, validateFiles(cmd)...)
+		if cmd.Stdin != nil {
+			diags = append(diags, validateJSONSchema(prefix+".stdin.schema", cmd.Stdin.Schema)...)
+		}
+		if cmd.Stdout != nil {
+			diags = append(diags, validateJSONSchema(prefix+".stdout.schema", cmd.Stdout.Schema)...)
+		}
+		diags = append(diags, validatePointers(prefix+".stdin", cmd.Stdin, schema.Definitions)...)
+		diags = append(diags, validatePointers(prefix+".stdout", cmd.Stdout, schema.Definitions)...)
+		diags = append(diags, validateCommandScopes(schema, cmd)...)
+	}
+	return diags
+}
+
+// validatePath checks that a subcommand's path spells out its name, one
+// argv word per element.
+func validatePath(path string, cmd CommandDescriptor) []Diagnostic {
+	if len(cmd.Path) == 0 {
+		return nil
+	}
+	for _, seg := range cmd.Path {
+		if seg == "" || strings.ContainsFunc(seg, unicode.IsSpace) {
+			return []Diagnostic{{Severity: SeverityError, Path: path, Message: fmt.Sprintf("path element %q is not a single word", seg)}}
+		}
+	}
+	if strings.Join(cmd.Path, " ") != cmd.Name {
+		return []Diagnostic{{
+			Severity: SeverityError,
+			Path:     path,
+			Message:  fmt.Sprintf("path %v doesn't match name %q", cmd.Path, cmd.Name),
+		}}
+	}
+	return nil
+}
+
+// validateEndpoints checks that each endpoint is a host, a host wildcard,
+// a host and port, or a URL with a host.
+func validateEndpoints(path string, endpoints []string) []Diagnostic {
+	var diags []Diagnostic
+	for i, e := range endpoints {
+		host := e
+		if strings.Contains(e, "://") {
+			u, err := url.Parse(strings.ReplaceAll(e, "*", "x"))
+			if err != nil || u.Host == "" {
+				host = ""
+			} else {
+				host = u.Host
+			}
+		}
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !endpointHost.MatchString(host) {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     fmt.Sprintf("%s[%d]", path, i),
+				Message:  fmt.Sprintf("%q is not a host, host:port, or URL", e),
+			})
+		}
+	}
+	return diags
+}
+
+// endpointHost matches a hostname, optionally with a leading "*." wildcard,
+// or an IP address.
+var endpointHost = regexp.MustCompile(`^(?:\*\.)?[A-Za-z0-9](?:[A-Za-z0-9.-]*[A-Za-z0-9])?$|^\[?[0-9A-Fa-f:.]+\]?$`)
+
+// validateGlobs checks filesystem access patterns: each must be non-empty
+// and valid path.Match syntax.
+func validateGlobs(path string, globs []string) []Diagnostic {
+	var diags []Diagnostic
+	for i, g := range globs {
+		if _, err := pathpkg.Match(g, ""); g == "" || err != nil {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     fmt.Sprintf("%s[%d]", path, i),
+				Message:  fmt.Sprintf("%q is not a valid glob pattern", g),
+			})
+		}
+	}
+	return diags
+}
+
+// errorCodePattern is the conventional shape of an error code.
+var errorCodePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// validateErrorCodes checks that codes are unique and conventionally
+// named (UPPER_SNAKE_CASE), and that exit codes fit a process status.
+func validateErrorCodes(codes []ErrorCode) []Diagnostic {
+	var diags []Diagnostic
+	seen := map[string]bool{}
+	for i, c := range codes {
+		path := fmt.Sprintf("errorCodes[%d]", i)
+		switch {
+		case c.Code == "":
+			diags = append(diags, Diagnostic{Severity: SeverityError, Path: path + ".code", Message: "code is required"})
+		case seen[c.Code]:
+			diags = append(diags, Diagnostic{Severity: SeverityError, Path: path + ".code", Message: fmt.Sprintf("duplicate code %q", c.Code)})
+		case !errorCodePattern.MatchString(c.Code):
+			diags = append(diags, Diagnostic{Severity: SeverityWarning, Path: path + ".code", Message: fmt.Sprintf("%q is not UPPER_SNAKE_CASE", c.Code)})
+		}
+		seen[c.Code] = true
+		if c.ExitCode < 0 || c.ExitCode > 255 {
+			diags = append(diags, Diagnostic{Severity: SeverityError, Path: path + ".exitCode", Message: fmt.Sprintf("exit code %d is outside 0-255", c.ExitCode)})
+		}
+	}
+	return diags
+}
+
+// validateFiles reports external files that resolveFiles couldn't load.
+func validateFiles(cmd CommandDescriptor) []Diagnostic {
+	var diags []Diagnostic
+	unresolved := func(path, file string) {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityError,
+			Path:     path,
+			Message:  fmt.Sprintf("could not load %q", file),
+		})
+	}
+
+	prefix := "commands[" + cmd.Name + "]"
+	for i, ex := range cmd.Examples {
+		if ex.CommandFile != "" {
+			unresolved(fmt.Sprintf("%s.examples[%d].command", prefix, i), ex.CommandFile)
+		}
+		if ex.OutputFile != "" {
+			unresolved(fmt.Sprintf("%s.examples[%d].output", prefix, i), ex.OutputFile)
+		}
+	}
+	if cmd.Stdin != nil && cmd.Stdin.SchemaFile != "" {
+		unresolved(prefix+".stdin.schema", cmd.Stdin.SchemaFile)
+	}
+	if cmd.Stdout != nil && cmd.Stdout.SchemaFile != "" {
+		unresolved(prefix+".stdout.schema", cmd.Stdout.SchemaFile)
+	}
+	return diags
+}
+
+// validateDefinitions checks that every $ref to a tool-level definition,
+// from a command's IO schemas or another definition, names one the tool
+// has, and warns about definitions nothing refers to.
+func validateDefinitions(schema *ToolSchema) []Diagnostic {
+	var diags []Diagnostic
+	used := map[string]bool{}
+	check := func(path string, s map[string]any) {
+		if id, ok := s["$id"].(string); ok && refersToDefinitions(s) {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Path:     path,
+				Message:  fmt.Sprintf("$id %q makes its %s references resolve against that document; inline them with ResolveRefs or drop the $id", id, DefinitionRefPrefix),
+			})
+		}
+		definitionRefs(s, func(name string) {
+			used[name] = true
+			if _, ok := schema.Definitions[name]; !ok {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Path:     path,
+					Message:  fmt.Sprintf("$ref %q names no definition", DefinitionRefPrefix+name),
+				})
+			}
+		})
+	}
+	for _, name := range sortedKeys(schema.Definitions) {
+		diags = append(diags, validateJSONSchema("definitions["+name+"]", schema.Definitions[name])...)
+		check("definitions["+name+"]", schema.Definitions[name])
+	}
+	for _, cmd := range schema.Commands {
+		prefix := "commands[" + cmd.Name + "]"
+		if cmd.Stdin != nil {
+			check(prefix+".stdin.schema", cmd.Stdin.Schema)
+		}
+		if cmd.Stdout != nil {
+			check(prefix+".stdout.schema", cmd.Stdout.Schema)
+		}
+	}
+	for _, name := range sortedKeys(schema.Definitions) {
+		if !used[name] {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Path:     "definitions[" + name + "]",
+				Message:  "definition is never referenced",
+			})
+		}
+	}
+	return diags
+}
+
+// validatePointers checks that an IO descriptor's output pointers are
+// well-formed and lead somewhere its schema declares.
+func validatePointers(path string, io *IODescriptor, defs map[string]map[string]any) []Diagnostic {
+	if io == nil {
+		return nil
+	}
+	var diags []Diagnostic
+	for _, name := range sortedKeys(io.OutputPointers) {
+		p := path + ".outputPointers[" + name + "]"
+		if strings.TrimSpace(name) == "" {
+			diags = append(diags, Diagnostic{Severity: SeverityError, Path: p, Message: "output pointer has an empty name"})
+			continue
+		}
+		pointer := io.OutputPointers[name]
+		tokens, err := splitPointer(pointer)
+		if err != nil {
+			diags = append(diags, Diagnostic{Severity: SeverityError, Path: p, Message: strings.TrimPrefix(err.Error(), "mtp: ")})
+			continue
+		}
+		if io.Schema == nil {
+			continue
+		}
+		if err := schemaAt(io.Schema, tokens, defs); err != nil {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     p,
+				Message:  fmt.Sprintf("%q doesn't match the schema: %v", pointer, err),
+			})
+		}
+	}
+	return diags
+}
+
+// maxDescriptionLength is the length, in characters, past which a
+// description is flagged. Help text this long is rarely written for humans.
+const maxDescriptionLength = 2000
+
+// injectionPhrases match instructions aimed at a model rather than a user.
+var injectionPhrases = regexp.MustCompile(`(?i)\b(?:` +
+	`(?:ignore|disregard|forget)\s+(?:all\s+|any\s+)?(?:the\s+)?(?:previous|prior|above|earlier|preceding)\s+(?:instructions|prompts?|messages|context)` +
+	`|you\s+are\s+now\s+(?:a|an|in)\b` +
+	`|new\s+instructions\s*:` +
+	`|(?:reveal|print|output|repeat)\s+(?:your|the)\s+system\s+prompt` +
+	`|do\s+not\s+(?:tell|inform|mention\s+(?:this\s+)?to)\s+the\s+user` +
+	`)|</?(?:system|assistant|user)>|\[/?INST\]`)
+
+// activeContent matches HTML that could execute or load remote content,
+// and markdown images, which render as a fetch of an arbitrary URL.
+var activeContent = regexp.MustCompile(`(?i)<\s*/?\s*(?:script|iframe|object|embed|img|svg|style|link|meta|form)\b|javascript:|\bon[a-z]+\s*=\s*["']|!\[[^\]]*\]\([^)]*\)`)
+
+// lintDescription screens one description; path locates it in the schema.
+func lintDescription(path, desc string) []Diagnostic {
+	if desc == "" {
+		return nil
+	}
+
+	var diags []Diagnostic
+	if n := utf8.RuneCountInString(desc); n > maxDescriptionLength {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Path:     path,
+			Message:  fmt.Sprintf("description is %d characters (limit %d)", n, maxDescriptionLength),
+		})
+	}
+	if m := injectionPhrases.FindString(desc); m != "" {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Path:     path,
+			Message:  fmt.Sprintf("description contains instruction-like text %q", m),
+		})
+	}
+	if m := activeContent.FindString(desc); m != "" {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Path:     path,
+			Message:  fmt.Sprintf("description contains markup or script %q", m),
+		})
+	}
+	return diags
+}
+
+// validateDeprecation checks that a sunset date is a calendar date, so
+// consumers can compare it without guessing the format.
+func validateDeprecation(path string, d *Deprecation) []Diagnostic {
+	if d == nil || d.SunsetDate == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.DateOnly, d.SunsetDate); err != nil {
+		return []Diagnostic{{
+			Severity: SeverityError,
+			Path:     path + ".sunsetDate",
+			Message:  fmt.Sprintf("sunset date %q is not in YYYY-MM-DD form", d.SunsetDate),
+		}}
+	}
+	return nil
+}
+
+// validateReplacements checks that a deprecated command's replacedBy names
+// another command in the schema, and a deprecated arg's names another arg
+// of the same command, so clients can rewrite calls to the successor.
+func validateReplacements(schema *ToolSchema, cmd CommandDescriptor) []Diagnostic {
+	var diags []Diagnostic
+	prefix := "commands[" + cmd.Name + "]"
+	if d := cmd.Deprecated; d != nil && d.ReplacedBy != "" {
+		found := d.ReplacedBy != cmd.Name && slices.ContainsFunc(schema.Commands, func(c CommandDescriptor) bool {
+			return c.Name == d.ReplacedBy
+		})
+		if !found {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     prefix + ".deprecated.replacedBy",
+				Message:  fmt.Sprintf("replacement %q is not another command in the schema", d.ReplacedBy),
+			})
+		}
+	}
+	for _, arg := range cmd.Args {
+		d := arg.Deprecated
+		if d == nil || d.ReplacedBy == "" {
+			continue
+		}
+		found := d.ReplacedBy != arg.Name && slices.ContainsFunc(cmd.Args, func(a ArgDescriptor) bool {
+			return a.Name == d.ReplacedBy
+		})
+		if !found {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     prefix + ".args[" + arg.Name + "].deprecated.replacedBy",
+				Message:  fmt.Sprintf("replacement %q is not another argument of %s", d.ReplacedBy, cmd.Name),
+			})
+		}
+	}
+	return diags
+}
+
+// validateSeeAlso checks that a command's cross references name other
+// commands in the schema, each once.
+func validateSeeAlso(schema *ToolSchema, cmd CommandDescriptor) []Diagnostic {
+	var diags []Diagnostic
+	for i, name := range cmd.SeeAlso {
+		path := fmt.Sprintf("commands[%s].seeAlso[%d]", cmd.Name, i)
+		found := name != cmd.Name && slices.ContainsFunc(schema.Commands, func(c CommandDescriptor) bool {
+			return c.Name == name
+		})
+		switch {
+		case !found:
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     path,
+				Message:  fmt.Sprintf("%q is not another command in the schema", name),
+			})
+		case slices.Contains(cmd.SeeAlso[:i], name):
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Path:     path,
+				Message:  fmt.Sprintf("%q is listed more than once", name),
+			})
+		}
+	}
+	return diags
+}
+
+// currencyCode matches an ISO 4217 currency code.
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// validateCost checks that a cost estimate is usable for budgeting: a
+// non-negative amount in a known currency, on a command that is billable.
+func validateCost(path string, c *Cost) []Diagnostic {
+	if c == nil {
+		return nil
+	}
+	var diags []Diagnostic
+	if c.Estimate < 0 {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityError,
+			Path:     path + ".estimate",
+			Message:  fmt.Sprintf("estimate %g is negative", c.Estimate),
+		})
+	}
+	if c.Currency != "" && !currencyCode.MatchString(c.Currency) {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityError,
+			Path:     path + ".currency",
+			Message:  fmt.Sprintf("currency %q is not an ISO 4217 code like \"USD\"", c.Currency),
+		})
+	}
+	if c.Estimate != 0 && c.Currency == "" {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Path:     path + ".currency",
+			Message:  "estimate has no currency",
+		})
+	}
+	if !c.Billable && (c.Estimate != 0 || c.Currency != "" || c.Unit != "") {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Path:     path + ".billable",
+			Message:  "cost details on a command that isn't billable",
+		})
+	}
+	return diags
+}
+
+// validateLocks checks that lock names are non-empty and listed once. Hosts
+// compare names exactly, so a near-duplicate like "State-File" next to
+// "statefile" is worth a warning too.
+func validateLocks(path string, locks []string) []Diagnostic {
+	var diags []Diagnostic
+	seen := map[string]string{}
+	for i, name := range locks {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		if strings.TrimSpace(name) == "" {
+			diags = append(diags, Diagnostic{Severity: SeverityError, Path: p, Message: "lock name is empty"})
+			continue
+		}
+		key := strings.ToLower(strings.NewReplacer("-", "", "_", "", ".", "").Replace(name))
+		switch prev, ok := seen[key]; {
+		case ok && prev == name:
+			diags = append(diags, Diagnostic{Severity: SeverityError, Path: p, Message: fmt.Sprintf("lock %q is listed twice", name)})
+		case ok:
+			diags = append(diags, Diagnostic{Severity: SeverityWarning, Path: p, Message: fmt.Sprintf("lock %q differs from %q only in case or punctuation; hosts treat them as different locks", name, prev)})
+		default:
+			seen[key] = name
+		}
+	}
+	return diags
+}
+
+// validateChangelog checks that each entry names its version and has a
+// calendar date, if any.
+func validateChangelog(entries []ChangeEntry) []Diagnostic {
+	var diags []Diagnostic
+	for i, e := range entries {
+		path := fmt.Sprintf("changelog[%d]", i)
+		if e.Version == "" {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     path + ".version",
+				Message:  "changelog entry has no version",
+			})
+		}
+		if e.Date == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, e.Date); err != nil {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     path + ".date",
+				Message:  fmt.Sprintf("date %q is not in YYYY-MM-DD form", e.Date),
+			})
+		}
+	}
+	return diags
+}
+
+// sha256Hex matches a hex-encoded SHA-256 digest.
+var sha256Hex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// validateInstall checks that every download can be fetched securely and
+// verified: it needs an https URL, a well-formed SHA-256, and a platform.
+func validateInstall(install *InstallInfo) []Diagnostic {
+	if install == nil {
+		return nil
+	}
+	var diags []Diagnostic
+	for i, d := range install.Downloads {
+		path := fmt.Sprintf("install.downloads[%d]", i)
+		if !strings.HasPrefix(d.URL, "https://") {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     path + ".url",
+				Message:  fmt.Sprintf("download URL %q is not https", d.URL),
+			})
+		}
+		if !sha256Hex.MatchString(d.SHA256) {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     path + ".sha256",
+				Message:  "checksum must be a 64-character hex SHA-256 digest",
+			})
+		}
+		if d.OS == "" || d.Arch == "" {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     path,
+				Message:  "download must name its os and arch",
+			})
+		}
+	}
+	return diags
+}
+
+// validateConditions checks that every requiredIf and conflictsWith
+// condition is well formed and names a flag of the same command.
+func validateConditions(cmd CommandDescriptor) []Diagnostic {
+	var diags []Diagnostic
+	flags := map[string]bool{}
+	for _, arg := range cmd.Args {
+		if isFlag(arg) {
+			flags[argKey(arg)] = true
+		}
+	}
+	for _, arg := range cmd.Args {
+		path := "commands[" + cmd.Name + "].args[" + arg.Name + "]"
+		check := func(field string, conds []string) {
+			for _, cond := range conds {
+				name, _, _, err := parseCondition(cond)
+				switch {
+				case err != nil:
+					diags = append(diags, Diagnostic{Severity: SeverityError, Path: path + "." + field, Message: err.Error()})
+				case !flags[name]:
+					diags = append(diags, Diagnostic{
+						Severity: SeverityError,
+						Path:     path + "." + field,
+						Message:  fmt.Sprintf("condition %q refers to unknown flag --%s", cond, name),
+					})
+				}
+			}
+		}
+		check("requiredIf", arg.RequiredIf)
+		check("conflictsWith", arg.ConflictsWith)
+	}
+	return diags
+}
+
+// validateArgs checks each argument's enum declaration and default: an enum
+// must list its values, a default must be one of them, and a default must
+// be a valid value of the argument's declared type. A unit on a
+// non-numeric argument, or a content hint on a non-string one, is a
+// warning.
+func validateArgs(cmd CommandDescriptor) []Diagnostic {
+	var diags []Diagnostic
+	for _, arg := range cmd.Args {
+		path := "commands[" + cmd.Name + "].args[" + arg.Name + "]"
+
+		if arg.Type == "enum" && len(arg.Values) == 0 {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     path,
+				Message:  "enum has no values",
+			})
+		}
+
+		if arg.Default != nil && !defaultMatchesType(arg.Default, arg.Type) {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     path + ".default",
+				Message:  fmt.Sprintf("default %v is not a valid %s", arg.Default, arg.Type),
+			})
+		}
+
+		if arg.Default != nil && len(arg.Values) > 0 {
+			for _, def := range defaultElements(arg) {
+				if !slices.Contains(arg.Values, def) {
+					diags = append(diags, Diagnostic{
+						Severity: SeverityError,
+						Path:     path + ".default",
+						Message:  fmt.Sprintf("default %q is not one of [%s]", def, strings.Join(arg.Values, " ")),
+					})
+				}
+			}
+		}
+
+		if arg.Unit != "" && arg.Type != "integer" && arg.Type != "number" && arg.Type != "array" {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Path:     path + ".unit",
+				Message:  fmt.Sprintf("unit %q on a %s argument; units describe numbers", arg.Unit, arg.Type),
+			})
+		}
+
+		if arg.ContentHint != "" && arg.Type != "string" && arg.Type != "array" {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Path:     path + ".contentHint",
+				Message:  fmt.Sprintf("content hint %q on a %s argument; hints describe free text", arg.ContentHint, arg.Type),
+			})
+		}
+
+		switch arg.Style {
+		case "":
+		case StyleRepeated, StyleComma:
+			if arg.Type != "array" {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityWarning,
+					Path:     path + ".style",
+					Message:  fmt.Sprintf("style %q on a %s argument; it applies to arrays", arg.Style, arg.Type),
+				})
+			}
+		case StylePresence, StyleExplicit:
+			if arg.Type != "boolean" {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityWarning,
+					Path:     path + ".style",
+					Message:  fmt.Sprintf("style %q on a %s argument; it applies to booleans", arg.Style, arg.Type),
+				})
+			}
+		default:
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     path + ".style",
+				Message:  fmt.Sprintf("unknown style %q; use repeated, comma, presence, or explicit", arg.Style),
+			})
+		}
+	}
+	return diags
+}
+
+// ValidateCommandTree checks a Cobra command tree for problems that can't
+// be seen in the generated schema, such as a flag name defined with
+// different types at two levels of the tree, or two flags sharing a
+// shorthand letter. Commands are named as Describe(root, opts) names them.
+func ValidateCommandTree(root *cobra.Command, opts *DescribeOptions) []Diagnostic {
+	var diags []Diagnostic
+	walkAll(root, func(cmd *cobra.Command) {
+		flags, conflicts := collectFlags(cmd)
+		diags = append(diags, validateFlagNames(commandName(cmd, opts), flags)...)
+		for _, c := range conflicts {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Path:     "commands[" + commandName(cmd, opts) + "].args[--" + c.name + "]",
+				Message: fmt.Sprintf("flag is %s here but %s on %s; clients can't tell which applies",
+					c.kept.Value.Type(), c.dropped.Value.Type(), flagOwner(cmd, c.dropped, opts)),
+			})
+		}
+	})
+	return diags
+}
+
+// validateFlagNames reports flags on the command called name that share a shorthand letter or
+// whose names differ only by case. A shared shorthand is an error: Cobra
+// panics when it merges the flag sets at execution time. A case-only
+// difference works in pflag but is a warning, since many clients fold case
+// when matching argument names.
+func validateFlagNames(name string, flags []*pflag.Flag) []Diagnostic {
+	var diags []Diagnostic
+	shorthands := map[string]*pflag.Flag{}
+	folded := map[string]*pflag.Flag{}
+
+	for _, f := range flags {
+		if skippedFlags[f.Name] {
+			continue
+		}
+		path := "commands[" + name + "].args[--" + f.Name + "]"
+
+		if f.Shorthand != "" {
+			if other, ok := shorthands[f.Shorthand]; ok {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Path:     path,
+					Message:  fmt.Sprintf("shorthand -%s is also used by --%s", f.Shorthand, other.Name),
+				})
+			} else {
+				shorthands[f.Shorthand] = f
+			}
+		}
+
+		key := strings.ToLower(f.Name)
+		if other, ok := folded[key]; ok {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Path:     path,
+				Message:  fmt.Sprintf("name differs from --%s only by case", other.Name),
+			})
+		} else {
+			folded[key] = f
+		}
+	}
+	return diags
+}
+
+// walkAll calls fn for cmd and every visible descendant.
+func walkAll(cmd *cobra.Command, fn func(*cobra.Command)) {
+	fn(cmd)
+	for _, sub := range visibleSubcommands(cmd) {
+		walkAll(sub, fn)
+	}
+}
+
+// commandName returns the schema name for cmd: its space-separated path
+// below the root, or for the root itself "_root" or opts.RootName.
+func commandName(cmd *cobra.Command, opts *DescribeOptions) string {
+	if !cmd.HasParent() {
+		return rootName(opts)
+	}
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+// flagOwner names the command that defines f as a persistent flag, searching
+// cmd and its ancestors.
+func flagOwner(cmd *cobra.Command, f *pflag.Flag, opts *DescribeOptions) string {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.PersistentFlags().Lookup(f.Name) == f {
+			return commandName(c, opts)
+		}
+	}
+	return commandName(cmd, opts)
+}
+
+// defaultElements returns arg's default as the strings to check against its
+// Values: each element of a list default, or the default itself. An enum
+// slice flag's default arrives in pflag's "[a,b]" rendering.
+func defaultElements(arg ArgDescriptor) []string {
+	switch def := arg.Default.(type) {
+	case []string:
+		return def
+	case []any:
+		elems := make([]string, len(def))
+		for i, v := range def {
+			elems[i] = fmt.Sprint(v)
+		}
+		return elems
+	case string:
+		inner, ok := strings.CutPrefix(def, "[")
+		if ok && strings.HasSuffix(inner, "]") && !slices.Contains(arg.Values, def) {
+			if inner = strings.TrimSuffix(inner, "]"); inner == "" {
+				return nil
+			}
+			return strings.Split(inner, ",")
+		}
+	}
+	return []string{fmt.Sprint(arg.Default)}
+}
+
+// defaultMatchesType reports whether def is a valid value for an argument of
+// MTP type typ. Describe emits numeric flag defaults as strings (Cobra's
+// DefValue), so strings that parse as the declared type are accepted.
+// Unknown types accept anything.
+func defaultMatchesType(def any, typ string) bool {
+	switch typ {
+	case "integer":
+		switch v := def.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return true
+		case float64:
+			return v == math.Trunc(v)
+		case json.Number:
+			_, err := v.Int64()
+			return err == nil
+		case string:
+			if _, err := strconv.ParseInt(v, 0, 64); err == nil {
+				return true
+			}
+			_, err := strconv.ParseUint(v, 0, 64)
+			return err == nil
+		}
+		return false
+	case "number":
+		switch v := def.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			return true
+		case json.Number:
+			_, err := v.Float64()
+			return err == nil
+		case string:
+			_, err := strconv.ParseFloat(v, 64)
+			return err == nil
+		}
+		return false
+	case "boolean":
+		switch v := def.(type) {
+		case bool:
+			return true
+		case string:
+			_, err := strconv.ParseBool(v)
+			return err == nil
+		}
+		return false
+	case "array":
+		if s, ok := def.(string); ok {
+			// pflag renders slice defaults as "[a,b]".
+			return strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]")
+		}
+		return reflect.ValueOf(def).Kind() == reflect.Slice
+	case "string", "enum":
+		_, ok := def.(string)
+		return ok
+	}
+	return true
+}
+
+// validateCommandScopes checks that a command's required scopes can all be
+// granted by a single provider. Providers that don't advertise scopes are
+// ignored; if none do, there is nothing to check against.
+func validateCommandScopes(schema *ToolSchema, cmd CommandDescriptor) []Diagnostic {
+	if cmd.Auth == nil || len(cmd.Auth.Scopes) == 0 {
+		return nil
+	}
+	path := "commands[" + cmd.Name + "].auth.scopes"
+
+	if schema.Auth == nil {
+		return []Diagnostic{{
+			Severity: SeverityError,
+			Path:     path,
+			Message:  "command requires scopes but the tool declares no auth config",
+		}}
+	}
+
+	advertised := false
+	for _, p := range schema.Auth.Providers {
+		if len(p.Scopes) == 0 {
+			continue
+		}
+		advertised = true
+		if isSubset(cmd.Auth.Scopes, p.Scopes) {
+			return nil
+		}
+	}
+	if !advertised {
+		return nil
+	}
+
+	return []Diagnostic{{
+		Severity: SeverityError,
+		Path:     path,
+		Message:  fmt.Sprintf("scopes [%s] are not all offered by any single provider", strings.Join(cmd.Auth.Scopes, " ")),
+	}}
+}
+
+// isSubset reports whether every element of sub appears in set.
+func isSubset(sub, set []string) bool {
+	have := make(map[string]bool, len(set))
+	for _, s := range set {
+		have[s] = true
+	}
+	for _, s := range sub {
+		if !have[s] {
+			return false
+		}
+	}
+	return true
+}